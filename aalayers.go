@@ -0,0 +1,33 @@
+package mandel
+
+import "image"
+
+// GenerateAALayers renders each anti-aliasing subpixel position as its own
+// image, before the samples are averaged into pixels. There are
+// AntiAlias×AntiAlias layers, ordered from the top left subpixel of each
+// pixel across and then down, so layer 0 is the top left sample and the
+// last layer is the bottom right.
+func (p *Parameters) GenerateAALayers() ([]*image.NRGBA, error) {
+	if err := p.checkInit("GenerateAALayers"); err != nil {
+		return nil, err
+	}
+	f := p.computeField(p.Continuous)
+	aa := f.AntiAlias
+
+	layers := make([]*image.NRGBA, aa*aa)
+	for i := range layers {
+		layers[i] = image.NewNRGBA(image.Rect(0, 0, p.SizeX, p.SizeY))
+	}
+	p.forRows(p.SizeY, func(row int) {
+		for col := 0; col < p.SizeX; col++ {
+			for sy := 0; sy < aa; sy++ {
+				for sx := 0; sx < aa; sx++ {
+					var sum colorSum
+					sum.add(p.getColor(f.At(col*aa+sx, row*aa+sy)))
+					layers[sy*aa+sx].SetNRGBA(col, row, p.adjust(sum.color()))
+				}
+			}
+		}
+	})
+	return layers, nil
+}
@@ -0,0 +1,79 @@
+package mandel
+
+import "image"
+
+// color difference, in 8-bit channel steps, past which neighboring pixels
+// get the full anti-aliasing grid under AdaptiveAA
+const defaultAdaptiveThreshold = 12
+
+func (p *Parameters) adaptiveThreshold() int {
+	if p.AdaptiveThreshold > 0 {
+		return p.AdaptiveThreshold
+	}
+	return defaultAdaptiveThreshold
+}
+
+// generateAdaptive renders with the full anti-aliasing grid only where the
+// image changes quickly. A first pass colors every pixel from its center
+// sample. Pixels whose color differs from one of their eight neighbors by
+// more than the threshold in any channel are rendered again with every
+// sample; the rest keep the first pass color. Both passes compute the
+// samples of a row as one batch, as calcRow does.
+func (p *Parameters) generateAdaptive() *image.NRGBA {
+	w, h := p.SizeX, p.SizeY
+	first := image.NewNRGBA(image.Rect(0, 0, w, h))
+	p.forRows(h, func(row int) {
+		buf := rowScratch.Get().(*rowSamples)
+		defer rowScratch.Put(buf)
+		if cap(buf.xs) < w {
+			buf.xs, buf.ys, buf.vs = make([]float64, w), make([]float64, w), make([]float64, w)
+		}
+		xs, ys, vs := buf.xs[:w], buf.ys[:w], buf.vs[:w]
+		for col := 0; col < w; col++ {
+			xs[col], ys[col] = p.toPlane(col, row, 0, 0)
+		}
+		p.escapes(p.MaxIterations, xs, ys, vs, p.Continuous)
+		for col, v := range vs {
+			var sum colorSum
+			sum.add(p.getColor(v))
+			first.SetNRGBA(col, row, p.adjust(sum.color()))
+		}
+	})
+
+	threshold := p.adaptiveThreshold()
+	differs := func(a, b []uint8) bool {
+		for i := 0; i < 4; i++ {
+			d := int(a[i]) - int(b[i])
+			if d > threshold || d < -threshold {
+				return true
+			}
+		}
+		return false
+	}
+	edge := func(col, row int) bool {
+		c := first.Pix[first.PixOffset(col, row):]
+		for j := row - 1; j <= row+1; j++ {
+			for i := col - 1; i <= col+1; i++ {
+				if i >= 0 && i < w && j >= 0 && j < h && differs(c, first.Pix[first.PixOffset(i, j):]) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	canvas := p.newCanvas()
+	p.forRows(h, func(row int) {
+		var pixels []image.Point
+		for col := 0; col < w; col++ {
+			if edge(col, row) {
+				pixels = append(pixels, image.Point{col, row})
+				continue
+			}
+			canvas.SetNRGBA(col, row, first.NRGBAAt(col, row))
+			p.report.fastPixel()
+		}
+		p.calcPixels(canvas, pixels)
+	})
+	return canvas
+}
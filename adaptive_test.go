@@ -0,0 +1,98 @@
+package mandel
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+// adaptiveViews are views for comparing AdaptiveAA with the full grid: a
+// default view with wide smooth areas, and one mostly boundary.
+var adaptiveViews = []struct {
+	name      string
+	x, y, mag float64
+}{
+	{"whole set", -0.75, 0, 0.4},
+	{"seahorse valley", -0.7453, 0.1127, 300},
+}
+
+// adaptiveParams are the parameters of a view for the adaptive tests.
+func adaptiveParams(x, y, mag float64, aa int, adaptive bool) Parameters {
+	return Parameters{CenterX: x, CenterY: y, Magnification: mag, MaxIterations: 1000, SizeX: 160, SizeY: 120, AntiAlias: aa, Continuous: true, Palette: DefaultPalette(), AdaptiveAA: adaptive}
+}
+
+// meanError is the mean difference of the channels of two images.
+func meanError(a, b *image.NRGBA) float64 {
+	total := 0
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		total += d
+	}
+	return float64(total) / float64(len(a.Pix))
+}
+
+// TestAdaptiveAA compares AdaptiveAA with the full 3×3 grid and with a
+// single sample per pixel. It must come much closer to the full grid than
+// one sample does, while taking far fewer samples than the full grid.
+func TestAdaptiveAA(t *testing.T) {
+	for _, view := range adaptiveViews {
+		render := func(aa int, adaptive bool) (*image.NRGBA, int64) {
+			var samples int64
+			p := adaptiveParams(view.x, view.y, view.mag, aa, adaptive)
+			p.Report = func(r *RenderReport) { samples = r.Samples }
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			img, err := p.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return img, samples
+		}
+		full, fullSamples := render(3, false)
+		single, _ := render(1, false)
+		adaptive, adaptiveSamples := render(3, true)
+
+		singleError, adaptiveError := meanError(single, full), meanError(adaptive, full)
+		t.Logf("%s: one sample is off by %.3f on average, adaptive by %.3f, with %.0f%% of the samples", view.name, singleError, adaptiveError, 100*float64(adaptiveSamples)/float64(fullSamples))
+		if adaptiveError > singleError/4 {
+			t.Errorf("%s: adaptive is off by %.3f on average, one sample by %.3f", view.name, adaptiveError, singleError)
+		}
+		if adaptiveSamples >= fullSamples*3/4 {
+			t.Errorf("%s: adaptive took %d samples, the full grid %d", view.name, adaptiveSamples, fullSamples)
+		}
+
+		// with one sample per pixel there is nothing to adapt
+		alone, _ := render(1, true)
+		if !bytes.Equal(alone.Pix, single.Pix) {
+			t.Errorf("%s: AdaptiveAA changes a render with one sample per pixel", view.name)
+		}
+	}
+}
+
+// BenchmarkAdaptiveAA renders the views with the full 3×3 grid and with
+// AdaptiveAA.
+func BenchmarkAdaptiveAA(b *testing.B) {
+	for _, view := range adaptiveViews {
+		for _, adaptive := range []bool{false, true} {
+			p := adaptiveParams(view.x, view.y, view.mag, 3, adaptive)
+			if err := p.Init(); err != nil {
+				b.Fatal(err)
+			}
+			name := view.name + "/full"
+			if adaptive {
+				name = view.name + "/adaptive"
+			}
+			b.Run(name, func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					if _, err := p.Generate(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
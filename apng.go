@@ -0,0 +1,121 @@
+package mandel
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+)
+
+// APNGWriter writes an animated PNG a frame at a time, so an animation
+// never has to be held in memory whole. Every frame covers the whole
+// image and replaces the one before it. Viewers without APNG support
+// show the first frame.
+type APNGWriter struct {
+	w               io.Writer
+	width, height   int
+	frames, written int
+
+	// sequence number of the next fcTL or fdAT chunk
+	seq uint32
+}
+
+// NewAPNGWriter starts an animated PNG of frames frames, each width by
+// height pixels, that plays loops times, or forever if loops is 0.
+func NewAPNGWriter(w io.Writer, width, height, frames, loops int) (*APNGWriter, error) {
+	if width < 1 || height < 1 || frames < 1 || loops < 0 {
+		return nil, fmt.Errorf("an animation needs a size, at least one frame, and a loop count that is not negative")
+	}
+	if _, err := io.WriteString(w, "\x89PNG\r\n\x1a\n"); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], uint32(width))
+	binary.BigEndian.PutUint32(header[4:], uint32(height))
+	header[8] = 8 // bits per channel
+	header[9] = 6 // truecolor with alpha, not premultiplied
+	if err := writePNGChunk(w, "IHDR", header); err != nil {
+		return nil, err
+	}
+	control := make([]byte, 8)
+	binary.BigEndian.PutUint32(control[0:], uint32(frames))
+	binary.BigEndian.PutUint32(control[4:], uint32(loops))
+	if err := writePNGChunk(w, "acTL", control); err != nil {
+		return nil, err
+	}
+	return &APNGWriter{w: w, width: width, height: height, frames: frames}, nil
+}
+
+// WriteFrame adds the next frame, shown for delay hundredths of a second.
+// The first frame doubles as the still image.
+func (a *APNGWriter) WriteFrame(img *image.NRGBA, delay int) error {
+	if a.written == a.frames {
+		return fmt.Errorf("the animation already has all %d frames", a.frames)
+	}
+	if img.Rect.Dx() != a.width || img.Rect.Dy() != a.height {
+		return fmt.Errorf("frame is %dx%d but the animation is %dx%d", img.Rect.Dx(), img.Rect.Dy(), a.width, a.height)
+	}
+	control := make([]byte, 26)
+	binary.BigEndian.PutUint32(control[0:], a.seq)
+	binary.BigEndian.PutUint32(control[4:], uint32(a.width))
+	binary.BigEndian.PutUint32(control[8:], uint32(a.height))
+	binary.BigEndian.PutUint16(control[20:], uint16(delay))
+	binary.BigEndian.PutUint16(control[22:], 100)
+	// the offsets and the dispose and blend operations stay zero: the
+	// frame is drawn at the corner over nothing, and left in place
+	if err := writePNGChunk(a.w, "fcTL", control); err != nil {
+		return err
+	}
+	a.seq++
+
+	var data io.Writer = idatWriter{a.w}
+	if a.written > 0 {
+		data = fdatWriter{a}
+	}
+	s := &pngStream{
+		w:    a.w,
+		prev: make([]byte, 4*a.width),
+		out:  make([]byte, 1+4*a.width),
+	}
+	s.buf = bufio.NewWriterSize(data, 1<<16)
+	s.z = zlib.NewWriter(s.buf)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		start := img.PixOffset(img.Rect.Min.X, y)
+		if err := s.writeRow(img.Pix[start : start+4*a.width]); err != nil {
+			return err
+		}
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	a.written++
+	return nil
+}
+
+// Close ends the file. It is an error to close an animation that is
+// missing some of its frames.
+func (a *APNGWriter) Close() error {
+	if a.written < a.frames {
+		return fmt.Errorf("the animation has %d of its %d frames", a.written, a.frames)
+	}
+	return writePNGChunk(a.w, "IEND", nil)
+}
+
+// fdatWriter wraps everything written to it in an fdAT chunk, the IDAT
+// of the frames after the first, which carries a sequence number.
+type fdatWriter struct {
+	a *APNGWriter
+}
+
+func (fw fdatWriter) Write(data []byte) (int, error) {
+	chunk := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(chunk, fw.a.seq)
+	copy(chunk[4:], data)
+	if err := writePNGChunk(fw.a.w, "fdAT", chunk); err != nil {
+		return 0, err
+	}
+	fw.a.seq++
+	return len(data), nil
+}
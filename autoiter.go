@@ -0,0 +1,66 @@
+package mandel
+
+import "math"
+
+const (
+	// bounds on automatically chosen iteration limits
+	autoMinIterations = 100
+	autoMaxIterations = 1 << 24
+
+	// probes iterate for up to this many times the estimated limit
+	autoProbeFactor = 16
+)
+
+// autoIterations chooses MaxIterations when it is left at 0: an estimate
+// from the magnification, refined with probe points when IterationProbes
+// asks for them and the view can be iterated in float64.
+func (p *Parameters) autoIterations() int {
+	limit := estimateIterations(p.Magnification)
+	if p.IterationProbes > 0 && !p.perturbed() && p.Fractal != "newton" {
+		limit = p.probeIterations(limit, p.IterationProbes)
+	}
+	return limit
+}
+
+// estimateIterations guesses an iteration limit from the magnification
+// alone. Detail near the boundary takes more iterations to resolve the
+// deeper the zoom, growing a little faster than the number of digits of
+// magnification.
+func estimateIterations(magnification float64) int {
+	digits := math.Max(0, math.Log10(math.Abs(magnification)))
+	return clampIterations(250 * math.Pow(1+digits, 1.5))
+}
+
+// probeIterations refines an estimated limit by iterating an n×n grid of
+// points across the view for up to autoProbeFactor times the estimate.
+// The limit becomes twice the most iterations an escaping probe needed,
+// which keeps slowly escaping detail without wasting iterations on views
+// that escape quickly. If no probe escapes, the estimate stands.
+func (p *Parameters) probeIterations(estimate, n int) int {
+	q := *p
+	q.MaxIterations = clampIterations(float64(estimate) * autoProbeFactor)
+	q.CurvatureColor = false
+	most := make([]float64, n)
+	q.forRows(n, func(j int) {
+		row := (2*j + 1) * p.SizeY / (2 * n)
+		for i := 0; i < n; i++ {
+			col := (2*i + 1) * p.SizeX / (2 * n)
+			x, y := q.toPlane(col, row, 0, 0)
+			most[j] = math.Max(most[j], q.escape(q.MaxIterations, x, y, false))
+		}
+	})
+	highest := 0.0
+	for _, v := range most {
+		highest = math.Max(highest, v)
+	}
+	if highest == 0 {
+		return estimate
+	}
+	return clampIterations(2 * highest)
+}
+
+// clampIterations rounds an iteration limit and keeps it within the
+// bounds for automatic limits.
+func clampIterations(iters float64) int {
+	return int(math.Max(autoMinIterations, math.Min(math.Round(iters), autoMaxIterations)))
+}
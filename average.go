@@ -0,0 +1,117 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// squared escape radius for the average colorings, large enough that the
+// interpolation between the last two averages hides the iteration bands
+const averageBailout = 1e8
+
+// initAverage checks the settings for stripe and triangle inequality
+// average coloring.
+func (p *Parameters) initAverage() error {
+	if p.Coloring != "stripe" && p.Coloring != "tia" {
+		return nil
+	}
+	if !p.quadratic() || p.norm != nil {
+		return fmt.Errorf("%s coloring only works with z² + c and a circular bailout", p.Coloring)
+	}
+	if p.StripeDensity < 0 {
+		return fmt.Errorf("stripe density must not be negative")
+	}
+	return nil
+}
+
+// averageStat is the statistic that average coloring takes of each
+// point z of an orbit, given the point before it and c.
+func (p *Parameters) averageStat() func(z, prev, c complex128) float64 {
+	if p.Coloring == "tia" {
+		return triangleStat
+	}
+	density := p.StripeDensity
+	if density == 0 {
+		density = 5
+	}
+	return func(z, prev, c complex128) float64 {
+		return 0.5*math.Sin(density*cmplx.Phase(z)) + 0.5
+	}
+}
+
+// triangleStat places |z| = |prev² + c| between the least and the most
+// the triangle inequality allows for it, from 0 to 1.
+func triangleStat(z, prev, c complex128) float64 {
+	m := real(prev)*real(prev) + imag(prev)*imag(prev)
+	mc := cmplx.Abs(c)
+	lo, hi := math.Abs(m-mc), m+mc
+	if hi == lo {
+		return 0
+	}
+	return (cmplx.Abs(z) - lo) / (hi - lo)
+}
+
+// mandelAverage iterates z² + c like mandel, and returns the average of
+// stat over the points of the orbit after the first, up to and including
+// the one that escapes, along with whether it escaped. The average is
+// interpolated toward the average without the last point by how far past
+// the escape radius that point went, which makes it continuous across the
+// iteration bands.
+func mandelAverage(maxIters int, x, y float64, stat func(z, prev, c complex128) float64, bailout float64) (avg float64, escaped bool) {
+	c := complex(x, y)
+	a, b := x, y
+	sum, last, n := 0.0, 0.0, 0
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if mag2 := a2 + b2; mag2 >= bailout {
+			if n == 0 {
+				return 0, true
+			}
+			avg = sum / float64(n)
+			prev := avg
+			if n > 1 {
+				prev = (sum - last) / float64(n-1)
+			}
+			weight := 1 + math.Log2(math.Log(bailout)/math.Log(mag2))
+			return prev + weight*(avg-prev), true
+		}
+		pa, pb := a, b
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+		last = stat(complex(a, b), complex(pa, pb), c)
+		sum += last
+		n++
+	}
+	return 0, false
+}
+
+// averagePixel is pixelSum for stripe and triangle inequality average
+// coloring. The average of each escaping sample runs through the palette
+// once; interior points take InsideColor.
+func (p *Parameters) averagePixel(col, row int, sum *colorSum) (inside bool) {
+	stat := p.averageStat()
+	inside = true
+	for k := 0; k < p.samples; k++ {
+		xoffset, yoffset := p.pixelSample(col, row, k)
+		x, y := p.toPlane(col, row, xoffset, yoffset)
+		avg, escaped := mandelAverage(p.MaxIterations, x, y, stat, averageBailout)
+		inside = inside && !escaped
+		if !escaped {
+			sum.sample(p.sampleColor(0))
+			continue
+		}
+		if p.clearSample(false) {
+			sum.add(0, 0, 0, 0)
+			continue
+		}
+		level := math.Max(math.Min(avg, 1), math.SmallestNonzeroFloat64)
+		if math.IsNaN(level) {
+			level = math.SmallestNonzeroFloat64
+		}
+		sum.sample(p.levelColor(level))
+	}
+	return inside
+}
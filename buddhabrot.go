@@ -0,0 +1,207 @@
+package mandel
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// samples drawn from each random source in GenerateBuddhabrot; chunks are
+// seeded from Seed and their index, so the image does not depend on the
+// number of workers
+const buddhabrotChunk = 1 << 14
+
+// GenerateBuddhabrot renders the Buddhabrot: the density of the orbits of
+// escaping points rather than the escape time of each pixel. It draws
+// samples random points c from the square [-2, 2]², and for each one that
+// escapes within MaxIterations, but not before BuddhabrotMin, it counts a
+// hit at every pixel its orbit visits before escaping. Counts are divided
+// by the largest count and spread across the palette once, so pixels that
+// no orbit reaches take the first palette color. The random points come
+// from Seed. AntiAlias and exponential maps are ignored, and contours,
+// labels, and cropping are left out.
+func (p *Parameters) GenerateBuddhabrot(samples int) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateBuddhabrot"); err != nil {
+		return nil, err
+	}
+	counts := p.buddhabrotCounts(samples, []int{p.MaxIterations})[0]
+	return p.colorHits(counts, false), nil
+}
+
+// GenerateAntiBuddhabrot renders the anti-Buddhabrot: the density of the
+// orbits of the points that never escape, traced for MaxIterations
+// iterations each. Most of those orbits settle onto a cycle, so counts are
+// spread across the palette on a log scale to show the paths that lead
+// there. Otherwise it works like GenerateBuddhabrot.
+func (p *Parameters) GenerateAntiBuddhabrot(samples int) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateAntiBuddhabrot"); err != nil {
+		return nil, err
+	}
+	return p.colorHits(p.insideCounts(samples), true), nil
+}
+
+// colorHits colors orbit counts with the palette, from the first color
+// for pixels that no orbit reaches to the last for the most visited.
+func (p *Parameters) colorHits(counts []uint32, logScale bool) *image.NRGBA {
+	w, h := p.SizeX, p.SizeY
+	most := mostHits(counts)
+
+	// color by levels in (0, 1], as distance coloring does
+	q := *p
+	q.Coloring = "distance"
+	q.Transparent = ""
+	canvas := p.newCanvas()
+	p.forRows(h, func(row int) {
+		for col := 0; col < w; col++ {
+			level := math.SmallestNonzeroFloat64
+			if n := counts[row*w+col]; n > 0 {
+				level = math.Max(hitLevel(n, most, logScale), level)
+			}
+			var sum colorSum
+			sum.add(q.getColor(level))
+			canvas.SetNRGBA(col, row, q.adjust(sum.color()))
+		}
+	})
+	return canvas
+}
+
+// hitLevel scales a count by the largest count to [0, 1].
+func hitLevel(n, most uint32, logScale bool) float64 {
+	if logScale {
+		return math.Log1p(float64(n)) / math.Log1p(float64(most))
+	}
+	return float64(n) / float64(most)
+}
+
+// GenerateNebulabrot renders the Nebulabrot: three Buddhabrots in the
+// red, green, and blue channels, counting the orbits that escape within
+// limits[0], limits[1], and limits[2] iterations, in place of
+// MaxIterations. A limit of 0 means MaxIterations. Each channel is scaled
+// by its own largest count, and the palette is not used. Otherwise it
+// works like GenerateBuddhabrot.
+func (p *Parameters) GenerateNebulabrot(samples int, limits [3]int) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateNebulabrot"); err != nil {
+		return nil, err
+	}
+	w, h := p.SizeX, p.SizeY
+	for i, n := range limits {
+		if n <= 0 {
+			limits[i] = p.MaxIterations
+		}
+	}
+	counts := p.buddhabrotCounts(samples, limits[:])
+	var most [3]uint32
+	for i := range counts {
+		most[i] = mostHits(counts[i])
+	}
+
+	canvas := p.newCanvas()
+	p.forRows(h, func(row int) {
+		for col := 0; col < w; col++ {
+			var rgb [3]uint8
+			for i := range rgb {
+				if most[i] > 0 {
+					rgb[i] = clamp8(int(float64(counts[i][row*w+col])/float64(most[i])*255 + 0.5))
+				}
+			}
+			canvas.SetNRGBA(col, row, p.adjust(color.NRGBA{rgb[0], rgb[1], rgb[2], 255}))
+		}
+	})
+	return canvas, nil
+}
+
+// buddhabrotCounts draws samples random points and returns, for each
+// iteration limit, the number of times each pixel is visited by the
+// orbits that escape within that limit but not before BuddhabrotMin.
+func (p *Parameters) buddhabrotCounts(samples int, limits []int) [][]uint32 {
+	counts := make([][]uint32, len(limits))
+	maxIters := 0
+	for i, n := range limits {
+		counts[i] = make([]uint32, p.SizeX*p.SizeY)
+		if n > maxIters {
+			maxIters = n
+		}
+	}
+	p.traceOrbits(samples, maxIters, func(escaped int, hit [][]uint32) [][]uint32 {
+		if escaped == 0 || escaped < p.BuddhabrotMin {
+			return hit
+		}
+		for i, limit := range limits {
+			if escaped <= limit {
+				hit = append(hit, counts[i])
+			}
+		}
+		return hit
+	})
+	return counts
+}
+
+// insideCounts draws samples random points and returns the number of
+// times each pixel is visited by the orbits that never escape.
+func (p *Parameters) insideCounts(samples int) []uint32 {
+	counts := make([]uint32, p.SizeX*p.SizeY)
+	p.traceOrbits(samples, p.MaxIterations, func(escaped int, hit [][]uint32) [][]uint32 {
+		if escaped == 0 {
+			hit = append(hit, counts)
+		}
+		return hit
+	})
+	return counts
+}
+
+// traceOrbits draws samples random points c from the square [-2, 2]² and
+// iterates each for up to maxIters iterations. pick appends to hit the
+// counts that the orbit adds to, given the iteration it escaped on, or 0
+// if it never did. The orbit then counts a hit in each of them at every
+// pixel it visits before it escapes, or in all maxIters of its points if
+// it never does.
+func (p *Parameters) traceOrbits(samples, maxIters int, pick func(escaped int, hit [][]uint32) [][]uint32) {
+	w, h := p.SizeX, p.SizeY
+	chunks := (samples + buddhabrotChunk - 1) / buddhabrotChunk
+	p.forRows(chunks, func(chunk int) {
+		rng := rand.New(rand.NewSource(int64(jitterHash(uint64(p.Seed), uint64(chunk)))))
+		n := buddhabrotChunk
+		if rest := samples - chunk*buddhabrotChunk; rest < n {
+			n = rest
+		}
+		var hit [][]uint32
+		for k := 0; k < n; k++ {
+			x, y := 4*rng.Float64()-2, 4*rng.Float64()-2
+			escaped := int(mandel(maxIters, x, y, nil, 4))
+			hit = pick(escaped, hit[:0])
+			if len(hit) == 0 {
+				continue
+			}
+
+			// trace the orbit again, now that it is known where it ends
+			steps := escaped - 1
+			if escaped == 0 {
+				steps = maxIters
+			}
+			a, b := x, y
+			for iters := 1; iters <= steps; iters++ {
+				fx, fy := p.toPixel(a, b)
+				if fx >= 0 && fx < float64(w) && fy >= 0 && fy < float64(h) {
+					for _, c := range hit {
+						atomic.AddUint32(&c[int(fy)*w+int(fx)], 1)
+					}
+				}
+				a2, b2, ab := float64(a*a), float64(b*b), float64(a*b)
+				a, b = a2-b2+x, ab+ab+y
+			}
+		}
+	})
+}
+
+// mostHits is the largest count in a Buddhabrot channel.
+func mostHits(counts []uint32) uint32 {
+	var most uint32
+	for _, n := range counts {
+		if n > most {
+			most = n
+		}
+	}
+	return most
+}
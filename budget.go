@@ -0,0 +1,85 @@
+package mandel
+
+import (
+	"image"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// iteration limit for the first round of a budgeted render
+	budgetStartIterations = 64
+
+	// a round in which fewer than this fraction of the remaining interior
+	// samples escape means more iterations are unlikely to change much
+	budgetSettled = 0.0005
+)
+
+// GenerateWithinBudget renders the image in rounds, starting with a low
+// iteration limit and doubling it while time remains, up to MaxIterations.
+// Samples that escape keep their values from one round to the next, so
+// each round only revisits samples that still look like part of the set.
+// Rendering stops when the next round is not expected to finish in time or
+// when a round finds almost no new escapes. Since the stopping point
+// depends on timing, the output is the one render in this package that can
+// differ from run to run with the same parameters.
+func (p *Parameters) GenerateWithinBudget(d time.Duration) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateWithinBudget"); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(d)
+
+	q := *p
+	if q.MaxIterations > budgetStartIterations {
+		q.MaxIterations = budgetStartIterations
+	}
+	start := time.Now()
+	f := q.computeField(q.Continuous)
+	elapsed := time.Since(start)
+
+	for q.MaxIterations < p.MaxIterations {
+		// each round costs about twice the last one
+		if time.Now().Add(2 * elapsed).After(deadline) {
+			break
+		}
+		start = time.Now()
+		next := q.MaxIterations * 2
+		if next > p.MaxIterations {
+			next = p.MaxIterations
+		}
+		escaped, interior := q.refineField(f, next)
+		q.MaxIterations = next
+		elapsed = time.Since(start)
+
+		if float64(escaped) <= budgetSettled*float64(escaped+interior) {
+			break
+		}
+	}
+
+	return f.Colorize(&q), nil
+}
+
+// refineField iterates the interior samples of a field again with a higher
+// iteration limit. It reports how many of them escaped and how many remain.
+func (p *Parameters) refineField(f *Field, maxIters int) (escaped, interior int) {
+	var escapedCount, interiorCount int64
+	p.forRows(f.Height, func(j int) {
+		var e, n int64
+		for i := 0; i < f.Width; i++ {
+			if f.Values[j*f.Width+i] != 0 {
+				continue
+			}
+			x, y := p.samplePoint(i, j)
+			v := p.escape(maxIters, x, y, p.Continuous)
+			f.Values[j*f.Width+i] = v
+			if v != 0 {
+				e++
+			} else {
+				n++
+			}
+		}
+		atomic.AddInt64(&escapedCount, e)
+		atomic.AddInt64(&interiorCount, n)
+	})
+	return int(escapedCount), int(interiorCount)
+}
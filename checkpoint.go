@@ -0,0 +1,322 @@
+package mandel
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// checkpoint files start with a magic string and a version number,
+// followed by a header and one record for each finished band of rows:
+//
+//	parameters hash     uint64
+//	width, height       uint32
+//
+//	first row, rows     uint32
+//	data length         uint32
+//	data                zlib-compressed NRGBA pixels
+//
+// All numbers are little endian. Records are only ever appended, so a
+// crash can at worst leave a torn record at the end, which is dropped.
+const (
+	checkpointMagic   = "MCKP"
+	checkpointVersion = 1
+
+	// the most pixels a checkpoint may hold, a gigabyte of image
+	checkpointMaxPixels = 1 << 28
+)
+
+// GenerateCheckpointed renders the image for renders that take hours. It
+// works a band of BandRows rows at a time, like GenerateTo, and appends
+// each finished band to the checkpoint file at path. If the file already
+// holds bands rendered with the same parameters, they are loaded instead
+// of rendered again, so a render that was cancelled or crashed picks up
+// where it stopped; a checkpoint made with different parameters is an
+// error, and so is a Colorer or System that is not a CacheKeyer, since
+// nothing would tell whether the bands were rendered with it. The file is left in place, and ReadCheckpoint recovers the
+// finished part of the image from it. Bands are rendered like
+// GenerateRegion, so options that look at the whole image are left out,
+// as are contours, labels, and cropping.
+func (p *Parameters) GenerateCheckpointed(ctx context.Context, path string) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateCheckpointed"); err != nil {
+		return nil, err
+	}
+	q := *p
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+		defer q.progress.stop()
+	}
+	render := func(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error) {
+		q := q
+		q.ctx = ctx
+		return q.generateRegion(rect), ctx.Err()
+	}
+	return q.generateCheckpointed(ctx, path, 1, render)
+}
+
+// GenerateCheckpointedFunc is GenerateCheckpointed with the bands rendered
+// by render instead, up to parallel of them at once, so they can be
+// handed out to other machines. Bands may finish in any order; each one
+// is added to the checkpoint once the bands above it are done. render
+// must return an image with the bounds it was given, and an error from it
+// ends the render, cancelling the context passed to the other bands.
+func (p *Parameters) GenerateCheckpointedFunc(ctx context.Context, path string, parallel int, render func(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error)) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateCheckpointedFunc"); err != nil {
+		return nil, err
+	}
+	if parallel < 1 {
+		return nil, fmt.Errorf("at least one band must render at a time")
+	}
+	q := *p
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+		defer q.progress.stop()
+	}
+	count := func(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error) {
+		region, err := render(ctx, rect)
+		if err == nil {
+			for row := rect.Min.Y; row < rect.Max.Y; row++ {
+				q.progress.rowDone()
+			}
+		}
+		return region, err
+	}
+	return q.generateCheckpointed(ctx, path, parallel, count)
+}
+
+// generateCheckpointed renders the bands that the checkpoint at path is
+// missing with render, keeping up to parallel of them going at once.
+func (p *Parameters) generateCheckpointed(ctx context.Context, path string, parallel int, render func(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error)) (*image.NRGBA, error) {
+	band := p.BandRows
+	if band <= 0 {
+		band = defaultBandRows
+	}
+	hash, ok := p.checkpointHash()
+	if !ok {
+		return nil, fmt.Errorf("a Colorer or System needs a CacheKey method to be checkpointed")
+	}
+
+	fp, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	canvas, rows := p.newCanvas(), 0
+	info, err := fp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > 0 {
+		saved, size, err := readCheckpoint(fp, canvas.Rect)
+		if err != nil {
+			return nil, fmt.Errorf("error reading checkpoint %s: %v", path, err)
+		}
+		if saved.hash != hash {
+			return nil, fmt.Errorf("checkpoint %s was made with different parameters", path)
+		}
+		canvas, rows = saved.img, saved.rows
+
+		// drop a record torn by a crash
+		if err := fp.Truncate(size); err != nil {
+			return nil, err
+		}
+		if _, err := fp.Seek(size, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := writeCheckpointHeader(fp, hash, p.SizeX, p.SizeY); err != nil {
+			return nil, err
+		}
+	}
+
+	p.progress.expect(p.SizeY - rows)
+
+	type result struct {
+		region *image.NRGBA
+		err    error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, parallel)
+	done := map[int]*image.NRGBA{}
+	next, running := rows, 0
+	for rows < p.SizeY {
+		for ; running < parallel && next < p.SizeY; next += band {
+			bottom := next + band
+			if bottom > p.SizeY {
+				bottom = p.SizeY
+			}
+			rect := image.Rect(0, next, p.SizeX, bottom)
+			running++
+			go func() {
+				region, err := render(ctx, rect)
+				if err == nil && (region == nil || region.Rect != rect || region.Stride != 4*rect.Dx()) {
+					err = fmt.Errorf("band %v was rendered with the wrong bounds", rect)
+				}
+				results <- result{region, err}
+			}()
+		}
+		r := <-results
+		running--
+		if r.err != nil {
+			return nil, r.err
+		}
+		done[r.region.Rect.Min.Y] = r.region
+
+		// write out the bands that are now next in line
+		for region := done[rows]; region != nil; region = done[rows] {
+			delete(done, rows)
+			top, height := region.Rect.Min.Y, region.Rect.Dy()
+			copy(canvas.Pix[canvas.PixOffset(0, top):], region.Pix)
+			if err := writeCheckpointBand(fp, top, height, region.Pix); err != nil {
+				return nil, err
+			}
+			if err := fp.Sync(); err != nil {
+				return nil, err
+			}
+			rows += height
+		}
+	}
+	return canvas, fp.Close()
+}
+
+// ReadCheckpoint loads the image from a checkpoint file written by
+// GenerateCheckpointed, along with the number of rows from the top that
+// were finished. The rest of the image is left blank.
+func ReadCheckpoint(r io.Reader) (*image.NRGBA, int, error) {
+	saved, _, err := readCheckpoint(r, image.Rectangle{})
+	if err != nil {
+		return nil, 0, err
+	}
+	return saved.img, saved.rows, nil
+}
+
+type checkpoint struct {
+	hash uint64
+	img  *image.NRGBA
+	rows int
+}
+
+// readCheckpoint loads a checkpoint, stopping quietly at the first record
+// that is cut short or does not continue where the last one ended. It
+// also returns the length of the file up to that point. Unless want is
+// empty, the checkpoint must be of an image with those bounds, which is
+// checked before the image is allocated.
+func readCheckpoint(r io.Reader, want image.Rectangle) (*checkpoint, int64, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(checkpointMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, 0, fmt.Errorf("error reading checkpoint header: %v", err)
+	}
+	if string(header[:len(checkpointMagic)]) != checkpointMagic {
+		return nil, 0, fmt.Errorf("not a checkpoint file")
+	}
+	if version := header[len(checkpointMagic)]; version != checkpointVersion {
+		return nil, 0, fmt.Errorf("unsupported checkpoint file version %d", version)
+	}
+	var c checkpoint
+	var dims [2]uint32
+	if err := binary.Read(br, binary.LittleEndian, &c.hash); err != nil {
+		return nil, 0, fmt.Errorf("error reading checkpoint hash: %v", err)
+	}
+	if err := binary.Read(br, binary.LittleEndian, &dims); err != nil {
+		return nil, 0, fmt.Errorf("error reading checkpoint dimensions: %v", err)
+	}
+	width, height := int64(dims[0]), int64(dims[1])
+	if width < 1 || height < 1 || width > checkpointMaxPixels || width*height > checkpointMaxPixels {
+		return nil, 0, fmt.Errorf("invalid checkpoint dimensions %dx%d", width, height)
+	}
+	rect := image.Rect(0, 0, int(width), int(height))
+	if !want.Empty() && rect != want {
+		return nil, 0, fmt.Errorf("checkpoint is of a %dx%d image, not %dx%d", width, height, want.Dx(), want.Dy())
+	}
+	c.img = image.NewNRGBA(rect)
+	size := int64(len(header) + 16)
+
+	for {
+		var record [3]uint32
+		if err := binary.Read(br, binary.LittleEndian, &record); err != nil {
+			break
+		}
+		top, rows, length := int(record[0]), int(record[1]), int64(record[2])
+		if top != c.rows || rows < 1 || top+rows > c.img.Rect.Dy() {
+			break
+		}
+		zr, err := zlib.NewReader(io.LimitReader(br, length))
+		if err != nil {
+			break
+		}
+		start, end := c.img.PixOffset(0, top), c.img.PixOffset(0, top+rows)
+		if _, err := io.ReadFull(zr, c.img.Pix[start:end]); err != nil {
+			break
+		}
+		if _, err := io.Copy(ioutil.Discard, zr); err != nil {
+			break
+		}
+		c.rows += rows
+		size += 12 + length
+	}
+	return &c, size, nil
+}
+
+// writeCheckpointHeader starts a new checkpoint file.
+func writeCheckpointHeader(w io.Writer, hash uint64, width, height int) error {
+	var buf bytes.Buffer
+	buf.WriteString(checkpointMagic)
+	buf.WriteByte(checkpointVersion)
+	for _, v := range []interface{}{hash, uint32(width), uint32(height)} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeCheckpointBand appends the record for a band of rows, written in
+// one piece so that a crash is more likely to leave no trace of it than
+// half of it.
+func writeCheckpointBand(w io.Writer, top, rows int, pix []byte) error {
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	if _, err := zw.Write(pix); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, v := range []uint32{uint32(top), uint32(rows), uint32(data.Len())} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.Write(data.Bytes())
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// checkpointHash identifies the parameters that determine the pixels of
+// a checkpointed render: everything that is saved with the parameters,
+// apart from the settings that only affect how the work is done, along
+// with the CacheKey of the Colorer and System. It reports false if either
+// has no CacheKey.
+func (p *Parameters) checkpointHash() (uint64, bool) {
+	plugins, ok := p.pluginKeys()
+	if !ok {
+		return 0, false
+	}
+	q := *p
+	q.Workers, q.ChunkRows, q.BandRows = 0, 0, 0
+	raw, _ := json.Marshal(&q)
+	h := fnv.New64a()
+	h.Write(raw)
+	io.WriteString(h, plugins)
+	return h.Sum64(), true
+}
@@ -0,0 +1,57 @@
+package mandel
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckpointHeader checks that a checkpoint whose header claims an
+// impossible or unexpected size is refused before any image is allocated
+// for it, and that a good one still loads.
+func TestCheckpointHeader(t *testing.T) {
+	header := func(width, height int) []byte {
+		var buf bytes.Buffer
+		if err := writeCheckpointHeader(&buf, 42, width, height); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	for _, dims := range [][2]int{{0, 0}, {0, 10}, {10, 0}, {1 << 20, 1 << 20}, {0xffffffff, 0xffffffff}, {1<<28 + 1, 1}} {
+		if _, _, err := ReadCheckpoint(bytes.NewReader(header(dims[0], dims[1]))); err == nil {
+			t.Errorf("a %dx%d checkpoint was accepted", dims[0], dims[1])
+		}
+	}
+	if _, _, err := readCheckpoint(bytes.NewReader(header(64, 48)), image.Rect(0, 0, 32, 24)); err == nil {
+		t.Errorf("a 64x48 checkpoint was accepted for a 32x24 image")
+	}
+
+	good := header(4, 3)
+	var band bytes.Buffer
+	if err := writeCheckpointBand(&band, 0, 2, make([]byte, 4*4*2)); err != nil {
+		t.Fatal(err)
+	}
+	img, rows, err := ReadCheckpoint(bytes.NewReader(append(good, band.Bytes()...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Rect != image.Rect(0, 0, 4, 3) || rows != 2 {
+		t.Errorf("a 4x3 checkpoint with 2 rows loaded as %v with %d rows", img.Rect, rows)
+	}
+
+	// resuming from a corrupt checkpoint is an error, not a panic
+	p := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 8, SizeY: 8, AntiAlias: 1, Palette: DefaultPalette()}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "corrupt.ckpt")
+	if err := ioutil.WriteFile(path, header(0xffffffff, 0xffffffff), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GenerateCheckpointed(context.Background(), path); err == nil {
+		t.Errorf("resuming from a corrupt checkpoint succeeded")
+	}
+}
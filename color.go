@@ -0,0 +1,187 @@
+package mandel
+
+import (
+	"image/color"
+	"math"
+)
+
+// over composites src, scaled by an extra opacity factor, over dst.
+func over(src, dst color.NRGBA, opacity float64) color.NRGBA {
+	sa := float64(src.A) / 255 * opacity
+	da := float64(dst.A) / 255 * (1 - sa)
+	a := sa + da
+	if a <= 0 {
+		return color.NRGBA{}
+	}
+	mix := func(s, d uint8) uint8 {
+		return uint8((float64(s)*sa+float64(d)*da)/a + 0.5)
+	}
+	return color.NRGBA{mix(src.R, dst.R), mix(src.G, dst.G), mix(src.B, dst.B), uint8(a*255 + 0.5)}
+}
+
+// colorSum averages colors, weighting each one by its alpha so that
+// transparent samples do not darken their neighbors. Unless it is
+// precise, samples are truncated to whole channel values first, as
+// getColor truncates them for 8-bit output.
+type colorSum struct {
+	r, g, b, a float64
+	n          int
+	precise    bool
+}
+
+func (s *colorSum) add(r, g, b, a int) {
+	s.sample(float64(r), float64(g), float64(b), float64(a))
+}
+
+// sample adds a color with channels in [0, 255], as sampleColor returns
+// them.
+func (s *colorSum) sample(r, g, b, a float64) {
+	if !s.precise {
+		r, g, b, a = float64(clampChannel(r)), float64(clampChannel(g)), float64(clampChannel(b)), float64(clampChannel(a))
+	}
+	s.r, s.g, s.b, s.a = s.r+r*a, s.g+g*a, s.b+b*a, s.a+a
+	s.n++
+}
+
+func (s *colorSum) color() color.NRGBA {
+	if s.a == 0 {
+		return color.NRGBA{}
+	}
+	channel := func(v float64) uint8 {
+		return clamp8(int(v))
+	}
+	return color.NRGBA{channel(s.r / s.a), channel(s.g / s.a), channel(s.b / s.a), channel(s.a / float64(s.n))}
+}
+
+// value is the average color with channels in [0, 1], not premultiplied.
+func (s *colorSum) value() (r, g, b, a float64) {
+	if s.a == 0 {
+		return 0, 0, 0, 0
+	}
+	return s.r / s.a / 255, s.g / s.a / 255, s.b / s.a / 255, s.a / float64(s.n) / 255
+}
+
+// clamp8 converts a channel to a byte, clamping it to [0, 255] first.
+func clamp8(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// paletteIndex maps a palette position to an index into a palette of
+// length n, wrapping around in both directions. NaN and infinite values
+// map to 0 rather than to a garbage index.
+func paletteIndex(v float64, n int) int {
+	checkPaletteValue(v, n)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	i := int(math.Mod(math.Floor(v), float64(n)))
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// wrapPaletteOffset wraps a palette offset into [0, 1), so that offsets below 0
+// turn the palette backward without making palette positions negative.
+func wrapPaletteOffset(offset float64) float64 {
+	offset = math.Mod(offset, 1)
+	if offset < 0 {
+		offset++
+	}
+	if offset >= 1 {
+		// a tiny negative offset rounds up to 1
+		offset = 0
+	}
+	return offset
+}
+
+// hsvColor converts a hue in degrees, saturation, and value in [0, 1] to
+// an opaque color.
+func hsvColor(h, s, v float64) color.NRGBA {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := v - c
+	channel := func(u float64) uint8 {
+		return uint8((u+m)*255 + 0.5)
+	}
+	return color.NRGBA{channel(r), channel(g), channel(b), 255}
+}
+
+// toHSV converts a color to a hue in degrees, saturation, and value in
+// [0, 1]. Grays have a hue of 0.
+func toHSV(c color.NRGBA) (h, s, v float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	v = math.Max(r, math.Max(g, b))
+	chroma := v - math.Min(r, math.Min(g, b))
+	if v > 0 {
+		s = chroma / v
+	}
+	switch {
+	case chroma == 0:
+	case v == r:
+		h = 60 * math.Mod((g-b)/chroma+6, 6)
+	case v == g:
+		h = 60 * ((b-r)/chroma + 2)
+	default:
+		h = 60 * ((r-g)/chroma + 4)
+	}
+	return h, s, v
+}
+
+// mixHSV blends two colors in HSV, taking the shorter way around the hue
+// circle. A gray takes on the hue of the other color, so blends into gray
+// do not swing through unrelated hues.
+func mixHSV(c1, c2 color.NRGBA, weight float64) color.NRGBA {
+	h1, s1, v1 := toHSV(c1)
+	h2, s2, v2 := toHSV(c2)
+	if s1 == 0 {
+		h1 = h2
+	} else if s2 == 0 {
+		h2 = h1
+	}
+	dh := h2 - h1
+	if dh > 180 {
+		dh -= 360
+	} else if dh < -180 {
+		dh += 360
+	}
+	c := hsvColor(h1+dh*weight, s1+(s2-s1)*weight, v1+(v2-v1)*weight)
+	c.A = uint8(float64(c1.A)*(1-weight) + float64(c2.A)*weight + 0.5)
+	return c
+}
+
+// mixLab blends two colors in CIELAB.
+func mixLab(c1, c2 color.NRGBA, weight float64) color.NRGBA {
+	a, b := toLab(c1), toLab(c2)
+	return lab{
+		a.l + (b.l-a.l)*weight,
+		a.a + (b.a-a.a)*weight,
+		a.b + (b.b-a.b)*weight,
+		a.alpha + (b.alpha-a.alpha)*weight,
+	}.nrgba()
+}
@@ -0,0 +1,129 @@
+package mandel
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// TestClampChannel checks that channels just past either end of the range,
+// and ones that are not numbers at all, land in [0, 255], both alone and
+// averaged over the samples of a pixel, rather than wrapping around.
+func TestClampChannel(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want int
+	}{
+		{-1e-12, 0},
+		{0, 0},
+		{0.999, 0},
+		{254.999, 254},
+		{255, 255},
+		{math.Nextafter(255, 256), 255},
+		{256, 255},
+		{math.Inf(1), 255},
+		{math.Inf(-1), 0},
+		{math.NaN(), 0},
+	}
+	for _, test := range tests {
+		if got := clampChannel(test.v); got != test.want {
+			t.Errorf("clampChannel(%g) = %d, want %d", test.v, got, test.want)
+		}
+	}
+
+	var sum colorSum
+	for k := 0; k < 9; k++ {
+		sum.sample(math.Nextafter(255, 256), 255.0000001, -1e-9, 255)
+	}
+	if got, want := sum.color(), (color.NRGBA{255, 255, 0, 255}); got != want {
+		t.Errorf("nine samples just past the ends average to %v, want %v", got, want)
+	}
+
+	// precise sums keep their samples as they are, so only the average is
+	// clamped
+	precise := colorSum{precise: true}
+	for k := 0; k < 9; k++ {
+		precise.sample(256, 255.75, -0.5, 255)
+	}
+	if got, want := precise.color(), (color.NRGBA{255, 255, 0, 255}); got != want {
+		t.Errorf("nine precise samples past the ends average to %v, want %v", got, want)
+	}
+}
+
+// TestClampPalette renders with a palette whose entries are all at the top
+// of the red and blue channels, so rounding while interpolating between
+// them lands on either side of 255. No pixel may wrap around to a dark
+// one, whatever the interpolation.
+func TestClampPalette(t *testing.T) {
+	palette := []color.NRGBA{{255, 255, 255, 255}, {255, 0, 255, 255}, {255, 254, 255, 255}, {255, 1, 255, 255}}
+	for _, interpolation := range []string{"", "hsv", "lab"} {
+		p := Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 500, SizeX: 48, SizeY: 32, AntiAlias: 3, Continuous: true, Palette: palette, InsideColor: palette[0], Interpolation: interpolation, PaletteOffset: 0.3}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		img, err := p.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < p.SizeY; y++ {
+			for x := 0; x < p.SizeX; x++ {
+				c, err := p.CalcPixel(x, y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, got := range []color.NRGBA{img.NRGBAAt(x, y), c.(color.NRGBA)} {
+					if got.R < 254 || got.B < 254 || got.A != 255 {
+						t.Errorf("interpolation %q: pixel (%d, %d) is %v", interpolation, x, y, got)
+					}
+				}
+			}
+		}
+
+		for _, v := range []float64{0.5, 1, 1.999999999, 2.5, 1e300} {
+			if r, _, b, a := p.getColor(v); r < 254 || b < 254 || a != 255 {
+				t.Errorf("interpolation %q: escape value %g gives red %d, blue %d, alpha %d", interpolation, v, r, b, a)
+			}
+		}
+	}
+}
+
+// TestCurvatureField checks that curvature escape values do not depend on
+// the palette, so a field computed with one palette and colored with
+// another matches a render made with the second palette from the start.
+func TestCurvatureField(t *testing.T) {
+	short := []color.NRGBA{{255, 0, 0, 255}, {0, 0, 255, 255}}
+	for _, continuous := range []bool{false, true} {
+		params := func(palette []color.NRGBA) *Parameters {
+			p := &Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 100, MaxIterations: 500, SizeX: 48, SizeY: 32, AntiAlias: 2, Continuous: continuous, CurvatureColor: true, Palette: palette}
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			return p
+		}
+		long, two := params(DefaultPalette()), params(short)
+		field, err := long.ComputeIterations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := two.ComputeIterations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range field.Values {
+			if other.Values[i] != v {
+				t.Fatalf("continuous %v: sample %d is %g with the default palette, %g with two colors", continuous, i, v, other.Values[i])
+			}
+		}
+		want, err := two.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := field.Colorize(two)
+		for i := range want.Pix {
+			if got.Pix[i] != want.Pix[i] {
+				t.Errorf("continuous %v: recolored field differs from the render at byte %d", continuous, i)
+				break
+			}
+		}
+	}
+}
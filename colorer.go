@@ -0,0 +1,48 @@
+package mandel
+
+import "image/color"
+
+// IterationResult is what a Colorer gets to color a single sample.
+type IterationResult struct {
+	// escape value: the number of iterations before the point escaped,
+	// smoothed in continuous mode, or 0 if it did not escape
+	Iterations float64
+
+	// the point did not escape within MaxIterations
+	Inside bool
+
+	// the iteration limit the sample was computed with
+	MaxIterations int
+}
+
+// Colorer colors samples in place of the palette coloring. Its colors are
+// averaged over the anti-aliasing samples of each pixel and then gamma
+// adjusted, like those of the palette. Color is called from many
+// goroutines at once. Renders with a Colorer are only cached if it is
+// also a CacheKeyer.
+type Colorer interface {
+	Color(r IterationResult) color.NRGBA
+}
+
+// ColorerFunc adapts an ordinary function to a Colorer.
+type ColorerFunc func(r IterationResult) color.NRGBA
+
+// Color calls f(r).
+func (f ColorerFunc) Color(r IterationResult) color.NRGBA {
+	return f(r)
+}
+
+// PaletteColorer returns the palette coloring of p as a Colorer, ignoring
+// p.Colorer, so a custom Colorer can adjust its colors or fall back on it.
+// p must not change after the call.
+func (p *Parameters) PaletteColorer() (Colorer, error) {
+	if err := p.checkInit("PaletteColorer"); err != nil {
+		return nil, err
+	}
+	q := *p
+	q.Colorer = nil
+	return ColorerFunc(func(r IterationResult) color.NRGBA {
+		cr, cg, cb, ca := q.getColor(r.Iterations)
+		return color.NRGBA{uint8(cr), uint8(cg), uint8(cb), uint8(ca)}
+	}), nil
+}
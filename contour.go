@@ -0,0 +1,97 @@
+package mandel
+
+import (
+	"image"
+	"math"
+)
+
+// drawContours traces every level in Contours across the continuous
+// escape field using marching squares and draws the resulting lines over
+// the canvas in ContourColor.
+func (p *Parameters) drawContours(canvas *image.NRGBA) {
+	f := p.computeField(true)
+	scale := 1 / float64(f.AntiAlias)
+
+	// per-pixel line coverage, so overlapping segments blend only once
+	w, h := canvas.Rect.Dx(), canvas.Rect.Dy()
+	coverage := make([]float64, w*h)
+
+	for j := 0; j+1 < f.Height; j++ {
+		for i := 0; i+1 < f.Width; i++ {
+			// corners in order around the cell
+			v := [4]float64{f.At(i, j), f.At(i+1, j), f.At(i+1, j+1), f.At(i, j+1)}
+			if v[0] == 0 || v[1] == 0 || v[2] == 0 || v[3] == 0 {
+				// skip cells that touch the interior
+				continue
+			}
+			cx := [4]float64{0, 1, 1, 0}
+			cy := [4]float64{0, 0, 1, 1}
+
+			for _, level := range p.Contours {
+				// find where the level crosses each edge of the cell
+				var xs, ys []float64
+				for e := 0; e < 4; e++ {
+					a, b := v[e]-level, v[(e+1)%4]-level
+					if (a < 0) == (b < 0) {
+						continue
+					}
+					t := a / (a - b)
+					n := (e + 1) % 4
+					xs = append(xs, float64(i)+cx[e]+t*(cx[n]-cx[e]))
+					ys = append(ys, float64(j)+cy[e]+t*(cy[n]-cy[e]))
+				}
+
+				// two crossings make one segment, a saddle makes two
+				for k := 0; k+1 < len(xs); k += 2 {
+					// sample centers sit half a sample in from the grid origin
+					x0, y0 := (xs[k]+0.5)*scale, (ys[k]+0.5)*scale
+					x1, y1 := (xs[k+1]+0.5)*scale, (ys[k+1]+0.5)*scale
+					plotSegment(coverage, w, h, x0, y0, x1, y1)
+				}
+			}
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if c := coverage[y*w+x]; c > 0 {
+				dst := canvas.NRGBAAt(canvas.Rect.Min.X+x, canvas.Rect.Min.Y+y)
+				canvas.SetNRGBA(canvas.Rect.Min.X+x, canvas.Rect.Min.Y+y, over(p.ContourColor, dst, c))
+			}
+		}
+	}
+}
+
+// plotSegment records the coverage of a one-pixel-wide anti-aliased line
+// from (x0, y0) to (x1, y1), given in pixel units.
+func plotSegment(coverage []float64, w, h int, x0, y0, x1, y1 float64) {
+	minx := int(math.Floor(math.Min(x0, x1) - 1))
+	maxx := int(math.Ceil(math.Max(x0, x1) + 1))
+	miny := int(math.Floor(math.Min(y0, y1) - 1))
+	maxy := int(math.Ceil(math.Max(y0, y1) + 1))
+	dx, dy := x1-x0, y1-y0
+	length2 := dx*dx + dy*dy
+
+	for y := miny; y <= maxy; y++ {
+		if y < 0 || y >= h {
+			continue
+		}
+		for x := minx; x <= maxx; x++ {
+			if x < 0 || x >= w {
+				continue
+			}
+
+			// distance from the pixel center to the segment
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/length2))
+			}
+			d := math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+
+			if c := 1 - d; c > coverage[y*w+x] {
+				coverage[y*w+x] = c
+			}
+		}
+	}
+}
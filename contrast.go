@@ -0,0 +1,37 @@
+package mandel
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// percentiles of the escape values that bound the AutoContrast window
+const contrastLow, contrastHigh = 0.05, 0.95
+
+// generateAutoContrast computes the escape field, finds the contrast
+// window from its escaped samples, and colors the field with it. The
+// window is the middle 90% of their escape values on a log scale, so
+// contrast holds steady through a zoom.
+func (p *Parameters) generateAutoContrast() *image.NRGBA {
+	var f *Field
+	if p.SmartIterations {
+		f = p.smartField()
+	} else {
+		f = p.computeField(p.Continuous)
+	}
+
+	var logs []float64
+	for _, v := range f.Values {
+		if v != 0 {
+			logs = append(logs, math.Log1p(p.colorValue(v)))
+		}
+	}
+	q := *p
+	if len(logs) > 0 {
+		sort.Float64s(logs)
+		q.contrastLo = logs[int(contrastLow*float64(len(logs)-1))]
+		q.contrastHi = logs[int(contrastHigh*float64(len(logs)-1))]
+	}
+	return f.Colorize(&q)
+}
@@ -0,0 +1,30 @@
+package mandel
+
+import (
+	"image"
+	"math"
+)
+
+// cropImage makes the pixels outside the circle or ellipse inscribed in
+// the canvas transparent, blending the edge over about one pixel.
+func (p *Parameters) cropImage(canvas *image.NRGBA) {
+	w, h := float64(canvas.Rect.Dx()), float64(canvas.Rect.Dy())
+	rx, ry := w/2, h/2
+	if p.CropShape == "circle" {
+		rx = math.Min(rx, ry)
+		ry = rx
+	}
+	for row := 0; row < canvas.Rect.Dy(); row++ {
+		for col := 0; col < canvas.Rect.Dx(); col++ {
+			// distance outside the edge in pixels, roughly, for an ellipse
+			dx, dy := (float64(col)+0.5-w/2)/rx, (float64(row)+0.5-h/2)/ry
+			d := (math.Hypot(dx, dy) - 1) * math.Min(rx, ry)
+			coverage := math.Max(0, math.Min(1, 0.5-d))
+			if coverage == 1 {
+				continue
+			}
+			i := canvas.PixOffset(col, row) + 3
+			canvas.Pix[i] = uint8(float64(canvas.Pix[i])*coverage + 0.5)
+		}
+	}
+}
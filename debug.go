@@ -0,0 +1,19 @@
+//go:build mandeldebug
+// +build mandeldebug
+
+package mandel
+
+import (
+	"fmt"
+	"math"
+)
+
+// checkPaletteValue panics on values that paletteIndex would have to
+// patch up, so that bugs feeding it bad values show up in testing. Build
+// with -tags mandeldebug to enable it. Negative values are fine: depth
+// normalization makes them, and paletteIndex wraps them by design.
+func checkPaletteValue(v float64, n int) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		panic(fmt.Sprintf("invalid palette value %v for palette of length %d", v, n))
+	}
+}
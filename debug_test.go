@@ -0,0 +1,24 @@
+//go:build mandeldebug
+// +build mandeldebug
+
+package mandel
+
+import "testing"
+
+// TestDebugDepthNormalize renders a deep view with DepthNormalize, whose
+// fast escapes fall below 0 after the shift, and checks that the debug
+// checks let those legitimate values through.
+func TestDebugDepthNormalize(t *testing.T) {
+	for _, continuous := range []bool{false, true} {
+		p := Parameters{CenterX: 3, Magnification: 1e8, MaxIterations: 100, SizeX: 16, SizeY: 16, AntiAlias: 2, Continuous: continuous, DepthNormalize: true, Palette: DefaultPalette()}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if p.depthShift <= 1 {
+			t.Fatalf("depth shift is %g, too small to make escape values negative", p.depthShift)
+		}
+		if _, err := p.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,9 @@
-package main
+package mandel
 
-var defaultColors = [][]uint8{
+import "image/color"
+
+// the palette mandelgen uses when none is given
+var defaultPalette = []color.NRGBA{
 	{15, 0, 0, 255},
 	{31, 0, 0, 255},
 	{47, 0, 0, 255},
@@ -92,3 +95,9 @@ var defaultColors = [][]uint8{
 	{0, 0, 47, 255},
 	{0, 0, 31, 255},
 }
+
+// DefaultPalette returns a copy of the default palette, which ramps up
+// and back down through red, then green, then blue.
+func DefaultPalette() []color.NRGBA {
+	return append([]color.NRGBA(nil), defaultPalette...)
+}
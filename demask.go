@@ -0,0 +1,56 @@
+package mandel
+
+import "image"
+
+// pixels closer than this to the boundary, by the distance estimate, get
+// the full anti-aliasing grid under DEMaskedAA
+const deMaskPixels = 2
+
+// generateMasked renders with the full anti-aliasing grid only for pixels
+// near the boundary. A first pass estimates the distance from the center
+// of every pixel to the set. Pixels within deMaskPixels of the boundary,
+// and interior pixels next to an exterior one, are rendered by CalcPixel;
+// the rest use their center sample alone.
+func (p *Parameters) generateMasked() *image.NRGBA {
+	w, h := p.SizeX, p.SizeY
+	dist := make([]float64, w*h)
+	p.forRows(h, func(row int) {
+		for col := 0; col < w; col++ {
+			x, y := p.toPlane(col, row, 0, 0)
+			dist[row*w+col] = distance(p.MaxIterations, x, y)
+		}
+	})
+
+	threshold := deMaskPixels * p.pixelSize()
+	nearBoundary := func(col, row int) bool {
+		d := dist[row*w+col]
+		if d > 0 {
+			return d < threshold
+		}
+		for j := row - 1; j <= row+1; j++ {
+			for i := col - 1; i <= col+1; i++ {
+				if i >= 0 && i < w && j >= 0 && j < h && dist[j*w+i] > 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	canvas := p.newCanvas()
+	p.forRows(h, func(row int) {
+		for col := 0; col < w; col++ {
+			if nearBoundary(col, row) {
+				c, _ := p.calcPixel(col, row)
+				canvas.SetNRGBA(col, row, c)
+				continue
+			}
+			x, y := p.toPlane(col, row, 0, 0)
+			var sum colorSum
+			sum.add(p.getColor(p.escape(p.MaxIterations, x, y, p.Continuous)))
+			canvas.SetNRGBA(col, row, p.adjust(sum.color()))
+			p.report.fastPixel()
+		}
+	})
+	return canvas
+}
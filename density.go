@@ -0,0 +1,88 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// initDensity checks the density overlay settings.
+func (p *Parameters) initDensity() error {
+	if p.DensityOverlay < 0 {
+		return fmt.Errorf("density overlay samples must not be negative")
+	}
+	switch p.DensityBlend {
+	case "", "screen", "add", "multiply", "normal":
+	default:
+		return fmt.Errorf("unknown density blend %q", p.DensityBlend)
+	}
+	if !(p.DensityOpacity >= 0 && p.DensityOpacity <= 1) {
+		return fmt.Errorf("density opacity must be between 0 and 1")
+	}
+	return nil
+}
+
+// overlayDensity blends the anti-Buddhabrot over a finished render. Each
+// pixel is covered by DensityColor in proportion to its count, on the log
+// scale of GenerateAntiBuddhabrot, times DensityOpacity.
+func (p *Parameters) overlayDensity(canvas *image.NRGBA) {
+	// the render has already reported all of its progress
+	q := *p
+	q.progress = nil
+	p = &q
+
+	counts := p.insideCounts(p.DensityOverlay)
+	most := mostHits(counts)
+	if most == 0 {
+		return
+	}
+	c := p.DensityColor
+	if c == (color.NRGBA{}) {
+		c = color.NRGBA{255, 255, 255, 255}
+	}
+	over := [3]float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}
+	opacity := p.DensityOpacity
+	if opacity == 0 {
+		opacity = 1
+	}
+	opacity *= float64(c.A) / 255
+
+	w := p.SizeX
+	p.forRows(p.SizeY, func(row int) {
+		for col := 0; col < w; col++ {
+			n := counts[row*w+col]
+			if n == 0 {
+				continue
+			}
+			cover := hitLevel(n, most, true) * opacity
+			pix := canvas.Pix[canvas.PixOffset(col, row):]
+			for k := 0; k < 3; k++ {
+				pix[k] = blendChannel(p.DensityBlend, float64(pix[k])/255, over[k], cover)
+			}
+		}
+	})
+}
+
+// blendChannel combines a channel of the image with a channel of an
+// overlay that covers it by cover, all in [0, 1], and scales the result
+// to a byte.
+func blendChannel(mode string, base, over, cover float64) uint8 {
+	return uint8(blendValue(mode, base, over, cover)*255 + 0.5)
+}
+
+// blendValue is blendChannel without the scaling.
+func blendValue(mode string, base, over, cover float64) float64 {
+	var v float64
+	switch mode {
+	case "", "screen":
+		v = 1 - (1-base)*(1-over*cover)
+	case "add":
+		v = base + over*cover
+	case "multiply":
+		v = base * (1 - cover + over*cover)
+	case "normal":
+		v = base + (over-base)*cover
+	}
+	return math.Max(0, math.Min(v, 1))
+}
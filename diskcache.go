@@ -0,0 +1,258 @@
+package mandel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheKey identifies the image that p renders, for caching rendered or
+// encoded images: a hash of everything saved with the parameters, apart
+// from the settings that only affect how the work is done, along with
+// variant, which tells apart the different things cached for the same
+// parameters, such as the format they are encoded in. Call it after
+// Init, which fills in values such as an automatic iteration limit.
+//
+// A Colorer or System is part of the key only through its CacheKeyer
+// method, so if either is set without one, the image cannot be identified
+// and CacheKey returns "", which a DiskCache never stores.
+func (p *Parameters) CacheKey(variant string) string {
+	plugins, ok := p.pluginKeys()
+	if !ok {
+		return ""
+	}
+	q := *p
+	q.Workers, q.ChunkRows, q.BandRows = 0, 0, 0
+	raw, _ := json.Marshal(&q)
+	h := sha256.New()
+	h.Write(raw)
+	fmt.Fprintf(h, "%s %q", plugins, variant)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheKeyer is implemented by a Colorer or System whose results can be
+// named, so that the images it renders can be cached and checkpointed.
+// CacheKey returns a string that differs whenever its results would.
+type CacheKeyer interface {
+	CacheKey() string
+}
+
+// pluginKey names a Colorer or System by its type and CacheKey, or reports
+// false if it has no CacheKey method. A nil plug-in is named "".
+func pluginKey(v interface{}) (string, bool) {
+	if v == nil {
+		return "", true
+	}
+	k, ok := v.(CacheKeyer)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%T %q", v, k.CacheKey()), true
+}
+
+// pluginKeys names p's Colorer and System for the hashes that identify a
+// render, or reports false if either cannot be named. It is "" if neither
+// is set, so those hashes stay as they were before plug-ins.
+func (p *Parameters) pluginKeys() (string, bool) {
+	if p.Colorer == nil && p.System == nil {
+		return "", true
+	}
+	ck, ok := pluginKey(p.Colorer)
+	if !ok {
+		return "", false
+	}
+	sk, ok := pluginKey(p.System)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(" colorer %s system %s", ck, sk), true
+}
+
+// DiskCache keeps rendered images, or anything else, as files in a
+// directory, keyed by CacheKey. Once the files add up to more than the
+// size limit, the ones used least recently are removed. It is safe for
+// concurrent use, a nil *DiskCache caches nothing, and nothing is ever
+// cached under the empty key.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// OpenDiskCache opens the cache in dir, creating the directory if needed,
+// that holds up to maxBytes bytes, or any amount if maxBytes is 0.
+func OpenDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if maxBytes < 0 {
+		return nil, fmt.Errorf("cache size must not be negative")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %v", err)
+	}
+	c := &DiskCache{dir: dir, maxBytes: maxBytes}
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.size += e.Size()
+	}
+	return c, nil
+}
+
+// path is the file for key, in a subdirectory named for its first two
+// characters so no one directory grows too large.
+func (c *DiskCache) path(key string) string {
+	if len(key) < 2 || strings.ContainsAny(key, `/\.`) {
+		key = hex.EncodeToString([]byte(key))
+	}
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Get returns the data cached under key, if any.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	name := c.path(key)
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	// the modification time records the last use for eviction
+	now := time.Now()
+	os.Chtimes(name, now, now)
+	return data, true
+}
+
+// Put caches data under key, replacing anything already there, and then
+// removes the least recently used entries until the cache fits its limit.
+func (c *DiskCache) Put(key string, data []byte) error {
+	if c == nil || key == "" {
+		return nil
+	}
+	name := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	// write to a temporary file and rename it, so readers never see part
+	// of an entry
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "tmp-")
+	if err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if info, err := os.Stat(name); err == nil {
+		c.size -= info.Size()
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	c.size += int64(len(data))
+	if c.maxBytes > 0 && c.size > c.maxBytes {
+		return c.evict()
+	}
+	return nil
+}
+
+// evict removes the least recently used entries until the cache fits its
+// limit. The caller holds the lock.
+func (c *DiskCache) evict() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	c.size = 0
+	for _, e := range entries {
+		c.size += e.Size()
+	}
+	for _, e := range entries {
+		if c.size <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(c.path(e.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing cache entry: %v", err)
+		}
+		c.size -= e.Size()
+	}
+	return nil
+}
+
+// Clear removes every entry from the cache.
+func (c *DiskCache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Remove(c.path(e.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing cache entry: %v", err)
+		}
+		c.size -= e.Size()
+	}
+	return nil
+}
+
+// Size returns the total size of the entries in the cache, in bytes.
+func (c *DiskCache) Size() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// entries lists the files in the cache, leaving out temporary files.
+func (c *DiskCache) entries() ([]os.FileInfo, error) {
+	dirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache directory: %v", err)
+	}
+	var entries []os.FileInfo
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(c.dir, d.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading cache directory: %v", err)
+		}
+		for _, f := range files {
+			if f.Mode().IsRegular() && !strings.HasPrefix(f.Name(), "tmp-") {
+				entries = append(entries, f)
+			}
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,53 @@
+package mandel
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+)
+
+// keyedColorer is a Colorer that can be cached, named by its color.
+type keyedColorer struct {
+	c color.NRGBA
+}
+
+func (k keyedColorer) Color(r IterationResult) color.NRGBA { return k.c }
+func (k keyedColorer) CacheKey() string                    { return fmt.Sprint(k.c) }
+
+// TestCacheKeyPlugins checks that a Colorer or System is part of the key
+// by its CacheKey, and that one without a CacheKey cannot be cached, since
+// two different functions could otherwise share a key.
+func TestCacheKeyPlugins(t *testing.T) {
+	base := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 8, SizeY: 8, AntiAlias: 1}
+
+	red, blue := base, base
+	red.Colorer = keyedColorer{color.NRGBA{255, 0, 0, 255}}
+	blue.Colorer = keyedColorer{color.NRGBA{0, 0, 255, 255}}
+	if red.CacheKey("") == blue.CacheKey("") {
+		t.Errorf("colorers with different keys share a cache key")
+	}
+	if red.CacheKey("") == base.CacheKey("") {
+		t.Errorf("a colorer does not change the cache key")
+	}
+
+	f := base
+	f.Colorer = ColorerFunc(func(r IterationResult) color.NRGBA { return color.NRGBA{} })
+	if key := f.CacheKey(""); key != "" {
+		t.Errorf("a ColorerFunc has cache key %q, want none", key)
+	}
+
+	j1, j2 := base, base
+	j1.System = JuliaSystem{C: complex(-0.8, 0.156)}
+	j2.System = JuliaSystem{C: complex(-0.8, 0.157)}
+	if j1.CacheKey("") == j2.CacheKey("") || j1.CacheKey("") == "" {
+		t.Errorf("Julia systems with different constants share a cache key")
+	}
+
+	c := &DiskCache{dir: t.TempDir()}
+	if err := c.Put("", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(""); ok {
+		t.Errorf("the empty key was cached")
+	}
+}
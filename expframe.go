@@ -0,0 +1,185 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// expMapAngles returns the starting angle of an exponential map and the
+// angle its columns sweep, in radians.
+func (p *Parameters) expMapAngles() (start, span float64) {
+	start, span = 0.0, 2*math.Pi
+	if p.ExpMapAngleStart != 0 || p.ExpMapAngleEnd != 0 {
+		start = p.ExpMapAngleStart * math.Pi / 180
+		span = (p.ExpMapAngleEnd - p.ExpMapAngleStart) * math.Pi / 180
+	}
+	return start, span
+}
+
+// expMapOctaves is the number of times the radius halves from the top
+// edge of an exponential map to the bottom, when the radii are not given.
+func (p *Parameters) expMapOctaves() float64 {
+	if p.ExpMapOctaves != 0 {
+		return p.ExpMapOctaves
+	}
+	_, span := p.expMapAngles()
+	return span * float64(p.SizeY) / (float64(p.SizeX) * math.Ln2)
+}
+
+// expMapRadius is the radius of an exponential map at a depth from 0 at
+// the top edge to 1 at the bottom.
+func (p *Parameters) expMapRadius(depth float64) float64 {
+	if p.ExpMapRadiusStart > 0 {
+		// explicit radii override the magnification and octaves
+		ratio := math.Log(p.ExpMapRadiusEnd / p.ExpMapRadiusStart)
+		return p.ExpMapRadiusStart * math.Exp(ratio*depth)
+	}
+	return math.Exp2(-p.expMapOctaves()*depth) / math.Abs(p.Magnification)
+}
+
+// expMapDepth is the inverse of expMapRadius.
+func (p *Parameters) expMapDepth(radius float64) float64 {
+	if p.ExpMapRadiusStart > 0 {
+		return math.Log(radius/p.ExpMapRadiusStart) / math.Log(p.ExpMapRadiusEnd/p.ExpMapRadiusStart)
+	}
+	return -math.Log2(radius*math.Abs(p.Magnification)) / p.expMapOctaves()
+}
+
+// ExpMapZoomRange returns the range of magnifications that ExpMapFrame
+// can fill a width×height frame for from the exponential map that p
+// describes: from the first view whose corners fit inside the top edge of
+// the map, to the last view in which the part deeper than the bottom edge
+// is smaller than a pixel.
+func (p *Parameters) ExpMapZoomRange(width, height int) (outer, inner float64, err error) {
+	if err := p.checkInit("ExpMapZoomRange"); err != nil {
+		return 0, 0, err
+	}
+	if !p.ExpMap {
+		return 0, 0, fmt.Errorf("ExpMapZoomRange needs an exponential map")
+	}
+	if width < 2 || height < 2 {
+		return 0, 0, fmt.Errorf("frames must be at least 2 by 2 pixels")
+	}
+	minsize := width
+	if height < width {
+		minsize = height
+	}
+	top, bottom := p.expMapRadius(0), p.expMapRadius(1)
+	if bottom > top {
+		top, bottom = bottom, top
+	}
+	outer = math.Hypot(float64(width)/2, float64(height)/2) / (float64(minsize-1) * top)
+	inner = 0.5 / (float64(minsize-1) * bottom)
+	return outer, inner, nil
+}
+
+// ExpMapFrame reprojects strip, an exponential map rendered with p, into
+// an ordinary width×height view of the zoom center at the given
+// magnification, framed as Generate would frame it with the view settings
+// of p. Each pixel blends samples×samples points from the strip
+// bilinearly, so a video can be made from one strip by resampling instead
+// of rendering every frame. Pixels beyond the top edge of the strip, or
+// outside the sector it covers, are transparent, and pixels deeper than
+// the bottom edge take the bottom row; ExpMapZoomRange gives the
+// magnifications the strip covers fully.
+func (p *Parameters) ExpMapFrame(strip image.Image, magnification float64, width, height, samples int) (*image.NRGBA, error) {
+	if err := p.checkInit("ExpMapFrame"); err != nil {
+		return nil, err
+	}
+	if !p.ExpMap {
+		return nil, fmt.Errorf("ExpMapFrame needs an exponential map")
+	}
+	if strip.Bounds().Dx() != p.SizeX || strip.Bounds().Dy() != p.SizeY {
+		return nil, fmt.Errorf("strip is %v, but the parameters describe %d×%d", strip.Bounds().Size(), p.SizeX, p.SizeY)
+	}
+	if width < 1 || height < 1 || samples < 1 {
+		return nil, fmt.Errorf("frame size and samples must be at least 1")
+	}
+	if magnification == 0 || math.IsNaN(magnification) || math.IsInf(magnification, 0) {
+		return nil, fmt.Errorf("magnification must be a nonzero number")
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, p.SizeX, p.SizeY))
+	draw.Draw(src, src.Rect, strip, strip.Bounds().Min, draw.Src)
+	start, span := p.expMapAngles()
+	fullTurn := math.Abs(span) >= 2*math.Pi
+
+	// the mirroring, Rotation, and Transform of p apply to the strip and
+	// the frame alike, so offsets are compared before them
+	minsize := width
+	if height < width {
+		minsize = height
+	}
+	unit := math.Abs(magnification) * float64(minsize-1)
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+	p.forRows(height, func(row int) {
+		for col := 0; col < width; col++ {
+			var sum colorSum
+			for j := 0; j < samples; j++ {
+				for i := 0; i < samples; i++ {
+					xoffset := (float64(i)+0.5)/float64(samples) - 0.5
+					yoffset := (float64(j)+0.5)/float64(samples) - 0.5
+					dx := (float64(col-width/2) + xoffset) / unit
+					dy := -(float64(row-height/2) - yoffset) / unit
+					sum.add(stripSample(src, p, start, span, fullTurn, dx, dy))
+				}
+			}
+			canvas.SetNRGBA(col, row, sum.color())
+		}
+	})
+	return canvas, nil
+}
+
+// stripSample blends the strip's color at the offset dx, dy from the zoom
+// center.
+func stripSample(src *image.NRGBA, p *Parameters, start, span float64, fullTurn bool, dx, dy float64) (r, g, b, a int) {
+	radius := math.Hypot(dx, dy)
+	depth := 1.0
+	if radius > 0 {
+		depth = math.Min(p.expMapDepth(radius), 1)
+	}
+	if !(depth >= 0) {
+		return 0, 0, 0, 0
+	}
+	turn := (math.Atan2(dy, dx) - start) / span
+	if fullTurn {
+		turn -= math.Floor(turn)
+	} else if turn < 0 || turn > 1 {
+		return 0, 0, 0, 0
+	}
+
+	// pixel centers sit half a pixel in from the edges
+	fx := turn*float64(p.SizeX) - 0.5
+	fy := math.Min(depth*float64(p.SizeY)-0.5, float64(p.SizeY-1))
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(math.Max(fy, 0)))
+	tx, ty := fx-float64(x0), math.Max(fy, 0)-float64(y0)
+	y1 := y0 + 1
+	if y1 >= p.SizeY {
+		y1 = p.SizeY - 1
+	}
+	x1 := x0 + 1
+	wrap := func(x int) int {
+		if fullTurn {
+			return (x%p.SizeX + p.SizeX) % p.SizeX
+		}
+		if x < 0 {
+			return 0
+		}
+		if x >= p.SizeX {
+			return p.SizeX - 1
+		}
+		return x
+	}
+	x0, x1 = wrap(x0), wrap(x1)
+	c00, c10 := src.NRGBAAt(x0, y0), src.NRGBAAt(x1, y0)
+	c01, c11 := src.NRGBAAt(x0, y1), src.NRGBAAt(x1, y1)
+	mix := func(v00, v10, v01, v11 uint8) int {
+		top := float64(v00)*(1-tx) + float64(v10)*tx
+		bottom := float64(v01)*(1-tx) + float64(v11)*tx
+		return int(top*(1-ty) + bottom*ty + 0.5)
+	}
+	return mix(c00.R, c10.R, c01.R, c11.R), mix(c00.G, c10.G, c01.G, c11.G),
+		mix(c00.B, c10.B, c01.B, c11.B), mix(c00.A, c10.A, c01.A, c11.A)
+}
@@ -0,0 +1,137 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// a target view is this many times wider than the distance from its
+	// center to the boundary, so the boundary crosses it with room to show
+	// the detail around it
+	exploreViewScale = 64
+
+	// a target is at least this much deeper than the view it was found in
+	exploreMinZoom = 4
+
+	// the deepest target, where float64 coordinates still have digits to
+	// spare for the pixels
+	exploreMaxMagnification = 1e12
+
+	// targets are scored by iterating a grid this many points on a side
+	// across their view
+	exploreProbes = 8
+)
+
+// Target is a view found by Explore.
+type Target struct {
+	X, Y          float64
+	Magnification float64
+
+	// how interesting the view looked; higher is better
+	Score float64
+}
+
+// Explore looks for interesting views to zoom into inside the current
+// view, returning the best count of them, best first. It scatters an n×n
+// grid of candidate points across the image, jittered within their cells
+// by Seed, and keeps the ones that escape close to the boundary of the
+// set. Each candidate's view is sized by its distance estimate, so the
+// boundary runs through it, and scored by the spread of log iteration
+// counts over a grid of probes across the view, which is highest where
+// escaping bands and the inside of the set are tangled together. Targets
+// whose views overlap a better target are dropped. Only z² + c views that
+// can be iterated in float64 can be explored.
+func (p *Parameters) Explore(n, count int) ([]Target, error) {
+	if err := p.checkInit("Explore"); err != nil {
+		return nil, err
+	}
+	if !p.quadratic() || p.perturbed() {
+		return nil, fmt.Errorf("only z² + c views shallower than magnification %g can be explored", perturbMagnification)
+	}
+	if n < 1 || count < 1 {
+		return nil, fmt.Errorf("exploring needs at least one candidate and one target")
+	}
+
+	rows := make([][]Target, n)
+	p.forRows(n, func(j int) {
+		for i := 0; i < n; i++ {
+			if t, ok := p.exploreCandidate(n, i, j); ok {
+				rows[j] = append(rows[j], t)
+			}
+		}
+	})
+	var candidates []Target
+	for _, row := range rows {
+		candidates = append(candidates, row...)
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].Score > candidates[b].Score
+	})
+
+	var targets []Target
+	for _, c := range candidates {
+		overlaps := false
+		for _, t := range targets {
+			// the half widths of the two views
+			reach := 0.5/c.Magnification + 0.5/t.Magnification
+			if math.Abs(c.X-t.X) < reach && math.Abs(c.Y-t.Y) < reach {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			targets = append(targets, c)
+			if len(targets) == count {
+				break
+			}
+		}
+	}
+	return targets, nil
+}
+
+// exploreCandidate sizes and scores the view around candidate i, j of an
+// n×n grid, reporting false if the candidate is inside the set or too far
+// from its boundary to zoom into.
+func (p *Parameters) exploreCandidate(n, i, j int) (Target, bool) {
+	h := jitterHash(uint64(p.Seed), uint64(i), uint64(j))
+	u := (float64(i) + float64(h>>40)/(1<<24)) / float64(n) * float64(p.SizeX)
+	v := (float64(j) + float64(h&(1<<24-1))/(1<<24)) / float64(n) * float64(p.SizeY)
+	x, y := p.pixelToComplex(u, v)
+
+	d := distance(p.MaxIterations, x, y)
+	if d <= 0 || math.IsNaN(d) || math.IsInf(d, 0) {
+		return Target{}, false
+	}
+	mag := 1 / (exploreViewScale * d)
+	if mag < exploreMinZoom*math.Abs(p.Magnification) {
+		return Target{}, false
+	}
+	mag = math.Min(mag, exploreMaxMagnification)
+
+	// probe the view with the iterations a render of it would choose, or
+	// more if the current view already uses more
+	iters := estimateIterations(mag)
+	if p.MaxIterations > iters {
+		iters = p.MaxIterations
+	}
+	inside := math.Log1p(float64(iters))
+	sum, sum2 := 0.0, 0.0
+	for b := 0; b < exploreProbes; b++ {
+		py := y + ((float64(b)+0.5)/exploreProbes-0.5)/mag
+		for a := 0; a < exploreProbes; a++ {
+			px := x + ((float64(a)+0.5)/exploreProbes-0.5)/mag
+			level := inside
+			if e := mandel(iters, px, py, smoothEscape, 256); e > 0 {
+				level = math.Log1p(e)
+			}
+			sum += level
+			sum2 += level * level
+		}
+	}
+	probes := float64(exploreProbes * exploreProbes)
+	mean := sum / probes
+	score := math.Sqrt(math.Max(0, sum2/probes-mean*mean))
+	return Target{X: x, Y: y, Magnification: mag, Score: score}, true
+}
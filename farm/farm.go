@@ -0,0 +1,263 @@
+// Package farm spreads a render across several machines over HTTP. Each
+// machine runs a Worker, and a Coordinator hands bands of the image out to
+// the workers, trying a band again on another worker when one fails.
+package farm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/russross/mandel"
+)
+
+// the path workers serve bands on
+const BandPath = "/band"
+
+// the largest job a worker will read
+const maxJobBytes = 16 << 20
+
+// the most a job may ask for of the settings that multiply the work for
+// each pixel
+const (
+	maxAntiAlias   = 8
+	maxSamples     = 64
+	maxPasses      = 8
+	maxPower       = 16
+	maxNewtonTerms = 17
+	maxProbes      = 4096
+)
+
+// job is a unit of work: the parameters of the whole image, and the part
+// of it to render.
+type job struct {
+	Parameters *mandel.Parameters `json:"parameters"`
+
+	// min x, min y, max x, max y
+	Rect [4]int `json:"rect"`
+}
+
+// Worker renders the jobs posted to it and replies with each region as a
+// PNG.
+type Worker struct {
+	maxPixels     int
+	maxIterations int
+}
+
+// NewWorker creates a worker that refuses jobs for more than maxPixels
+// pixels or more than maxIterations iterations.
+func NewWorker(maxPixels, maxIterations int) *Worker {
+	return &Worker{maxPixels: maxPixels, maxIterations: maxIterations}
+}
+
+func (wk *Worker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "jobs must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	var j job
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobBytes)).Decode(&j); err != nil {
+		http.Error(w, fmt.Sprintf("invalid job: %v", err), http.StatusBadRequest)
+		return
+	}
+	p := j.Parameters
+	if p == nil {
+		http.Error(w, "job has no parameters", http.StatusBadRequest)
+		return
+	}
+	rect := image.Rect(j.Rect[0], j.Rect[1], j.Rect[2], j.Rect[3])
+	if rect.Empty() || !rect.In(image.Rect(0, 0, p.SizeX, p.SizeY)) {
+		http.Error(w, fmt.Sprintf("region %v is not inside the image", rect), http.StatusBadRequest)
+		return
+	}
+	if rect.Dx()*rect.Dy() > wk.maxPixels {
+		http.Error(w, fmt.Sprintf("region %v has more than %d pixels", rect, wk.maxPixels), http.StatusBadRequest)
+		return
+	}
+	if err := checkWork(p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := p.Init(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// after Init, which may choose the iterations
+	if p.MaxIterations > wk.maxIterations {
+		http.Error(w, fmt.Sprintf("iterations must be at most %d", wk.maxIterations), http.StatusBadRequest)
+		return
+	}
+	if opt := p.WholeImageOption(); opt != "" {
+		http.Error(w, fmt.Sprintf("%s needs the whole image, so it cannot be rendered in parts", opt), http.StatusBadRequest)
+		return
+	}
+
+	// stop rendering if the coordinator gives up on the job
+	region, err := p.GenerateRegionContext(r.Context(), rect)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, region); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
+
+// checkWork refuses settings that would make a job cost far more than its
+// pixels and iterations suggest.
+func checkWork(p *mandel.Parameters) error {
+	switch {
+	case p.AntiAlias > maxAntiAlias:
+		return fmt.Errorf("anti-aliasing of %d is too high: the limit is %d", p.AntiAlias, maxAntiAlias)
+	case p.SampleCount > maxSamples:
+		return fmt.Errorf("%d samples per pixel is too many: the limit is %d", p.SampleCount, maxSamples)
+	case p.Passes > maxPasses:
+		return fmt.Errorf("%d passes is too many: the limit is %d", p.Passes, maxPasses)
+	case p.Supersample > 1:
+		return fmt.Errorf("supersampling needs the whole image, so it cannot be rendered in parts")
+	case p.Power > maxPower:
+		return fmt.Errorf("a power of %d is too high: the limit is %d", p.Power, maxPower)
+	case len(p.NewtonCoefficients) > maxNewtonTerms:
+		return fmt.Errorf("%d Newton coefficients is too many: the limit is %d", len(p.NewtonCoefficients), maxNewtonTerms)
+	case p.IterationProbes > maxProbes:
+		return fmt.Errorf("%d iteration probes is too many: the limit is %d", p.IterationProbes, maxProbes)
+	}
+	return nil
+}
+
+// Coordinator hands out regions of a render to a set of workers.
+type Coordinator struct {
+	hosts   []string
+	retries int
+	client  *http.Client
+	next    uint32
+}
+
+// NewCoordinator creates a coordinator for the workers at hosts, given
+// as host:port or as URLs. A region that fails is tried again on the
+// next worker, up to retries more times.
+func NewCoordinator(hosts []string, retries int) (*Coordinator, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("a render farm needs at least one worker")
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative")
+	}
+	c := &Coordinator{retries: retries, client: http.DefaultClient}
+	for _, host := range hosts {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		c.hosts = append(c.hosts, strings.TrimSuffix(host, "/"))
+	}
+	return c, nil
+}
+
+// Generate renders p across the workers a band of p.BandRows rows at a
+// time, keeping two bands going on each worker, and saves the bands to
+// the checkpoint file at path as they finish, as
+// mandel.GenerateCheckpointed does, so a render that stops can be resumed
+// with the same parameters and checkpoint. p must be initialized, and
+// cannot use a Colorer or a System, which do not travel to the workers, or
+// an option that needs the whole image, since each worker renders only
+// its own bands.
+func (c *Coordinator) Generate(ctx context.Context, p *mandel.Parameters, path string) (*image.NRGBA, error) {
+	if err := sendable(p); err != nil {
+		return nil, err
+	}
+	return p.GenerateCheckpointedFunc(ctx, path, 2*len(c.hosts), func(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error) {
+		return c.RenderRegion(ctx, p, rect)
+	})
+}
+
+// RenderRegion has a worker render the part of the image inside rect,
+// moving on to the next worker when one fails. Jobs that a worker refuses
+// as invalid are not tried again.
+func (c *Coordinator) RenderRegion(ctx context.Context, p *mandel.Parameters, rect image.Rectangle) (*image.NRGBA, error) {
+	if err := sendable(p); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(job{Parameters: p, Rect: [4]int{rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y}})
+	if err != nil {
+		return nil, err
+	}
+	start := int(atomic.AddUint32(&c.next, 1))
+	var last error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		host := c.hosts[(start+attempt)%len(c.hosts)]
+		region, retry, err := c.post(ctx, host, body, rect)
+		if err == nil {
+			return region, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		last = fmt.Errorf("worker %s: %v", host, err)
+		if !retry {
+			break
+		}
+	}
+	return nil, fmt.Errorf("region %v failed: %v", rect, last)
+}
+
+// sendable reports why p cannot be split across workers, or returns nil if
+// it can.
+func sendable(p *mandel.Parameters) error {
+	if p.Colorer != nil {
+		return fmt.Errorf("a Colorer cannot be sent to workers")
+	}
+	if p.System != nil {
+		return fmt.Errorf("a System cannot be sent to workers")
+	}
+	if opt := p.WholeImageOption(); opt != "" {
+		return fmt.Errorf("%s needs the whole image, so it cannot be split across workers", opt)
+	}
+	return nil
+}
+
+// post sends a job to one worker and decodes the region it sends back,
+// also reporting whether a failure is worth trying again.
+func (c *Coordinator) post(ctx context.Context, host string, body []byte, rect image.Rectangle) (*image.NRGBA, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, host+BandPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		retry := resp.StatusCode >= 500
+		return nil, retry, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	img, err := png.Decode(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if img.Bounds().Size() != rect.Size() {
+		return nil, true, fmt.Errorf("sent a %v image for a %v region", img.Bounds().Size(), rect.Size())
+	}
+	region := image.NewNRGBA(rect)
+	draw.Draw(region, rect, img, img.Bounds().Min, draw.Src)
+	return region, false, nil
+}
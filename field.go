@@ -0,0 +1,328 @@
+package mandel
+
+import (
+	"image"
+	"math"
+	"time"
+)
+
+// Field holds one raw value per sample for a whole image. Samples are
+// laid out row by row on a grid with AntiAlias×AntiAlias samples per pixel.
+type Field struct {
+	Width, Height int
+	AntiAlias     int
+	Hash          uint64 // identifies the parameters that produced the field, or 0 if they cannot be
+	Values        []float64
+}
+
+func newField(p *Parameters) *Field {
+	f := &Field{
+		Width:     p.SizeX * p.AntiAlias,
+		Height:    p.SizeY * p.AntiAlias,
+		AntiAlias: p.AntiAlias,
+		Hash:      p.fieldHash(),
+	}
+	f.Values = make([]float64, f.Width*f.Height)
+	return f
+}
+
+// At returns the value of the sample at column x, row y of the sample grid.
+func (f *Field) At(x, y int) float64 {
+	return f.Values[y*f.Width+x]
+}
+
+// ComputeField computes the escape value of every sample in the image.
+// Interior samples are 0.
+func (p *Parameters) ComputeField() (*Field, error) {
+	if err := p.checkInit("ComputeField"); err != nil {
+		return nil, err
+	}
+	return p.computeField(p.Continuous), nil
+}
+
+// ComputeIterations computes the escape values that Generate would color,
+// including the savings of SmartIterations, so an interactive palette
+// editor can call Colorize on the result after every change instead of
+// rendering again. Colorize(ComputeIterations()) matches Generate apart
+// from contours, labels, and cropping. Any change to the geometry or
+// iteration settings makes the field stale; Matches detects that.
+func (p *Parameters) ComputeIterations() (*Field, error) {
+	if err := p.checkInit("ComputeIterations"); err != nil {
+		return nil, err
+	}
+	return p.computeIterations(), nil
+}
+
+// computeIterations is ComputeIterations without the check.
+func (p *Parameters) computeIterations() *Field {
+	if p.SmartIterations {
+		return p.smartField()
+	}
+	return p.computeField(p.Continuous)
+}
+
+// Matches reports whether the field was computed with the same geometry
+// and iteration settings as p, so that it can be colored for p.
+func (f *Field) Matches(p *Parameters) bool {
+	return f.Hash != 0 && f.Hash == p.fieldHash()
+}
+
+func (p *Parameters) computeField(continuous bool) *Field {
+	if p.perturbed() {
+		return p.perturbField(continuous)
+	}
+	f := newField(p)
+	var mirror []int
+	if p.mirrorable() {
+		mirror = p.mirrorSampleRows()
+	}
+	p.forRows(f.Height, func(j int) {
+		if mirror != nil && mirror[j] >= 0 {
+			return
+		}
+		xs := make([]float64, f.Width)
+		ys := make([]float64, f.Width)
+		for i := range xs {
+			xs[i], ys[i] = p.samplePoint(i, j)
+		}
+		p.escapes(p.MaxIterations, xs, ys, f.Values[j*f.Width:(j+1)*f.Width], continuous)
+	})
+	mirrored := 0
+	for j, m := range mirror {
+		if m >= 0 {
+			copy(f.Values[j*f.Width:(j+1)*f.Width], f.Values[m*f.Width:])
+			mirrored++
+		}
+	}
+	p.report.mirroredRows(mirrored / p.AntiAlias)
+	return f
+}
+
+// Colorize turns a field into an image using the coloring settings of p,
+// the same way CalcPixel colors its samples. Only the coloring settings
+// and MaxIterations of p matter, so a saved field can be recolored
+// without computing it again. Histogram coloring is fitted to the field.
+func (f *Field) Colorize(p *Parameters) *image.NRGBA {
+	if p.Coloring == "histogram" && p.histogram == nil {
+		q := *p
+		q.histogram = p.histogramField(f)
+		p = &q
+	}
+	aa := f.AntiAlias
+	canvas := image.NewNRGBA(image.Rect(0, 0, f.Width/aa, f.Height/aa))
+	p.forRows(f.Height/aa, func(row int) {
+		for col := 0; col < f.Width/aa; col++ {
+			var sum colorSum
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					sum.add(p.getColor(f.At(i, j)))
+				}
+			}
+			canvas.SetNRGBA(col, row, p.adjust(sum.color()))
+		}
+	})
+	return canvas
+}
+
+// samplePoint maps a position on the sample grid to the complex plane.
+// Positive vertical offsets point up, so they are taken in reverse to keep
+// the grid running top to bottom.
+func (p *Parameters) samplePoint(i, j int) (x, y float64) {
+	aa := p.AntiAlias
+	xoffset, yoffset := p.subpixel(i/aa, j/aa, i%aa, aa-1-j%aa)
+	return p.toPlane(i/aa, j/aa, xoffset, yoffset)
+}
+
+// sampleImagePoint is the position of a sample on the sample grid in the
+// image, in pixels, with x to the right and y up.
+func (p *Parameters) sampleImagePoint(i, j int) (x, y float64) {
+	aa := p.AntiAlias
+	xoffset, yoffset := p.subpixel(i/aa, j/aa, i%aa, aa-1-j%aa)
+	return float64(i/aa) + 0.5 + xoffset, yoffset - float64(j/aa) - 0.5
+}
+
+// sampleOffset is samplePoint as an offset from the center of the image.
+func (p *Parameters) sampleOffset(i, j int) (dx, dy float64) {
+	aa := p.AntiAlias
+	xoffset, yoffset := p.subpixel(i/aa, j/aa, i%aa, aa-1-j%aa)
+	return p.planeOffset(i/aa, j/aa, xoffset, yoffset)
+}
+
+// DistanceEstimate estimates the distance from every sample in the
+// image to the boundary of the set, in complex-plane units. Interior
+// samples are 0. DEMethod selects between tracking the derivative during
+// iteration ("analytic", the default) and differencing the continuous
+// escape values of neighboring samples ("finite"). Custom formulas, other
+// powers, and the burning ship always use finite differences. Past
+// perturbMagnification, the analytic estimate is computed by perturbation.
+func (p *Parameters) DistanceEstimate() (*Field, error) {
+	if err := p.checkInit("DistanceEstimate"); err != nil {
+		return nil, err
+	}
+	return p.distanceEstimate(), nil
+}
+
+// distanceEstimate is DistanceEstimate without the check.
+func (p *Parameters) distanceEstimate() *Field {
+	if p.DEMethod == "finite" || !p.quadratic() {
+		return p.finiteDistance()
+	}
+	if p.perturbed() {
+		return p.perturbDistanceField()
+	}
+
+	f := newField(p)
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			x, y := p.samplePoint(i, j)
+			f.Values[j*f.Width+i] = distance(p.MaxIterations, x, y)
+		}
+	})
+	return f
+}
+
+// GenerateDerivativeField returns |dz/dc| at the end of the orbit of every
+// sample, laid out on the same sample grid as a Field. Escaping orbits
+// report the derivative where they escaped, and the rest report it after
+// MaxIterations iterations. The derivative is always that of z² + c, so
+// Formula and BailoutShape are ignored.
+func (p *Parameters) GenerateDerivativeField() ([]float64, error) {
+	if err := p.checkInit("GenerateDerivativeField"); err != nil {
+		return nil, err
+	}
+
+	f := newField(p)
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			x, y := p.samplePoint(i, j)
+			_, f.Values[j*f.Width+i], _ = derivative(p.MaxIterations, x, y)
+		}
+	})
+	return f.Values, nil
+}
+
+// distanceLevels converts the distance estimate to levels for distance
+// coloring, measuring the distance in pixels so the result looks the same
+// at any magnification. Points a pixel out are at about 0.2, and the level
+// approaches 1 a dozen or so pixels out.
+func (p *Parameters) distanceLevels() *Field {
+	f := p.distanceEstimate()
+	pixel := p.pixelSize()
+	for k, d := range f.Values {
+		if d > 0 {
+			f.Values[k] = math.Max(1-math.Exp(-d/pixel/4), math.SmallestNonzeroFloat64)
+		}
+	}
+	return f
+}
+
+// finiteDistance derives the distance estimate from the gradient of the
+// continuous escape value mu. The potential of a point is G = ln2·2^(1-mu),
+// and the distance estimate G/|∇G| reduces to 1/(ln2·|∇mu|).
+func (p *Parameters) finiteDistance() *Field {
+	// the potential only follows from the log-log smoothing
+	q := *p
+	q.Smoothing = ""
+	mu := q.computeField(true)
+	f := newField(p)
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			if mu.At(i, j) == 0 {
+				continue
+			}
+			gx, gy := p.gradient(mu, i, j, p.samplePoint)
+			f.Values[j*f.Width+i] = 1 / (math.Ln2 * math.Hypot(gx, gy))
+		}
+	})
+	return f
+}
+
+// gradient is the gradient of a field at sample (i, j), with the samples
+// placed by at. It fits a plane by least squares to the differences from
+// the sample to its neighbors across and down the grid, so it is right for
+// sample patterns that are not an even grid as well; on an even grid it
+// is the central difference, or the one-sided one at the edges of the
+// image. The halton and sobol patterns take in the diagonal neighbors too,
+// since the neighbors of a sample on their grid can lie in a line. It is
+// zero where the neighbors do not span the plane.
+func (p *Parameters) gradient(f *Field, i, j int, at func(i, j int) (x, y float64)) (gx, gy float64) {
+	x, y := at(i, j)
+	v := f.At(i, j)
+	var sxx, sxy, syy, sxv, syv float64
+	for dj := -1; dj <= 1; dj++ {
+		for di := -1; di <= 1; di++ {
+			if di == 0 && dj == 0 || di != 0 && dj != 0 && !p.sequencePattern() {
+				continue
+			}
+			ni, nj := i+di, j+dj
+			if ni < 0 || nj < 0 || ni >= f.Width || nj >= f.Height {
+				continue
+			}
+			nx, ny := at(ni, nj)
+			dx, dy, dv := nx-x, ny-y, f.At(ni, nj)-v
+			sxx, sxy, syy = sxx+dx*dx, sxy+dx*dy, syy+dy*dy
+			sxv, syv = sxv+dx*dv, syv+dy*dv
+		}
+	}
+	det := sxx*syy - sxy*sxy
+	if det <= 1e-12*sxx*syy {
+		return 0, 0
+	}
+	return (sxv*syy - syv*sxy) / det, (syv*sxx - sxv*sxy) / det
+}
+
+// forRows calls fn once for every row in [0, rows), handing the rows to
+// the row workers ChunkRows at a time; one row at a time balances the
+// load best when some rows cost far more than others, while larger chunks
+// cut the overhead for cheap rows. No more workers are started than
+// there are chunks, and it stops handing out chunks once the render is
+// cancelled. The workers are goroutines of their own, or those of the
+// Pool the render was given to.
+func (p *Parameters) forRows(rows int, fn func(row int)) {
+	chunk := p.ChunkRows
+	if chunk < 1 {
+		chunk = 1
+	}
+	fanout := p.workers()
+	if chunks := (rows + chunk - 1) / chunk; fanout > chunks {
+		fanout = chunks
+	}
+	pass, started := p.report.pass(rows), time.Now()
+	if pass != nil {
+		render := fn
+		fn = func(row int) {
+			t := time.Now()
+			render(row)
+			pass.RowTimes[row] = time.Since(t)
+		}
+	}
+	rowch := make(chan int)
+	done := make(chan struct{})
+	worker := func() {
+		for start := range rowch {
+			for row := start; row < start+chunk && row < rows; row++ {
+				fn(row)
+				p.progress.rowDone()
+			}
+		}
+		done <- struct{}{}
+	}
+	for i := 0; i < fanout; i++ {
+		if p.pool != nil {
+			p.pool.jobs <- worker
+		} else {
+			go worker()
+		}
+	}
+	p.progress.add(rows)
+	for start := 0; start < rows && !p.cancelled(); start += chunk {
+		rowch <- start
+	}
+	close(rowch)
+	for i := 0; i < fanout; i++ {
+		<-done
+	}
+	if pass != nil {
+		pass.Time = time.Since(started)
+	}
+}
@@ -0,0 +1,235 @@
+package mandel
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+)
+
+// field files start with a magic string and a version number, followed by
+// a flags byte and the (possibly gzipped) body:
+//
+//	width, height, anti-alias  uint32
+//	parameters hash            uint64
+//	value encoding             uint8
+//	values                     float32 or uint16 each
+//
+// All numbers are little endian.
+const (
+	fieldMagic   = "MFLD"
+	fieldVersion = 1
+
+	fieldGzip = 1 << 0
+
+	fieldFloat32 = 1
+	fieldUint16  = 2
+
+	// the most samples a field file may have on a side
+	fieldMaxSide = 1 << 20
+
+	// values are read this many at a time
+	fieldChunk = 1 << 16
+)
+
+// WriteBinary writes the field in a compact, gzipped binary format that
+// ReadField can load. Fields with only whole-number values are stored
+// as 16-bit integers when they fit.
+func (f *Field) WriteBinary(w io.Writer) error {
+	if _, err := io.WriteString(w, fieldMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{fieldVersion, fieldGzip}); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	header := []interface{}{uint32(f.Width), uint32(f.Height), uint32(f.AntiAlias), f.Hash}
+	for _, v := range header {
+		if err := binary.Write(bw, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	encoding := byte(fieldUint16)
+	for _, v := range f.Values {
+		if v != math.Floor(v) || v < 0 || v > math.MaxUint16 {
+			encoding = fieldFloat32
+			break
+		}
+	}
+	if err := bw.WriteByte(encoding); err != nil {
+		return err
+	}
+
+	var err error
+	if encoding == fieldUint16 {
+		data := make([]uint16, len(f.Values))
+		for i, v := range f.Values {
+			data[i] = uint16(v)
+		}
+		err = binary.Write(bw, binary.LittleEndian, data)
+	} else {
+		data := make([]float32, len(f.Values))
+		for i, v := range f.Values {
+			data[i] = float32(v)
+		}
+		err = binary.Write(bw, binary.LittleEndian, data)
+	}
+	if err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadField loads a field written by WriteBinary.
+func ReadField(r io.Reader) (*Field, error) {
+	header := make([]byte, len(fieldMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("error reading field header: %v", err)
+	}
+	if string(header[:len(fieldMagic)]) != fieldMagic {
+		return nil, fmt.Errorf("not a field file")
+	}
+	if version := header[len(fieldMagic)]; version != fieldVersion {
+		return nil, fmt.Errorf("unsupported field file version %d", version)
+	}
+	if header[len(fieldMagic)+1]&fieldGzip != 0 {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing field: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	r = bufio.NewReader(r)
+
+	var dims [3]uint32
+	f := new(Field)
+	if err := binary.Read(r, binary.LittleEndian, &dims); err != nil {
+		return nil, fmt.Errorf("error reading field dimensions: %v", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &f.Hash); err != nil {
+		return nil, fmt.Errorf("error reading field hash: %v", err)
+	}
+	f.Width, f.Height, f.AntiAlias = int(dims[0]), int(dims[1]), int(dims[2])
+	if f.AntiAlias < 1 || f.Width%f.AntiAlias != 0 || f.Height%f.AntiAlias != 0 ||
+		f.Width > fieldMaxSide || f.Height > fieldMaxSide {
+		return nil, fmt.Errorf("invalid field dimensions %dx%d with anti-aliasing %d", f.Width, f.Height, f.AntiAlias)
+	}
+
+	var encoding [1]byte
+	if _, err := io.ReadFull(r, encoding[:]); err != nil {
+		return nil, fmt.Errorf("error reading field encoding: %v", err)
+	}
+	if encoding[0] != fieldUint16 && encoding[0] != fieldFloat32 {
+		return nil, fmt.Errorf("unknown field value encoding %d", encoding[0])
+	}
+
+	// the values are read a chunk at a time, so the memory used grows
+	// with the data actually there rather than what the header claims
+	n := f.Width * f.Height
+	size := n
+	if size > fieldChunk {
+		size = fieldChunk
+	}
+	f.Values = make([]float64, 0, size)
+	ints, floats := make([]uint16, size), make([]float32, size)
+	for len(f.Values) < n {
+		k := n - len(f.Values)
+		if k > size {
+			k = size
+		}
+		var err error
+		if encoding[0] == fieldUint16 {
+			if err = binary.Read(r, binary.LittleEndian, ints[:k]); err == nil {
+				for _, v := range ints[:k] {
+					f.Values = append(f.Values, float64(v))
+				}
+			}
+		} else {
+			if err = binary.Read(r, binary.LittleEndian, floats[:k]); err == nil {
+				for _, v := range floats[:k] {
+					f.Values = append(f.Values, float64(v))
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading field values: %v", err)
+		}
+	}
+	return f, nil
+}
+
+// fieldHash identifies the parameters that determine the values in a
+// field. Coloring settings are left out, since a field can be recolored.
+// It is 0 if the System has no CacheKey to identify it by.
+func (p *Parameters) fieldHash() uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, p.CenterX, p.CenterY, p.Magnification, p.MaxIterations,
+		p.SizeX, p.SizeY, p.AntiAlias, p.Continuous, p.SmoothBailout,
+		p.Formula, p.ExpMap, p.ExpMapOctaves, p.BailoutShape)
+	if p.ExpMapAngleStart != 0 || p.ExpMapAngleEnd != 0 || p.ExpMapRadiusStart != 0 || p.ExpMapRadiusEnd != 0 {
+		fmt.Fprint(h, p.ExpMapAngleStart, p.ExpMapAngleEnd, p.ExpMapRadiusStart, p.ExpMapRadiusEnd)
+	}
+	if p.CompensatedSum {
+		fmt.Fprint(h, " compensated")
+	}
+	if p.CurvatureColor {
+		fmt.Fprint(h, " curvature")
+	}
+	if p.ExactInterior {
+		fmt.Fprint(h, " exactinterior")
+	}
+	if p.SampleOffsetX != 0 || p.SampleOffsetY != 0 {
+		fmt.Fprint(h, p.SampleOffsetX, p.SampleOffsetY)
+	}
+	if p.Power > 2 || (p.Fractal != "" && p.Fractal != "mandelbrot") {
+		fmt.Fprint(h, " power", p.Power, p.Fractal)
+	}
+	if p.Fractal == "julia" {
+		fmt.Fprint(h, " julia", p.JuliaCX, p.JuliaCY)
+	}
+	if p.Fractal == "newton" {
+		fmt.Fprint(h, " newton", p.NewtonCoefficients)
+	}
+	if p.system != nil {
+		key, ok := pluginKey(p.system)
+		if !ok {
+			return 0
+		}
+		fmt.Fprint(h, " system ", key)
+	}
+	if p.boundsSet() {
+		fmt.Fprint(h, " bounds", p.MinX, p.MinY, p.MaxX, p.MaxY)
+	}
+	if p.Rotation != 0 || len(p.Transform) > 0 {
+		fmt.Fprint(h, " view", p.Rotation, p.Transform)
+	}
+	if p.JitterAA && p.AntiAlias > 1 {
+		fmt.Fprint(h, " jitter", p.Seed)
+	}
+	if p.SamplePattern != "" && p.SamplePattern != "grid" && p.AntiAlias > 1 {
+		fmt.Fprint(h, " pattern", p.SamplePattern)
+	}
+	if p.Smoothing == "linear" {
+		fmt.Fprint(h, " linear")
+	}
+	if p.Bailout != 0 {
+		fmt.Fprint(h, " escaperadius", p.Bailout)
+	}
+	if p.PreciseX != "" || p.PreciseY != "" {
+		fmt.Fprint(h, " precise", p.PreciseX, p.PreciseY)
+	}
+	if p.Precision != 0 {
+		fmt.Fprint(h, " precision", p.Precision)
+	}
+	return h.Sum64()
+}
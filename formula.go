@@ -0,0 +1,219 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// cfunc is one step of a custom iteration formula.
+type cfunc func(z, c complex128) complex128
+
+// compileFormula parses a formula such as "z*z*z + sin(z) + c" into a
+// function of z and c. An optional leading "z =" is ignored. Formulas may
+// use z, c, i, numbers, + - * / ^, parentheses, and the functions sin,
+// cos, exp, conj, and abs.
+func compileFormula(src string) (cfunc, error) {
+	src = strings.TrimSpace(src)
+	if strings.HasPrefix(src, "z") {
+		if rest := strings.TrimSpace(src[1:]); strings.HasPrefix(rest, "=") {
+			src = rest[1:]
+		}
+	}
+	ps := &formulaParser{src: src}
+	ps.next()
+	f, err := ps.expr()
+	if err != nil {
+		return nil, err
+	}
+	if ps.tok != "" {
+		return nil, fmt.Errorf("unexpected %q in formula", ps.tok)
+	}
+	return f, nil
+}
+
+type formulaParser struct {
+	src string
+	tok string
+}
+
+// next advances to the next token: a number, a name, or a single
+// punctuation character. The empty string marks the end of the input.
+func (ps *formulaParser) next() {
+	ps.src = strings.TrimLeftFunc(ps.src, unicode.IsSpace)
+	if ps.src == "" {
+		ps.tok = ""
+		return
+	}
+	n := 1
+	switch ch := rune(ps.src[0]); {
+	case ch >= '0' && ch <= '9' || ch == '.':
+		n = strings.IndexFunc(ps.src, func(r rune) bool { return !(r >= '0' && r <= '9' || r == '.') })
+	case unicode.IsLetter(ch):
+		n = strings.IndexFunc(ps.src, func(r rune) bool { return !unicode.IsLetter(r) })
+	}
+	if n < 0 {
+		n = len(ps.src)
+	}
+	ps.tok, ps.src = ps.src[:n], ps.src[n:]
+}
+
+// expr := term { ("+" | "-") term }
+func (ps *formulaParser) expr() (cfunc, error) {
+	left, err := ps.term()
+	if err != nil {
+		return nil, err
+	}
+	for ps.tok == "+" || ps.tok == "-" {
+		op := ps.tok
+		ps.next()
+		right, err := ps.term()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(z, c complex128) complex128 { return l(z, c) + right(z, c) }
+		} else {
+			left = func(z, c complex128) complex128 { return l(z, c) - right(z, c) }
+		}
+	}
+	return left, nil
+}
+
+// term := unary { ("*" | "/") unary }
+func (ps *formulaParser) term() (cfunc, error) {
+	left, err := ps.unary()
+	if err != nil {
+		return nil, err
+	}
+	for ps.tok == "*" || ps.tok == "/" {
+		op := ps.tok
+		ps.next()
+		right, err := ps.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(z, c complex128) complex128 { return l(z, c) * right(z, c) }
+		} else {
+			left = func(z, c complex128) complex128 { return l(z, c) / right(z, c) }
+		}
+	}
+	return left, nil
+}
+
+// unary := "-" unary | power
+func (ps *formulaParser) unary() (cfunc, error) {
+	if ps.tok == "-" {
+		ps.next()
+		f, err := ps.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(z, c complex128) complex128 { return -f(z, c) }, nil
+	}
+	return ps.power()
+}
+
+// power := atom [ "^" unary ]
+func (ps *formulaParser) power() (cfunc, error) {
+	base, err := ps.atom()
+	if err != nil {
+		return nil, err
+	}
+	if ps.tok != "^" {
+		return base, nil
+	}
+	ps.next()
+	exp, err := ps.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(z, c complex128) complex128 { return cmplx.Pow(base(z, c), exp(z, c)) }, nil
+}
+
+var formulaFuncs = map[string]func(complex128) complex128{
+	"sin":  cmplx.Sin,
+	"cos":  cmplx.Cos,
+	"exp":  cmplx.Exp,
+	"conj": cmplx.Conj,
+	"abs":  func(v complex128) complex128 { return complex(cmplx.Abs(v), 0) },
+}
+
+// atom := number | "z" | "c" | "i" | name "(" expr ")" | "(" expr ")"
+func (ps *formulaParser) atom() (cfunc, error) {
+	tok := ps.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of formula")
+	case tok == "z":
+		ps.next()
+		return func(z, c complex128) complex128 { return z }, nil
+	case tok == "c":
+		ps.next()
+		return func(z, c complex128) complex128 { return c }, nil
+	case tok == "i":
+		ps.next()
+		return func(z, c complex128) complex128 { return 1i }, nil
+	case tok == "(":
+		ps.next()
+		f, err := ps.expr()
+		if err != nil {
+			return nil, err
+		}
+		if ps.tok != ")" {
+			return nil, fmt.Errorf("missing ) in formula")
+		}
+		ps.next()
+		return f, nil
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '.':
+		n, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in formula", tok)
+		}
+		ps.next()
+		v := complex(n, 0)
+		return func(z, c complex128) complex128 { return v }, nil
+	}
+
+	fn, ok := formulaFuncs[tok]
+	if !ok {
+		return nil, fmt.Errorf("unknown name %q in formula", tok)
+	}
+	ps.next()
+	if ps.tok != "(" {
+		return nil, fmt.Errorf("missing ( after %s in formula", tok)
+	}
+	arg, err := ps.atom()
+	if err != nil {
+		return nil, err
+	}
+	return func(z, c complex128) complex128 { return fn(arg(z, c)) }, nil
+}
+
+// iterateFormula is mandel for a custom formula. Continuous smoothing
+// assumes the formula grows quadratically once it escapes, as z² + c does.
+func iterateFormula(f cfunc, maxIters int, x, y float64, smooth smoother, bailout float64) float64 {
+	c := complex(x, y)
+	z := f(0, c)
+	for iters := 1; iters <= maxIters; iters++ {
+		mag2 := real(z)*real(z) + imag(z)*imag(z)
+		if math.IsNaN(mag2) || math.IsInf(mag2, 1) {
+			// overflow means the orbit escaped
+			return float64(iters)
+		}
+		if mag2 >= bailout {
+			if smooth != nil {
+				return smooth(iters, mag2, bailout)
+			}
+			return float64(iters)
+		}
+		z = f(z, c)
+	}
+	return 0.0
+}
@@ -0,0 +1,25 @@
+package mandel
+
+import "image"
+
+// GenerateWithGlitchMap renders the image along with a confidence map of
+// the same size, where 255 marks a pixel whose samples were all iterated
+// against the main reference orbit, or directly when the render does not
+// use perturbation. Samples that glitched and were fixed with another
+// reference count as 192, and samples that could not be fixed, and fell
+// back to plain float64, count as 0.
+func (p *Parameters) GenerateWithGlitchMap() (*image.NRGBA, *image.Gray, error) {
+	if err := p.checkInit("GenerateWithGlitchMap"); err != nil {
+		return nil, nil, err
+	}
+	q := *p
+	q.confidence = image.NewGray(image.Rect(0, 0, p.SizeX, p.SizeY))
+	for i := range q.confidence.Pix {
+		q.confidence.Pix[i] = confidenceDirect
+	}
+	canvas, err := q.Generate()
+	if err != nil {
+		return nil, nil, err
+	}
+	return canvas, q.confidence, nil
+}
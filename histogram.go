@@ -0,0 +1,51 @@
+package mandel
+
+import "math"
+
+// histogramField builds the cumulative histogram of the escaped samples
+// in a field for histogram coloring: entry k is the fraction of escaped
+// samples with fewer than k iterations.
+func (p *Parameters) histogramField(f *Field) []float64 {
+	var counts []float64
+	total := 0.0
+	for _, v := range f.Values {
+		if v == 0 {
+			continue
+		}
+		k := histogramBin(p.colorValue(v))
+		for len(counts) <= k {
+			counts = append(counts, 0)
+		}
+		counts[k]++
+		total++
+	}
+
+	cdf := make([]float64, len(counts)+1)
+	for k, n := range counts {
+		cdf[k+1] = cdf[k] + n/total
+	}
+	return cdf
+}
+
+// histogramBin is the histogram entry that counts an escape value.
+func histogramBin(v float64) int {
+	if v < 0 || math.IsNaN(v) {
+		return 0
+	}
+	return int(math.Floor(v))
+}
+
+// histogramLevel maps an escape value onto [0, 1] by the fraction of
+// the frame's escaped samples that took fewer iterations. Continuous
+// values move smoothly toward the next entry by their fractional part.
+func (p *Parameters) histogramLevel(iters float64) float64 {
+	k := histogramBin(iters)
+	if k >= len(p.histogram)-1 {
+		return 1
+	}
+	t := p.histogram[k]
+	if frac := iters - float64(k); frac > 0 {
+		t += frac * (p.histogram[k+1] - p.histogram[k])
+	}
+	return t
+}
@@ -0,0 +1,133 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// longest cycle interior distance estimation looks for
+const interiorMaxPeriod = 4096
+
+// initInterior checks the InteriorColoring settings. The magnitude,
+// average, and distance colorings spread their values across the palette,
+// and only work with palette coloring of z² + c.
+func (p *Parameters) initInterior() error {
+	switch p.InteriorColoring {
+	case "", "flat":
+		return nil
+	case "magnitude", "average", "distance":
+	default:
+		return fmt.Errorf("unknown interior coloring %q", p.InteriorColoring)
+	}
+	if p.Coloring != "" && p.Coloring != "palette" {
+		return fmt.Errorf("interior coloring cannot be used with %s coloring", p.Coloring)
+	}
+	if p.Colorer != nil {
+		return fmt.Errorf("interior coloring cannot be used with a Colorer")
+	}
+	if !p.quadratic() || p.norm != nil {
+		return fmt.Errorf("interior coloring only works with z² + c and a circular bailout")
+	}
+	return nil
+}
+
+// interiorColor colors a sample inside the set according to
+// InteriorColoring, spreading its level across the palette once, as
+// distance coloring does.
+func (p *Parameters) interiorColor(x, y float64) (r, g, b, a float64) {
+	var level float64
+	switch p.InteriorColoring {
+	case "magnitude":
+		a, b, _ := interiorOrbit(p.MaxIterations, x, y)
+		level = math.Hypot(a, b) / 2
+	case "average":
+		_, _, avg := interiorOrbit(p.MaxIterations, x, y)
+		level = avg / 2
+	case "distance":
+		// measured in pixels, as distanceLevels does
+		level = 1 - math.Exp(-interiorDistance(p.MaxIterations, x, y)/p.pixelSize()/4)
+	}
+	if !(level > 0) {
+		level = 0
+	}
+	return p.levelColor(math.Max(math.Min(level, 1), math.SmallestNonzeroFloat64))
+}
+
+// interiorOrbit iterates z² + c from z = c for maxIters steps, and
+// returns where the orbit ends up along with the average of |z| along
+// the way.
+func interiorOrbit(maxIters int, x, y float64) (a, b, avg float64) {
+	a, b = x, y
+	sum := 0.0
+	for iters := 1; iters <= maxIters; iters++ {
+		sum += math.Hypot(a, b)
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+	}
+	return a, b, sum / float64(maxIters)
+}
+
+// interiorDistance estimates the distance from an interior point c to the
+// boundary of the set. It finds the period of the cycle the orbit settles
+// into, pins the cycle down with Newton's method, and applies the
+// interior distance formula to the derivatives taken around it. Points
+// whose cycle cannot be found return 0.
+func interiorDistance(maxIters int, x, y float64) float64 {
+	c := complex(x, y)
+	a, b, _ := interiorOrbit(maxIters, x, y)
+	z0 := complex(a, b)
+
+	// the period is the first return close to where the orbit ended, or
+	// else the closest return
+	period, closest := 0, math.Inf(1)
+	w := z0
+	for k := 1; k <= interiorMaxPeriod; k++ {
+		w = w*w + c
+		if d := cmplx.Abs(w - z0); d < closest {
+			period, closest = k, d
+			if d < 1e-9 {
+				break
+			}
+		}
+	}
+	if period == 0 {
+		return 0
+	}
+
+	// solve f^period(z) = z
+	for n := 0; n < 16; n++ {
+		w, dz := z0, complex(1, 0)
+		for k := 0; k < period; k++ {
+			dz = 2 * w * dz
+			w = w*w + c
+		}
+		if dz == 1 {
+			break
+		}
+		step := (w - z0) / (dz - 1)
+		z0 -= step
+		if cmplx.Abs(step) < 1e-15 {
+			break
+		}
+	}
+
+	// derivatives of f^period with respect to z and c around the cycle
+	w = z0
+	dz, dzdz, dc, dcdz := complex(1, 0), complex(0, 0), complex(0, 0), complex(0, 0)
+	for k := 0; k < period; k++ {
+		dz, dzdz, dc, dcdz = 2*w*dz, 2*(dz*dz+w*dzdz), 2*w*dc+1, 2*(w*dcdz+dc*dz)
+		w = w*w + c
+	}
+	if cmplx.Abs(dz) >= 1 {
+		return 0
+	}
+	d := (1 - real(dz)*real(dz) - imag(dz)*imag(dz)) / cmplx.Abs(dcdz+dzdz*dc/(1-dz))
+	if math.IsNaN(d) || math.IsInf(d, 0) {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,136 @@
+package mandel
+
+import "math"
+
+// pixelSample returns the offset from the center of pixel (col, row) of
+// its kth sample, taking the anti-aliasing grid a row at a time from the
+// bottom, or the kth point of the sequence for the halton and sobol
+// patterns.
+func (p *Parameters) pixelSample(col, row, k int) (xoffset, yoffset float64) {
+	if p.samples != p.AntiAlias*p.AntiAlias {
+		// only the halton and sobol patterns take other counts
+		return p.sequenceSample(col, row, k, p.samples)
+	}
+	return p.subpixel(col, row, k%p.AntiAlias, k/p.AntiAlias)
+}
+
+// sequencePattern reports whether the samples of a pixel are points of a
+// low-discrepancy sequence, so there can be any number of them.
+func (p *Parameters) sequencePattern() bool {
+	return p.SamplePattern == "halton" || p.SamplePattern == "sobol"
+}
+
+// subpixel returns the offset from the center of pixel (col, row) of the
+// sample in column i, row j of its anti-aliasing grid, counting rows from
+// the bottom. SamplePattern decides where the samples go: "rotated" turns
+// the grid by atan(1/AntiAlias) and shrinks it so no two samples share a
+// row or a column, which catches near-horizontal and near-vertical
+// filaments better, and "halton" and "sobol" take the first points of
+// those sequences. Under JitterAA each sample moves by a pseudo-random
+// amount chosen by hashing the seed with the pixel and the sample, so the
+// same parameters always produce the same image.
+func (p *Parameters) subpixel(col, row, i, j int) (xoffset, yoffset float64) {
+	xoffset, yoffset = p.subpixOffsets[i], p.subpixOffsets[j]
+	if p.AntiAlias == 1 {
+		return xoffset, yoffset
+	}
+	if p.sequencePattern() {
+		return p.sequenceSample(col, row, j*p.AntiAlias+i, p.AntiAlias*p.AntiAlias)
+	}
+	cell := 1 / float64(p.AntiAlias)
+	if p.SamplePattern == "rotated" {
+		// the grid turned by atan(1/N) and shrunk to fit the pixel, so
+		// each sample has a column and a row of an N²×N² grid to itself
+		n := p.AntiAlias
+		cell /= float64(n)
+		xoffset = (float64(i*n+j)+0.5)*cell - 0.5
+		yoffset = (float64(j*n+n-1-i)+0.5)*cell - 0.5
+	}
+	if p.JitterAA {
+		h := jitterHash(uint64(p.Seed), uint64(col), uint64(row), uint64(j*p.AntiAlias+i))
+		xoffset += (float64(h>>40)/(1<<24) - 0.5) * cell
+		yoffset += (float64(h&(1<<24-1))/(1<<24) - 0.5) * cell
+	}
+	return xoffset, yoffset
+}
+
+// sequenceSample returns the offset from the center of pixel (col, row)
+// of the kth of its n samples under the halton and sobol patterns, which
+// take successive points of their sequences.
+func (p *Parameters) sequenceSample(col, row, k, n int) (xoffset, yoffset float64) {
+	if p.SamplePattern == "sobol" {
+		x, y := sobol(uint32(k))
+		if p.JitterAA {
+			// flipping the same bits of every point scrambles the pattern
+			// from pixel to pixel while keeping the points stratified
+			h := jitterHash(uint64(p.Seed), uint64(col), uint64(row))
+			x, y = x^uint32(h>>32), y^uint32(h)
+		} else {
+			// center the points in the cells of the finest grid they fill
+			half := uint32(1 << 31)
+			for m := 1; m < n; m *= 2 {
+				half >>= 1
+			}
+			x, y = x+half, y+half
+		}
+		return float64(x)/(1<<32) - 0.5, float64(y)/(1<<32) - 0.5
+	}
+	xoffset, yoffset = radicalInverse(k+1, 2), radicalInverse(k+1, 3)
+	if p.JitterAA {
+		// shift the whole pattern around the pixel, wrapping at the
+		// edges, so it does not repeat from pixel to pixel
+		h := jitterHash(uint64(p.Seed), uint64(col), uint64(row))
+		xoffset += float64(h>>40) / (1 << 24)
+		yoffset += float64(h&(1<<24-1)) / (1 << 24)
+	}
+	return wrapOffset(xoffset - 0.5), wrapOffset(yoffset - 0.5)
+}
+
+// wrapOffset wraps an offset from the center of a pixel back into the
+// pixel, [-0.5, 0.5).
+func wrapOffset(v float64) float64 {
+	return v - math.Floor(v+0.5)
+}
+
+// radicalInverse mirrors the digits of n in the given base around the
+// radix point, giving the nth point of the van der Corput sequence, and
+// in bases 2 and 3 the coordinates of the Halton sequence.
+func radicalInverse(n, base int) float64 {
+	v, scale := 0.0, 1.0
+	for ; n > 0; n /= base {
+		scale /= float64(base)
+		v += float64(n%base) * scale
+	}
+	return v
+}
+
+// sobol returns the nth point of the two-dimensional Sobol sequence as
+// fractions of 2³². The first coordinate is the van der Corput sequence in
+// base 2, and the second takes its direction numbers from the polynomial
+// x + 1, each one the last shifted right by one bit and added in.
+func sobol(n uint32) (x, y uint32) {
+	v := uint32(1 << 31)
+	for bit := uint32(1 << 31); n != 0; bit, n = bit>>1, n>>1 {
+		if n&1 != 0 {
+			x ^= bit
+			y ^= v
+		}
+		v ^= v >> 1
+	}
+	return x, y
+}
+
+// jitterHash mixes its arguments with the splitmix64 finalizer, which is
+// much cheaper than seeding a random source for every pixel.
+func jitterHash(vals ...uint64) uint64 {
+	var h uint64
+	for _, v := range vals {
+		h ^= v + 0x9e3779b97f4a7c15 + h<<6 + h>>2
+		h ^= h >> 30
+		h *= 0xbf58476d1ce4e5b9
+		h ^= h >> 27
+		h *= 0x94d049bb133111eb
+		h ^= h >> 31
+	}
+	return h
+}
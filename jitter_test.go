@@ -0,0 +1,113 @@
+package mandel
+
+import (
+	"math"
+	"testing"
+)
+
+// TestRotatedPattern checks that the rotated grid stays inside the pixel
+// for every size, with no two samples in the same row or column.
+func TestRotatedPattern(t *testing.T) {
+	for aa := 2; aa <= 8; aa++ {
+		p := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 4, SizeY: 4, Palette: DefaultPalette(), AntiAlias: aa, SamplePattern: "rotated"}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		xs, ys := make(map[float64]bool), make(map[float64]bool)
+		for k := 0; k < p.samples; k++ {
+			x, y := p.pixelSample(1, 2, k)
+			if x < -0.5 || x >= 0.5 || y < -0.5 || y >= 0.5 {
+				t.Errorf("%dx%d: sample %d at (%g, %g) is outside the pixel", aa, aa, k, x, y)
+			}
+			if xs[x] || ys[y] {
+				t.Errorf("%dx%d: sample %d at (%g, %g) shares a row or column", aa, aa, k, x, y)
+			}
+			xs[x], ys[y] = true, true
+		}
+	}
+}
+
+// TestSobol checks the start of the Sobol sequence, and that any count of
+// samples fills the pixel.
+func TestSobol(t *testing.T) {
+	want := [][2]float64{{0, 0}, {0.5, 0.5}, {0.25, 0.75}, {0.75, 0.25}, {0.125, 0.625}, {0.625, 0.125}, {0.375, 0.375}, {0.875, 0.875}}
+	for n, w := range want {
+		x, y := sobol(uint32(n))
+		if got := [2]float64{float64(x) / (1 << 32), float64(y) / (1 << 32)}; got != w {
+			t.Errorf("sobol(%d) = %v, want %v", n, got, w)
+		}
+	}
+
+	for _, samples := range []int{1, 3, 5, 7, 12} {
+		for _, pattern := range []string{"halton", "sobol"} {
+			p := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 4, SizeY: 4, Palette: DefaultPalette(), AntiAlias: 2, SamplePattern: pattern, SampleCount: samples}
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			var sx, sy float64
+			for k := 0; k < samples; k++ {
+				x, y := p.pixelSample(0, 0, k)
+				if x < -0.5 || x >= 0.5 || y < -0.5 || y >= 0.5 {
+					t.Errorf("%s, %d samples: sample %d at (%g, %g) is outside the pixel", pattern, samples, k, x, y)
+				}
+				sx, sy = sx+x, sy+y
+			}
+			if math.Abs(sx/float64(samples)) > 0.25 || math.Abs(sy/float64(samples)) > 0.25 {
+				t.Errorf("%s, %d samples: centered on (%g, %g)", pattern, samples, sx/float64(samples), sy/float64(samples))
+			}
+		}
+	}
+}
+
+// TestSampleCountOptions checks that counts other than AntiAlias² are
+// refused where they cannot be used.
+func TestSampleCountOptions(t *testing.T) {
+	base := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 4, SizeY: 4, Palette: DefaultPalette(), AntiAlias: 2, SamplePattern: "sobol", SampleCount: 5}
+	p := base
+	if err := p.Init(); err != nil {
+		t.Errorf("sobol with 5 samples: %v", err)
+	}
+	p = base
+	p.SamplePattern = "rotated"
+	if err := p.Init(); err == nil {
+		t.Errorf("the rotated pattern took 5 samples")
+	}
+	p = base
+	p.Coloring = "histogram"
+	if err := p.Init(); err == nil {
+		t.Errorf("histogram coloring took 5 samples")
+	}
+	p = base
+	p.SampleCount = 4
+	p.SamplePattern = "grid"
+	if err := p.Init(); err != nil {
+		t.Errorf("a grid with AntiAlias² samples: %v", err)
+	}
+}
+
+// TestGradient checks that the gradient of a field that rises evenly
+// across the plane comes out the same for every sample pattern, though
+// the samples are not evenly spaced.
+func TestGradient(t *testing.T) {
+	for _, pattern := range []string{"grid", "rotated", "halton", "sobol"} {
+		p := Parameters{Magnification: 1, MaxIterations: 100, SizeX: 6, SizeY: 4, Palette: DefaultPalette(), AntiAlias: 3, SamplePattern: pattern, JitterAA: true}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		f := newField(&p)
+		for j := 0; j < f.Height; j++ {
+			for i := 0; i < f.Width; i++ {
+				x, y := p.samplePoint(i, j)
+				f.Values[j*f.Width+i] = 3*x - 2*y
+			}
+		}
+		for j := 0; j < f.Height; j++ {
+			for i := 0; i < f.Width; i++ {
+				gx, gy := p.gradient(f, i, j, p.samplePoint)
+				if math.Abs(gx-3) > 1e-6 || math.Abs(gy+2) > 1e-6 {
+					t.Errorf("%s: gradient at (%d, %d) is (%g, %g), want (3, -2)", pattern, i, j, gx, gy)
+				}
+			}
+		}
+	}
+}
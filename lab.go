@@ -0,0 +1,104 @@
+package mandel
+
+import (
+	"image/color"
+	"math"
+)
+
+// lab is a color in CIELAB, with the alpha channel carried along.
+type lab struct {
+	l, a, b, alpha float64
+}
+
+// the D65 white point in XYZ
+const labXn, labYn, labZn = 0.95047, 1.0, 1.08883
+
+func toLab(c color.NRGBA) lab {
+	r := srgbToLinear(float64(c.R) / 255)
+	g := srgbToLinear(float64(c.G) / 255)
+	b := srgbToLinear(float64(c.B) / 255)
+	x := (0.4124*r + 0.3576*g + 0.1805*b) / labXn
+	y := (0.2126*r + 0.7152*g + 0.0722*b) / labYn
+	z := (0.0193*r + 0.1192*g + 0.9505*b) / labZn
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return lab{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz), float64(c.A)}
+}
+
+func (c lab) nrgba() color.NRGBA {
+	fy := (c.l + 16) / 116
+	fx := fy + c.a/500
+	fz := fy - c.b/200
+	finv := func(t float64) float64 {
+		if t*t*t > 216.0/24389 {
+			return t * t * t
+		}
+		return (116*t - 16) * 27 / 24389
+	}
+	x, y, z := finv(fx)*labXn, finv(fy)*labYn, finv(fz)*labZn
+
+	channel := func(v float64) uint8 {
+		v = linearToSRGB(math.Max(0, math.Min(1, v)))
+		return uint8(v*255 + 0.5)
+	}
+	return color.NRGBA{
+		channel(3.2406*x - 1.5372*y - 0.4986*z),
+		channel(-0.9689*x + 1.8758*y + 0.0415*z),
+		channel(0.0557*x - 0.2040*y + 1.0570*z),
+		uint8(math.Max(0, math.Min(255, c.alpha)) + 0.5),
+	}
+}
+
+func (c lab) distance(d lab) float64 {
+	return math.Sqrt((c.l-d.l)*(c.l-d.l) + (c.a-d.a)*(c.a-d.a) + (c.b-d.b)*(c.b-d.b))
+}
+
+// perceptualPalette resamples a palette so that successive entries,
+// including the step from the last back around to the first, are equally
+// far apart in CIELAB. New entries are interpolated in CIELAB along the
+// original path, so the palette keeps its colors and only the pacing
+// changes.
+func perceptualPalette(palette []color.NRGBA) []color.NRGBA {
+	n := len(palette)
+	pts := make([]lab, n)
+	for i, c := range palette {
+		pts[i] = toLab(c)
+	}
+
+	// arc length at the start of each step around the cycle
+	arc := make([]float64, n+1)
+	for i := 0; i < n; i++ {
+		arc[i+1] = arc[i] + pts[i].distance(pts[(i+1)%n])
+	}
+	total := arc[n]
+	if total == 0 {
+		return append([]color.NRGBA(nil), palette...)
+	}
+
+	out := make([]color.NRGBA, n)
+	seg := 0
+	for i := range out {
+		target := total * float64(i) / float64(n)
+		for seg < n-1 && arc[seg+1] <= target {
+			seg++
+		}
+		t := 0.0
+		if length := arc[seg+1] - arc[seg]; length > 0 {
+			t = (target - arc[seg]) / length
+		}
+		a, b := pts[seg], pts[(seg+1)%n]
+		out[i] = lab{
+			a.l + (b.l-a.l)*t,
+			a.a + (b.a-a.a)*t,
+			a.b + (b.b-a.b)*t,
+			a.alpha + (b.alpha-a.alpha)*t,
+		}.nrgba()
+	}
+	return out
+}
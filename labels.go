@@ -0,0 +1,104 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/cmplx"
+)
+
+// LabelBulbs arranges for Generate to label the bulbs attached to the main
+// cardioid with their rotation numbers p/q, for every q up to
+// maxDenominator. Labels are skipped for bulbs that are off the image or
+// much smaller than their labels, and in exponential map renders.
+func (p *Parameters) LabelBulbs(maxDenominator int, color color.NRGBA) {
+	p.LabelDenominator = maxDenominator
+	p.LabelColor = color
+}
+
+// a 3×5 bitmap font, one row per byte with the high bit on the left
+var labelFont = map[rune][5]byte{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 3, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 2, 2},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'/': {1, 1, 2, 4, 4},
+}
+
+func (p *Parameters) drawBulbLabels(canvas *image.NRGBA) {
+	if p.ExpMap {
+		return
+	}
+	minsize := p.SizeX
+	if p.SizeY < p.SizeX {
+		minsize = p.SizeY
+	}
+	scale := minsize/400 + 1
+	pixelsPerUnit := 1 / p.pixelSize()
+
+	for q := 2; q <= p.LabelDenominator; q++ {
+		for n := 1; n < q; n++ {
+			if gcd(n, q) != 1 {
+				continue
+			}
+
+			// the n/q bulb touches the cardioid at internal angle 2πn/q
+			// and has radius about sin(πn/q)/q², centered along the
+			// outward normal from the point of attachment
+			theta := 2 * math.Pi * float64(n) / float64(q)
+			w := cmplx.Exp(complex(0, theta))
+			attach := w/2 - w*w/4
+			tangent := complex(0, 0.5) * w * (1 - w)
+			normal := complex(0, -1) * tangent / complex(cmplx.Abs(tangent), 0)
+			radius := math.Sin(math.Pi*float64(n)/float64(q)) / float64(q*q)
+			center := attach + normal*complex(radius, 0)
+
+			text := fmt.Sprintf("%d/%d", n, q)
+			width := (4*len(text) - 1) * scale
+			if radius*pixelsPerUnit*4 < float64(width) {
+				continue
+			}
+			fx, fy := p.toPixel(real(center), imag(center))
+			drawText(canvas, text, int(fx)-width/2, int(fy)-5*scale/2, scale, p.LabelColor)
+		}
+	}
+}
+
+// drawText blends text in the label font onto the canvas with its top
+// left corner at (x, y), with each font pixel drawn as a scale×scale block.
+func drawText(canvas *image.NRGBA, text string, x, y, scale int, c color.NRGBA) {
+	for _, ch := range text {
+		glyph := labelFont[ch]
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>uint(col)) == 0 {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						px, py := x+col*scale+dx, y+row*scale+dy
+						if !(image.Point{px, py}.In(canvas.Rect)) {
+							continue
+						}
+						canvas.SetNRGBA(px, py, over(c, canvas.NRGBAAt(px, py), 1))
+					}
+				}
+			}
+		}
+		x += 4 * scale
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
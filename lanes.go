@@ -0,0 +1,116 @@
+package mandel
+
+import (
+	"image"
+	"sync"
+)
+
+// number of points that laneKernel iterates side by side
+const lanes = 8
+
+// laneKernel is mandel for lanes points at once, using the vector
+// instructions of the processor, or nil where there are none to use. It
+// must give every lane exactly the escape value mandel would.
+var laneKernel func(maxIters int, x, y *[lanes]float64, smooth smoother, bailout float64, out *[lanes]float64)
+
+// escapes is escape for a batch of points, leaving the escape value of
+// (xs[k], ys[k]) in out[k]. Plain z² + c runs through laneKernel where
+// there is one.
+func (p *Parameters) escapes(maxIters int, xs, ys, out []float64, continuous bool) {
+	k := 0
+	if laneKernel != nil && p.quadratic() && p.norm == nil && !p.CompensatedSum && !p.CurvatureColor && !p.ExactInterior {
+		smooth, bailout := p.smoother(continuous, 2), p.bailout(continuous)
+		for ; k+lanes <= len(xs); k += lanes {
+			start := p.report.clock()
+			x, y := (*[lanes]float64)(xs[k:k+lanes]), (*[lanes]float64)(ys[k:k+lanes])
+			laneKernel(maxIters, x, y, smooth, bailout, (*[lanes]float64)(out[k:k+lanes]))
+			p.report.sampled(p, start, maxIters, xs[k:k+lanes], ys[k:k+lanes], out[k:k+lanes])
+		}
+		p.report.escaped(maxIters, out[:k]...)
+	}
+	for ; k < len(xs); k++ {
+		out[k] = p.escape(maxIters, xs[k], ys[k], continuous)
+	}
+}
+
+// rowSamples holds the points and escape values of the samples of a
+// row for calcRow.
+type rowSamples struct {
+	xs, ys, vs []float64
+}
+
+// rowScratch keeps the sample buffers of calcRow between rows, so each
+// row worker goes on using the same ones instead of allocating them for
+// every row.
+var rowScratch = sync.Pool{New: func() interface{} { return new(rowSamples) }}
+
+// calcRow is calcPixel for the pixels of a row from column start up to
+// end, computing all of their samples as one batch.
+func (p *Parameters) calcRow(canvas *image.NRGBA, start, end, row int) {
+	n := p.samples
+	buf := rowScratch.Get().(*rowSamples)
+	defer rowScratch.Put(buf)
+	size := (end - start) * n
+	if cap(buf.xs) < size {
+		buf.xs, buf.ys, buf.vs = make([]float64, size), make([]float64, size), make([]float64, size)
+	}
+	xs, ys, vs := buf.xs[:size], buf.ys[:size], buf.vs[:size]
+	k := 0
+	for col := start; col < end; col++ {
+		for s := 0; s < n; s++ {
+			xoffset, yoffset := p.pixelSample(col, row, s)
+			xs[k], ys[k] = p.toPlane(col, row, xoffset, yoffset)
+			k++
+		}
+	}
+	p.escapes(p.MaxIterations, xs, ys, vs, p.Continuous)
+	for col := start; col < end; col++ {
+		var sum colorSum
+		for _, v := range vs[(col-start)*n : (col-start+1)*n] {
+			sum.add(p.getColor(v))
+		}
+		canvas.SetNRGBA(col, row, p.adjust(sum.color()))
+	}
+}
+
+// calcPixels renders the given pixels and reports whether every sample of
+// them was inside the set. Like calcSpan, it computes all of their samples
+// as one batch unless the coloring needs calcPixel.
+func (p *Parameters) calcPixels(canvas *image.NRGBA, pixels []image.Point) bool {
+	inside := true
+	if p.pixelColoring() || (p.AAFastPath && p.samples > 1) {
+		for _, pt := range pixels {
+			c, in := p.calcPixel(pt.X, pt.Y)
+			canvas.SetNRGBA(pt.X, pt.Y, c)
+			inside = inside && in
+		}
+		return inside
+	}
+
+	n := p.samples
+	buf := rowScratch.Get().(*rowSamples)
+	defer rowScratch.Put(buf)
+	size := len(pixels) * n
+	if cap(buf.xs) < size {
+		buf.xs, buf.ys, buf.vs = make([]float64, size), make([]float64, size), make([]float64, size)
+	}
+	xs, ys, vs := buf.xs[:size], buf.ys[:size], buf.vs[:size]
+	k := 0
+	for _, pt := range pixels {
+		for s := 0; s < n; s++ {
+			xoffset, yoffset := p.pixelSample(pt.X, pt.Y, s)
+			xs[k], ys[k] = p.toPlane(pt.X, pt.Y, xoffset, yoffset)
+			k++
+		}
+	}
+	p.escapes(p.MaxIterations, xs, ys, vs, p.Continuous)
+	for i, pt := range pixels {
+		var sum colorSum
+		for _, v := range vs[i*n : (i+1)*n] {
+			sum.add(p.getColor(v))
+			inside = inside && v == 0
+		}
+		canvas.SetNRGBA(pt.X, pt.Y, p.adjust(sum.color()))
+	}
+	return inside
+}
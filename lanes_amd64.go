@@ -0,0 +1,66 @@
+//go:build amd64
+// +build amd64
+
+package mandel
+
+func init() {
+	if hasAVX2() {
+		laneKernel = mandelLanesAVX2
+	}
+}
+
+// mandelLanesAVX2 is mandel for lanes points at once, iterating them side
+// by side in two sets of AVX2 registers. The vector instructions
+// round exactly as the scalar ones do, so every lane gets exactly the
+// escape value mandel would give it.
+func mandelLanesAVX2(maxIters int, x, y *[lanes]float64, smooth smoother, bailout float64, out *[lanes]float64) {
+	var run [lanes]uint64
+	for k := 0; k < lanes; k++ {
+		// points in the main cardioid or the period-2 bulb never escape
+		xq := x[k] - 0.25
+		y2 := float64(y[k] * y[k])
+		q := float64(xq*xq) + y2
+		if float64(q*(q+xq)) <= float64(0.25*y2) || float64((x[k]+1)*(x[k]+1))+y2 <= 0.0625 {
+			continue
+		}
+		run[k] = ^uint64(0)
+	}
+	var iters, mags [lanes]float64
+	mandelAVX2(maxIters, x, y, bailout, &run, &iters, &mags)
+	for k := range out {
+		switch {
+		case iters[k] == 0:
+			out[k] = 0.0
+		case smooth != nil:
+			out[k] = smooth(int(iters[k]), mags[k], bailout)
+		default:
+			out[k] = iters[k]
+		}
+	}
+}
+
+// mandelAVX2 iterates the lanes marked in run, with periodStart and
+// periodEpsilon built in, and leaves the iteration each one escaped on in
+// iters, or 0 for lanes that did not, and its |z|² at that point in mags.
+//
+//go:noescape
+func mandelAVX2(maxIters int, x, y *[lanes]float64, bailout float64, run *[lanes]uint64, iters, mags *[lanes]float64)
+
+// hasAVX2 reports whether the processor has AVX2 and the operating system
+// saves the AVX registers.
+func hasAVX2() bool {
+	_, _, ecx, _ := cpuid(1, 0)
+	if ecx&(1<<27) == 0 || ecx&(1<<28) == 0 {
+		// no OSXSAVE or no AVX
+		return false
+	}
+	if xgetbv()&6 != 6 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	return ebx&(1<<5) != 0
+}
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax uint32)
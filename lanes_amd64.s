@@ -0,0 +1,159 @@
+//go:build amd64
+// +build amd64
+
+#include "textflag.h"
+
+// four copies of periodEpsilon, and of the mask that clears the sign bit
+DATA periodEps<>+0(SB)/8, $1e-15
+DATA periodEps<>+8(SB)/8, $1e-15
+DATA periodEps<>+16(SB)/8, $1e-15
+DATA periodEps<>+24(SB)/8, $1e-15
+GLOBL periodEps<>(SB), RODATA|NOPTR, $32
+
+DATA absMask<>+0(SB)/8, $0x7fffffffffffffff
+DATA absMask<>+8(SB)/8, $0x7fffffffffffffff
+DATA absMask<>+16(SB)/8, $0x7fffffffffffffff
+DATA absMask<>+24(SB)/8, $0x7fffffffffffffff
+GLOBL absMask<>(SB), RODATA|NOPTR, $32
+
+// func mandelAVX2(maxIters int, x, y *[lanes]float64, bailout float64, run *[lanes]uint64, iters, mags *[lanes]float64)
+//
+// The lanes are iterated in two groups of four, A and B, so the processor
+// can work on one while the other waits on its multiplies.
+//
+// Y0, Y1  z of group A
+// Y2, Y3  saved point of the period check of group A
+// Y4      lanes of group A still running
+// Y5-Y9   the same for group B
+// Y15     bailout
+TEXT ·mandelAVX2(SB), NOSPLIT, $0-56
+	MOVQ maxIters+0(FP), DX
+	MOVQ x+8(FP), SI
+	MOVQ y+16(FP), DI
+	VBROADCASTSD bailout+24(FP), Y15
+	MOVQ run+32(FP), AX
+	MOVQ iters+40(FP), R10
+	MOVQ mags+48(FP), R11
+	VMOVUPD (SI), Y0
+	VMOVUPD (DI), Y1
+	VMOVAPD Y0, Y2
+	VMOVAPD Y1, Y3
+	VMOVUPD (AX), Y4
+	VMOVUPD 32(SI), Y5
+	VMOVUPD 32(DI), Y6
+	VMOVAPD Y5, Y7
+	VMOVAPD Y6, Y8
+	VMOVUPD 32(AX), Y9
+
+	MOVQ $1, CX  // iteration
+	MOVQ $16, R8 // next iteration to save a point on, periodStart
+	MOVQ $16, R9 // interval between saved points
+	CMPQ DX, $1
+	JLT done
+
+loop:
+	// A: a lane escapes when |z|² reaches the bailout
+	VMULPD Y0, Y0, Y10
+	VMULPD Y1, Y1, Y11
+	VADDPD Y11, Y10, Y12
+	VCMPPD $0x1d, Y15, Y12, Y13
+	VANDPD Y4, Y13, Y13
+	VMOVMSKPD Y13, AX
+	TESTQ AX, AX
+	JZ stepA
+	VCVTSI2SDQ CX, X14, X14
+	VBROADCASTSD X14, Y14
+	VMASKMOVPD Y14, Y13, 0(R10)
+	VMASKMOVPD Y12, Y13, 0(R11)
+	VANDNPD Y4, Y13, Y4
+
+stepA:
+	// z² + c, rounding each step as mandel does
+	VMULPD Y1, Y0, Y14
+	VSUBPD Y11, Y10, Y10
+	VADDPD 0(SI), Y10, Y0
+	VADDPD Y14, Y14, Y14
+	VADDPD 0(DI), Y14, Y1
+
+	// a lane whose orbit comes back to the saved point is in a cycle
+	VSUBPD Y2, Y0, Y10
+	VANDPD absMask<>(SB), Y10, Y10
+	VCMPPD $0x11, periodEps<>(SB), Y10, Y10
+	VSUBPD Y3, Y1, Y11
+	VANDPD absMask<>(SB), Y11, Y11
+	VCMPPD $0x11, periodEps<>(SB), Y11, Y11
+	VANDPD Y11, Y10, Y10
+	VANDNPD Y4, Y10, Y4
+
+	// B: a lane escapes when |z|² reaches the bailout
+	VMULPD Y5, Y5, Y10
+	VMULPD Y6, Y6, Y11
+	VADDPD Y11, Y10, Y12
+	VCMPPD $0x1d, Y15, Y12, Y13
+	VANDPD Y9, Y13, Y13
+	VMOVMSKPD Y13, AX
+	TESTQ AX, AX
+	JZ stepB
+	VCVTSI2SDQ CX, X14, X14
+	VBROADCASTSD X14, Y14
+	VMASKMOVPD Y14, Y13, 32(R10)
+	VMASKMOVPD Y12, Y13, 32(R11)
+	VANDNPD Y9, Y13, Y9
+
+stepB:
+	// z² + c, rounding each step as mandel does
+	VMULPD Y6, Y5, Y14
+	VSUBPD Y11, Y10, Y10
+	VADDPD 32(SI), Y10, Y5
+	VADDPD Y14, Y14, Y14
+	VADDPD 32(DI), Y14, Y6
+
+	// a lane whose orbit comes back to the saved point is in a cycle
+	VSUBPD Y7, Y5, Y10
+	VANDPD absMask<>(SB), Y10, Y10
+	VCMPPD $0x11, periodEps<>(SB), Y10, Y10
+	VSUBPD Y8, Y6, Y11
+	VANDPD absMask<>(SB), Y11, Y11
+	VCMPPD $0x11, periodEps<>(SB), Y11, Y11
+	VANDPD Y11, Y10, Y10
+	VANDNPD Y9, Y10, Y9
+
+	CMPQ CX, R8
+	JNE unsaved
+	VMOVAPD Y0, Y2
+	VMOVAPD Y1, Y3
+	VMOVAPD Y5, Y7
+	VMOVAPD Y6, Y8
+	SHLQ $1, R9
+	ADDQ R9, R8
+
+unsaved:
+	VORPD Y9, Y4, Y10
+	VMOVMSKPD Y10, AX
+	TESTQ AX, AX
+	JZ done
+	INCQ CX
+	CMPQ CX, DX
+	JLE loop
+
+done:
+	VZEROUPPER
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-4
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	RET
@@ -0,0 +1,82 @@
+package mandel
+
+import (
+	"image"
+	"testing"
+)
+
+// lanePoints is a grid of points over the whole set, a multiple of lanes
+// long.
+func lanePoints() (xs, ys []float64) {
+	for j := 0; j < 48; j++ {
+		for i := 0; i < 64; i++ {
+			xs = append(xs, -2.2+2.8*float64(i)/64)
+			ys = append(ys, -1.2+2.4*float64(j)/48)
+		}
+	}
+	return xs, ys
+}
+
+// TestLaneKernel checks that every lane of laneKernel gets exactly the
+// escape value that mandel does, with and without smoothing.
+func TestLaneKernel(t *testing.T) {
+	if laneKernel == nil {
+		t.Skip("no vector kernel on this processor")
+	}
+	xs, ys := lanePoints()
+	for _, smooth := range []smoother{nil, smoothEscape} {
+		bailout := 4.0
+		if smooth != nil {
+			bailout = 256
+		}
+		var out [lanes]float64
+		for k := 0; k < len(xs); k += lanes {
+			laneKernel(500, (*[lanes]float64)(xs[k:k+lanes]), (*[lanes]float64)(ys[k:k+lanes]), smooth, bailout, &out)
+			for l, v := range out {
+				if want := mandel(500, xs[k+l], ys[k+l], smooth, bailout); v != want {
+					t.Errorf("(%g, %g): lane gives %g, mandel gives %g", xs[k+l], ys[k+l], v, want)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkCalcRow renders rows of an overview and of a view near the
+// boundary, with and without laneKernel.
+func BenchmarkCalcRow(b *testing.B) {
+	views := []struct {
+		name      string
+		x, y, mag float64
+	}{
+		{"overview", -0.75, 0, 1},
+		{"seahorse", -0.7453, 0.1127, 200},
+	}
+	kernel := laneKernel
+	defer func() { laneKernel = kernel }()
+	for _, view := range views {
+		p := Parameters{CenterX: view.x, CenterY: view.y, Magnification: view.mag, MaxIterations: 1000, SizeX: 320, SizeY: 240, AntiAlias: 2, Palette: DefaultPalette()}
+		if err := p.Init(); err != nil {
+			b.Fatal(err)
+		}
+		canvas := image.NewNRGBA(image.Rect(0, 0, p.SizeX, p.SizeY))
+		for _, vector := range []bool{false, true} {
+			name := view.name + "/scalar"
+			laneKernel = nil
+			if vector {
+				if kernel == nil {
+					continue
+				}
+				name = view.name + "/vector"
+				laneKernel = kernel
+			}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					for row := 0; row < p.SizeY; row += 8 {
+						p.calcRow(canvas, 0, p.SizeX, row)
+					}
+				}
+			})
+		}
+	}
+}
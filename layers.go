@@ -0,0 +1,193 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Layer colors the escaping samples in a band of escape values by one of
+// their statistics, with a palette of its own, and blends the result over
+// the layers before it.
+type Layer struct {
+	// what to color by: "escape" (default), the escape value, as palette
+	// coloring does; "distance", the estimated distance to the set, as
+	// distance coloring does; or "orbittrap", the closest the orbit comes
+	// to the trap, as orbit-trap coloring does
+	Source string `json:"source,omitempty"`
+
+	// colors of the layer, spread over its source the way the coloring
+	// that shares its name spreads Palette
+	Palette []color.NRGBA `json:"palette"`
+
+	// escape values the layer covers, from MinIterations up to but not
+	// including MaxIterations; a MaxIterations of 0 means no upper limit
+	MinIterations float64 `json:"min,omitempty"`
+	MaxIterations float64 `json:"max,omitempty"`
+
+	// how the layer combines with the ones before it: "normal" (default),
+	// which covers them by its alpha, "multiply", "screen", or "add"
+	Blend string `json:"blend,omitempty"`
+
+	// opacity of the layer from 0 to 1; 0 means 1
+	Opacity float64 `json:"opacity,omitempty"`
+}
+
+// initLayers checks the layers.
+func (p *Parameters) initLayers() error {
+	if len(p.Layers) == 0 {
+		return nil
+	}
+	if p.Coloring != "" && p.Coloring != "palette" {
+		return fmt.Errorf("layers cannot be used with %s coloring", p.Coloring)
+	}
+	if p.Colorer != nil || p.duotone() || p.Fractal == "newton" || p.interiorColoring() || p.CurvatureColor {
+		return fmt.Errorf("layers replace the palette, and cannot be combined with other colorings")
+	}
+	if p.Output != (OutputSpec{}) && p.Output != NRGBA8 {
+		return fmt.Errorf("layers only support 8-bit color output")
+	}
+	for i, l := range p.Layers {
+		switch l.Source {
+		case "", "escape", "distance":
+		case "orbittrap":
+			if !p.quadratic() || p.perturbed() {
+				return fmt.Errorf("layer %d: orbit traps only work with z² + c", i)
+			}
+			if err := p.initTrap(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("layer %d: unknown source %q", i, l.Source)
+		}
+		if len(l.Palette) == 0 {
+			return fmt.Errorf("layer %d: palette must not be empty", i)
+		}
+		switch l.Blend {
+		case "", "normal", "multiply", "screen", "add":
+		default:
+			return fmt.Errorf("layer %d: unknown blend %q", i, l.Blend)
+		}
+		if !(l.Opacity >= 0 && l.Opacity <= 1) {
+			return fmt.Errorf("layer %d: opacity must be between 0 and 1", i)
+		}
+		if l.MaxIterations != 0 && l.MaxIterations <= l.MinIterations {
+			return fmt.Errorf("layer %d: iteration band must not be empty", i)
+		}
+	}
+	return nil
+}
+
+// trapLevels returns the orbit-trap level of every sample, as trapPixel
+// computes it without TrapBlend.
+func (p *Parameters) trapLevels() *Field {
+	f := newField(p)
+	bailout := p.bailout(false)
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			x, y := p.samplePoint(i, j)
+			minDist, _ := mandelTrap(p.MaxIterations, x, y, p.trap, nil, bailout)
+			level := 1 - math.Exp(-4*minDist)
+			if math.IsNaN(level) {
+				level = 1
+			}
+			f.Values[j*f.Width+i] = math.Max(level, math.SmallestNonzeroFloat64)
+		}
+	})
+	return f
+}
+
+// generateLayers renders the image with Layers in place of the palette.
+// Each escaping sample starts out transparent and takes the layers that
+// cover its escape value in order, and samples inside the set get
+// InsideColor.
+func (p *Parameters) generateLayers() *image.NRGBA {
+	escapes := p.computeField(p.Continuous)
+	sources := make([]*Field, len(p.Layers))
+	colorers := make([]*Parameters, len(p.Layers))
+	var distances, traps *Field
+	for k, l := range p.Layers {
+		sources[k] = escapes
+		switch l.Source {
+		case "distance":
+			if distances == nil {
+				distances = p.distanceLevels()
+			}
+			sources[k] = distances
+		case "orbittrap":
+			if traps == nil {
+				traps = p.trapLevels()
+			}
+			sources[k] = traps
+		}
+		q := *p
+		q.palette = l.Palette
+		if p.PerceptualPalette && len(l.Palette) > 1 {
+			q.palette = perceptualPalette(l.Palette)
+		}
+		q.constantPalette = false
+		colorers[k] = &q
+	}
+
+	aa := escapes.AntiAlias
+	canvas := p.newCanvas()
+	p.forRows(p.SizeY, func(row int) {
+		for col := 0; col < p.SizeX; col++ {
+			var sum colorSum
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					v := escapes.At(i, j)
+					if v == 0 {
+						sum.add(p.getColor(0))
+						continue
+					}
+					if p.clearSample(false) {
+						sum.add(0, 0, 0, 0)
+						continue
+					}
+					sum.add(p.layerColor(v, i, j, sources, colorers))
+				}
+			}
+			canvas.SetNRGBA(col, row, p.adjust(sum.color()))
+		}
+	})
+	return canvas
+}
+
+// layerColor blends the layers that cover escape value v for the sample
+// at i, j of the sample grid. The blend starts from transparent black, so
+// it works in premultiplied color.
+func (p *Parameters) layerColor(v float64, i, j int, sources []*Field, colorers []*Parameters) (r, g, b, a int) {
+	var out [4]float64
+	for k, l := range p.Layers {
+		if v < l.MinIterations || (l.MaxIterations != 0 && v >= l.MaxIterations) {
+			continue
+		}
+		var c [4]float64
+		if l.Source == "distance" || l.Source == "orbittrap" {
+			c[0], c[1], c[2], c[3] = colorers[k].levelColor(sources[k].At(i, j))
+		} else {
+			c[0], c[1], c[2], c[3] = colorers[k].valueColor(v, false)
+		}
+		cover := c[3] / 255
+		if l.Opacity != 0 {
+			cover *= l.Opacity
+		}
+		blend := l.Blend
+		if blend == "" {
+			blend = "normal"
+		}
+		for ch := 0; ch < 3; ch++ {
+			out[ch] = blendValue(blend, out[ch], c[ch]/255, cover)
+		}
+		out[3] += cover * (1 - out[3])
+	}
+	if out[3] == 0 {
+		return 0, 0, 0, 0
+	}
+	channel := func(v float64) int {
+		return int(math.Min(v/out[3], 1)*255 + 0.5)
+	}
+	return channel(out[0]), channel(out[1]), channel(out[2]), int(out[3]*255 + 0.5)
+}
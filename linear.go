@@ -0,0 +1,99 @@
+package mandel
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// GenerateLinear renders the image into a floating-point framebuffer in
+// linear light, before sRGB encoding and quantization. It holds four
+// float32 values per pixel, red, green, blue, and alpha in [0, 1], row by
+// row, with the colors premultiplied by alpha. Subpixels are averaged in
+// linear light. Output gamma, contours, and labels are not applied.
+func (p *Parameters) GenerateLinear() ([]float32, error) {
+	if err := p.checkInit("GenerateLinear"); err != nil {
+		return nil, err
+	}
+
+	buf := make([]float32, p.SizeX*p.SizeY*4)
+	p.forRows(p.SizeY, func(row int) {
+		for col := 0; col < p.SizeX; col++ {
+			r, g, b, a := p.linearPixel(col, row)
+			i := (row*p.SizeX + col) * 4
+			buf[i], buf[i+1], buf[i+2], buf[i+3] = float32(r), float32(g), float32(b), float32(a)
+		}
+	})
+	return buf, nil
+}
+
+// linearPixel averages the subpixels of a pixel in premultiplied linear
+// light.
+func (p *Parameters) linearPixel(col, row int) (r, g, b, a float64) {
+	for k := 0; k < p.samples; k++ {
+		xoffset, yoffset := p.pixelSample(col, row, k)
+		x, y := p.toPlane(col, row, xoffset, yoffset)
+		rs, gs, bs, as := p.sampleColor(p.escape(p.MaxIterations, x, y, p.Continuous))
+		as /= 255
+		r += srgbToLinear(rs/255) * as
+		g += srgbToLinear(gs/255) * as
+		b += srgbToLinear(bs/255) * as
+		a += as
+	}
+	n := float64(p.samples)
+	return r / n, g / n, b / n, a / n
+}
+
+// srgbToLinear decodes an sRGB channel value in [0, 1] to linear light.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB encodes a linear light value in [0, 1] as an sRGB channel
+// value.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// generatePasses renders the image Passes times in linear light, shifting
+// the sample grid by a random fraction of a subpixel each time, and
+// averages the passes before converting to sRGB.
+func (p *Parameters) generatePasses() *image.NRGBA {
+	rng := rand.New(rand.NewSource(p.Seed))
+	sub := 1 / float64(p.AntiAlias)
+	acc := make([]float64, p.SizeX*p.SizeY*4)
+	for pass := 0; pass < p.Passes; pass++ {
+		q := *p
+		q.SampleOffsetX += (rng.Float64() - 0.5) * sub
+		q.SampleOffsetY += (rng.Float64() - 0.5) * sub
+		buf, _ := q.GenerateLinear()
+		for i, v := range buf {
+			acc[i] += float64(v)
+		}
+	}
+
+	canvas := p.newCanvas()
+	n := float64(p.Passes)
+	for i := 0; i < len(acc); i += 4 {
+		a := acc[i+3] / n
+		if a <= 0 {
+			copy(canvas.Pix[i:i+4], []uint8{0, 0, 0, 0})
+			continue
+		}
+		channel := func(v float64) uint8 {
+			v = linearToSRGB(math.Max(0, math.Min(1, v/n/a)))
+			return uint8(v*255 + 0.5)
+		}
+		c := color.NRGBA{channel(acc[i]), channel(acc[i+1]), channel(acc[i+2]), uint8(a*255 + 0.5)}
+		c = p.adjust(c)
+		copy(canvas.Pix[i:i+4], []uint8{c.R, c.G, c.B, c.A})
+	}
+	return canvas
+}
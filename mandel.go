@@ -1,167 +1,1532 @@
 package mandel
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"math/big"
 	"runtime"
+	"time"
 )
 
 type Parameters struct {
 	CenterX       float64       `json:"x"`
 	CenterY       float64       `json:"y"`
-	Magnification float64       `json:"m"`
-	MaxIterations int           `json:"i"`
+	Magnification float64       `json:"m"` // negative to mirror left to right about CenterX
+	MaxIterations int           `json:"i"` // 0 to choose from the magnification
 	SizeX         int           `json:"px"`
 	SizeY         int           `json:"py"`
 	AntiAlias     int           `json:"a"`
 	Continuous    bool          `json:"c"`
 	Palette       []color.NRGBA `json:"palette"`
-	InsideColor   color.NRGBA   `json:"inside"`
+	InsideColor   color.NRGBA   `json:"inside"` // the zero value is opaque black
+
+	// inside coloring: "flat" (default), "magnitude", "average", or "distance"
+	InteriorColoring string `json:"interior,omitempty"`
+
+	// quality preset: "draft", "normal", "high", or "ultra"
+	Detail string `json:"detail,omitempty"`
+
+	// probe points on a side for refining automatic MaxIterations
+	IterationProbes int `json:"probes,omitempty"`
+
+	// rotate the palette by this fraction of its length
+	PaletteOffset float64 `json:"offset,omitempty"`
+
+	// color space for continuous gradients: "rgb" (default), "hsv", or "lab"
+	Interpolation string `json:"interpolation,omitempty"`
+
+	// continuous escape value formula: "log" (default) or "linear"
+	Smoothing string `json:"smoothing,omitempty"`
+
+	// resample the palette so its steps are perceptually even
+	PerceptualPalette bool `json:"perceptual,omitempty"`
+
+	// "palette" (default), "alpha-ramp", "histogram", "orbit-range",
+	// "distance", "orbittrap", "stripe", or "tia"
+	Coloring        string      `json:"coloring,omitempty"`
+	RampColor       color.NRGBA `json:"ramp"`
+	BackgroundColor color.NRGBA `json:"background"`
+
+	// shade from the first color to the second instead of the palette
+	Duotone [2]color.NRGBA `json:"duotone"`
+
+	// custom iteration formula such as "z*z*z + sin(z) + c"; blank for z² + c
+	Formula string `json:"formula,omitempty"`
+
+	// iterate z^Power + c instead of z² + c; 0 means 2
+	Power int `json:"power,omitempty"`
+
+	// "mandelbrot" (default), "burningship", "tricorn", "julia", or "newton"
+	Fractal string  `json:"fractal,omitempty"`
+	JuliaCX float64 `json:"cx,omitempty"`
+	JuliaCY float64 `json:"cy,omitempty"`
+
+	// Newton polynomial coefficients, highest power first; empty for z³ − 1
+	NewtonCoefficients []float64 `json:"newton,omitempty"`
+
+	// escape radius; 0 means 2, or √SmoothBailout for continuous coloring
+	Bailout float64 `json:"bailout,omitempty"`
+
+	// squared escape radius for continuous coloring; 0 means 256
+	SmoothBailout float64 `json:"smoothbailout,omitempty"`
+
+	// mantissa bits of deep zoom reference orbits; 0 to choose
+	Precision uint `json:"precision,omitempty"`
+
+	// the center as decimal strings, for zooms too deep for float64
+	PreciseX string `json:"precisex,omitempty"`
+	PreciseY string `json:"precisey,omitempty"`
+
+	// color by how sharply the orbit turns on its last step
+	CurvatureColor bool `json:"curvature,omitempty"`
+
+	// round each step of z² + c once instead of three times
+	CompensatedSum bool `json:"compensated,omitempty"`
+
+	// iterate interior points without the cardioid and periodicity shortcuts
+	ExactInterior bool `json:"exactinterior,omitempty"`
+
+	// escape test shape: "circle" (default), "square", "cross", or "rhombus"
+	BailoutShape string `json:"bailoutshape,omitempty"`
+
+	// the orbit trap for orbittrap coloring
+	TrapX      float64 `json:"trapx,omitempty"`
+	TrapY      float64 `json:"trapy,omitempty"`
+	TrapShape  string  `json:"trapshape,omitempty"`
+	TrapRadius float64 `json:"trapradius,omitempty"`
+	TrapAngle  float64 `json:"trapangle,omitempty"`
+	TrapBlend  float64 `json:"trapblend,omitempty"`
+
+	// stripes around the origin for stripe coloring; 0 means 5
+	StripeDensity float64 `json:"stripes,omitempty"`
+
+	// spend iterations in proportion to closeness to the boundary
+	SmartIterations bool `json:"smart,omitempty"`
+
+	// distance estimation: "analytic" (default) or "finite"
+	DEMethod string `json:"de,omitempty"`
+
+	// cap escape values at this level when coloring; 0 for no cap
+	ColorClampMax float64 `json:"clamp,omitempty"`
+
+	// fit the palette to the frame's escape values
+	AutoContrast bool `json:"autocontrast,omitempty"`
+
+	// shift colors with the magnification to keep them steady through a zoom
+	DepthNormalize bool `json:"depthnormalize,omitempty"`
+
+	// gamma applied to the final color channels; 0 or 1 leaves them alone
+	OutputGamma float64 `json:"gamma,omitempty"`
+
+	// iteration levels to outline on top of the image
+	Contours     []float64   `json:"contours,omitempty"`
+	ContourColor color.NRGBA `json:"contourcolor"`
+
+	// label cardioid bulbs with rotation numbers up to this denominator
+	LabelDenominator int         `json:"labels,omitempty"`
+	LabelColor       color.NRGBA `json:"labelcolor"`
+
+	// corners of the view, in place of the center and magnification
+	MinX float64 `json:"minx,omitempty"`
+	MaxX float64 `json:"maxx,omitempty"`
+	MinY float64 `json:"miny,omitempty"`
+	MaxY float64 `json:"maxy,omitempty"`
+
+	// turn the image counterclockwise about its center, in degrees
+	Rotation float64 `json:"rotation,omitempty"`
+
+	// 2×2 matrix, row by row, applied to the view before Rotation
+	Transform []float64 `json:"transform,omitempty"`
+
+	// exponential map: rows zoom out from the center, columns sweep a turn
+	ExpMap        bool    `json:"expmap,omitempty"`
+	ExpMapOctaves float64 `json:"octaves,omitempty"`
+
+	// sector of an exponential map; all zeros for a full turn
+	ExpMapAngleStart  float64 `json:"anglestart,omitempty"`
+	ExpMapAngleEnd    float64 `json:"angleend,omitempty"`
+	ExpMapRadiusStart float64 `json:"radiusstart,omitempty"`
+	ExpMapRadiusEnd   float64 `json:"radiusend,omitempty"`
+
+	// crop to the inscribed "circle" or "ellipse"; "none" by default
+	CropShape string `json:"crop,omitempty"`
+
+	// anti-alias only pixels that the distance estimate puts near the boundary
+	DEMaskedAA bool `json:"demask,omitempty"`
+
+	// anti-alias only pixels that differ from a neighbor; threshold 0 means 12
+	AdaptiveAA        bool `json:"adaptive,omitempty"`
+	AdaptiveThreshold int  `json:"adaptivethreshold,omitempty"`
+
+	// shift every sample by this much, in pixels, to the right and down
+	SampleOffsetX float64 `json:"sx,omitempty"`
+	SampleOffsetY float64 `json:"sy,omitempty"`
+
+	// move anti-aliasing samples to repeatable random spots
+	JitterAA bool `json:"jitter,omitempty"`
+
+	// anti-aliasing samples: "grid" (default), "rotated", "halton", or "sobol"
+	SamplePattern string `json:"pattern,omitempty"`
+
+	// samples per pixel for the halton and sobol patterns; 0 for AntiAlias²
+	SampleCount int `json:"samples,omitempty"`
+
+	// average this many renders with jittered samples; 0 or 1 for one
+	Passes int `json:"passes,omitempty"`
+
+	// shortest orbit that the Buddhabrot and Nebulabrot include
+	BuddhabrotMin int `json:"buddhabrotmin,omitempty"`
+
+	// overlay the anti-Buddhabrot traced from this many points; 0 for none
+	DensityOverlay int `json:"densityoverlay,omitempty"`
+
+	// color of the density overlay; white if left zero
+	DensityColor color.NRGBA `json:"densitycolor"`
+
+	// "screen" (default), "add", "multiply", or "normal"
+	DensityBlend string `json:"densityblend,omitempty"`
+
+	// opacity of the density overlay from 0 to 1; 0 means 1
+	DensityOpacity float64 `json:"densityopacity,omitempty"`
+
+	// relief lighting outside the set: "lambert", "phong", or "none"
+	Shading string `json:"shading,omitempty"`
+
+	// light direction in degrees around and above the image; 0 elevation is 45
+	LightAngle     float64 `json:"lightangle,omitempty"`
+	LightElevation float64 `json:"lightelevation,omitempty"`
+
+	// seed for everything random in a render, so results are repeatable
+	Seed int64 `json:"seed,omitempty"`
+
+	// channels and bit depth of the image from GenerateImage
+	Output OutputSpec `json:"output"`
+
+	// Paletted output colors: "render" (default), "websafe", or "plan9"
+	QuantizePalette string `json:"quantize,omitempty"`
+
+	// Paletted output dithering: "floyd-steinberg" (default), "ordered", or "none"
+	Dither string `json:"dither,omitempty"`
+
+	// fill tiles whose edges are entirely inside the set without iterating them
+	InteriorTiles bool `json:"interiortiles,omitempty"`
+
+	// called from another goroutine as rows finish
+	Progress func(rowsDone, rowsTotal int) `json:"-"`
+
+	// called with a report of the work and timings once a render is done
+	Report func(r *RenderReport) `json:"-"`
+
+	// with Report, also count the work of every pixel
+	ReportPixels bool `json:"-"`
+
+	// colors samples in place of the palette and InsideColor
+	Colorer Colorer `json:"-"`
+
+	// iteration to run in place of z² + c, Formula, and Fractal
+	System System `json:"-"`
+
+	// goroutines that render rows; 0 means GOMAXPROCS
+	Workers int `json:"workers,omitempty"`
+
+	// rows handed to a worker at a time; 0 means 1
+	ChunkRows int `json:"chunkrows,omitempty"`
+
+	// rows that GenerateTo and GenerateRows hold at a time; 0 means 64
+	BandRows int `json:"bandrows,omitempty"`
+
+	// color of pixels that a partial render never reaches
+	UnrenderedColor color.NRGBA `json:"unrendered"`
+
+	// color pixels whose center and corners agree from one sample
+	AAFastPath bool `json:"aafast,omitempty"`
+
+	// mirror the top half of a view centered on the real axis
+	Symmetry bool `json:"symmetry,omitempty"`
+
+	// color with these layers, blended in order, instead of the palette
+	Layers []Layer `json:"layers,omitempty"`
+
+	// render this many times larger on each side and shrink; 0 or 1 for none
+	Supersample int `json:"supersample,omitempty"`
+
+	// "lanczos" (default), "mitchell", or "box"
+	SupersampleFilter string `json:"filter,omitempty"`
+
+	// leave "exterior" or "interior" samples fully transparent
+	Transparent string `json:"transparent,omitempty"`
+
 	subpixOffsets []float64
+	samples       int
+	palette       []color.NRGBA
+	gammaLUT      []uint8
+	formula       cfunc
+	system        System
+	preciseX      *big.Float
+	preciseY      *big.Float
+	norm          func(a, b float64) float64
+	trap          func(a, b float64) float64
+	newtonPoly    []complex128
+	newtonRoots   []complex128
+	depthShift    float64
+
+	// Rotation and Transform combined, set by Init
+	view    [4]float64
+	viewSet bool
+
+	// the limit Init chose when MaxIterations was 0, so Init chooses again
+	// for a copy with a new view unless MaxIterations was changed
+	autoIters int
+
+	// every palette entry is the same color
+	constantPalette bool
+
+	// set by GenerateContext so long renders can stop early
+	ctx      context.Context
+	progress *progress
+	report   *reporter
+
+	// set by Pool.Render to run rows on the pool's workers
+	pool *Pool
+
+	// filled in by perturbField when set, for GenerateWithGlitchMap
+	confidence *image.Gray
+
+	// contrast window for AutoContrast, on the log scale of level
+	contrastLo, contrastHi float64
+
+	// cumulative histogram of the frame's escape values for histogram
+	// coloring
+	histogram []float64
+}
+
+// WholeSet returns parameters that frame the entire set in an image of
+// the given size, with a small margin, using the default iteration limit
+// and anti-aliasing level. The caller still needs to supply a palette.
+func WholeSet(sizeX, sizeY int) *Parameters {
+	// the set lies within x in [-2.1, 0.6] and y in [-1.2, 1.2], margin
+	// included
+	return FitBounds(-2.1, -1.2, 0.6, 1.2, sizeX, sizeY)
+}
+
+// FitBounds returns parameters that frame the rectangle from (minX, minY)
+// to (maxX, maxY) on the complex plane in an image of the given size. When
+// the aspect ratios differ, the rectangle is centered and the image shows
+// extra space along one axis. The iteration limit and anti-aliasing level
+// are the defaults, and the caller still needs to supply a palette.
+func FitBounds(minX, minY, maxX, maxY float64, sizeX, sizeY int) *Parameters {
+	p := &Parameters{
+		MaxIterations: 1000,
+		SizeX:         sizeX,
+		SizeY:         sizeY,
+		AntiAlias:     2,
+	}
+	p.FitBounds(minX, minY, maxX, maxY)
+	return p
+}
+
+// iteration limits, anti-aliasing levels, and continuous coloring for
+// each Detail level; Init fills in MaxIterations and AntiAlias only where
+// they are zero
+var detailLevels = map[string]struct {
+	iterations, antiAlias int
+	continuous            bool
+}{
+	"draft":  {250, 1, false},
+	"normal": {1000, 2, false},
+	"high":   {5000, 3, true},
+	"ultra":  {20000, 4, true},
 }
 
 func (p *Parameters) Init() error {
+	if p.Detail != "" {
+		d, ok := detailLevels[p.Detail]
+		if !ok {
+			return fmt.Errorf("unknown detail level %q", p.Detail)
+		}
+		if p.MaxIterations == 0 {
+			p.MaxIterations = d.iterations
+		}
+		if p.AntiAlias == 0 {
+			p.AntiAlias = d.antiAlias
+		}
+		p.Continuous = p.Continuous || d.continuous
+	}
+
+	if p.SizeX < 1 || p.SizeY < 1 {
+		return fmt.Errorf("image size must be at least 1 by 1 pixel")
+	}
+	if p.MaxIterations < 0 {
+		return fmt.Errorf("maximum iterations must not be negative")
+	}
+	if p.IterationProbes < 0 {
+		return fmt.Errorf("iteration probes must not be negative")
+	}
+	auto := p.MaxIterations == 0 || p.MaxIterations == p.autoIters
+
 	// compute subpixel offsets
 	if p.AntiAlias < 1 {
 		return fmt.Errorf("anti-aliasing level must be 1 or higher")
 	}
+	if p.Workers < 0 {
+		return fmt.Errorf("worker count must not be negative")
+	}
+	if p.ChunkRows < 0 {
+		return fmt.Errorf("chunk rows must not be negative")
+	}
+	if p.BandRows < 0 {
+		return fmt.Errorf("band rows must not be negative")
+	}
+	if p.BuddhabrotMin < 0 {
+		return fmt.Errorf("Buddhabrot minimum iterations must not be negative")
+	}
+	if err := p.initDensity(); err != nil {
+		return err
+	}
+	if err := p.initShading(); err != nil {
+		return err
+	}
+	if err := p.initSupersample(); err != nil {
+		return err
+	}
+	if p.boundsSet() && !p.ExpMap {
+		if p.MinX == p.MaxX || p.MinY == p.MaxY {
+			return fmt.Errorf("bounds must have a nonzero width and height")
+		}
+		p.CenterX, p.CenterY = (p.MinX+p.MaxX)/2, (p.MinY+p.MaxY)/2
+
+		// the magnification that gives the same spacing along the
+		// shorter side of the image
+		minsize, span := p.SizeX, p.MaxX-p.MinX
+		if p.SizeY < p.SizeX {
+			minsize, span = p.SizeY, p.MaxY-p.MinY
+		}
+		p.Magnification = float64(minsize) / (math.Abs(span) * float64(minsize-1))
+		if p.MaxX < p.MinX {
+			p.Magnification = -p.Magnification
+		}
+	}
+	if p.Magnification == 0 || math.IsNaN(p.Magnification) || math.IsInf(p.Magnification, 0) {
+		return fmt.Errorf("magnification must be a nonzero number")
+	}
+	if err := p.initView(); err != nil {
+		return err
+	}
+	if err := p.initPreciseCenter(); err != nil {
+		return err
+	}
 	p.subpixOffsets = make([]float64, p.AntiAlias)
 	for i := 0; i < p.AntiAlias; i++ {
 		p.subpixOffsets[i] = (0.5+float64(i))/float64(p.AntiAlias) - 0.5
 	}
 
-	if len(p.Palette) < 1 {
-		return fmt.Errorf("palette must not be empty")
+	switch p.Interpolation {
+	case "", "rgb", "hsv", "lab":
+	default:
+		return fmt.Errorf("unknown interpolation %q", p.Interpolation)
+	}
+
+	switch p.SamplePattern {
+	case "", "grid", "rotated", "halton", "sobol":
+	default:
+		return fmt.Errorf("unknown sample pattern %q", p.SamplePattern)
+	}
+	if p.SampleCount < 0 {
+		return fmt.Errorf("sample count must not be negative")
+	}
+
+	switch p.Smoothing {
+	case "", "log", "linear":
+	default:
+		return fmt.Errorf("unknown smoothing %q", p.Smoothing)
+	}
+
+	if p.Colorer != nil && p.Coloring != "" && p.Coloring != "palette" {
+		return fmt.Errorf("a Colorer cannot be used with %s coloring", p.Coloring)
+	}
+	// alpha-ramp fades RampColor in over BackgroundColor as the escape
+	// count grows; histogram spreads the palette so each color covers
+	// about as many escaped pixels; distance, orbittrap, stripe, and tia
+	// run through the palette once; orbit-range takes the hue from the
+	// orbit's closest approach to the origin and the brightness from its
+	// farthest excursion
+	switch p.Coloring {
+	case "", "palette", "histogram", "distance", "orbittrap", "stripe", "tia":
+		if len(p.Palette) < 1 && !p.duotone() && p.Colorer == nil && len(p.Layers) == 0 {
+			return fmt.Errorf("palette must not be empty")
+		}
+	case "alpha-ramp", "orbit-range":
+	default:
+		return fmt.Errorf("unknown coloring method %q", p.Coloring)
+	}
+
+	if p.Coloring == "orbittrap" {
+		if err := p.initTrap(); err != nil {
+			return err
+		}
+	}
+
+	// InsideColor was opaque whatever its alpha before colors had alpha,
+	// so the zero value stays opaque black; Transparent clears the inside
+	if p.InsideColor == (color.NRGBA{}) {
+		p.InsideColor.A = 255
+	}
+
+	p.palette = p.Palette
+	if p.PerceptualPalette && len(p.Palette) > 1 {
+		p.palette = perceptualPalette(p.Palette)
+	}
+	p.constantPalette = len(p.palette) > 0
+	for _, c := range p.palette {
+		if c != p.palette[0] {
+			p.constantPalette = false
+			break
+		}
+	}
+	if math.IsNaN(p.PaletteOffset) || math.IsInf(p.PaletteOffset, 0) {
+		return fmt.Errorf("palette offset must be a finite number")
+	}
+	p.PaletteOffset = wrapPaletteOffset(p.PaletteOffset)
+
+	if p.Bailout != 0 && !(p.Bailout >= 2) {
+		return fmt.Errorf("bailout must be at least 2")
+	}
+	if p.SmoothBailout != 0 && p.SmoothBailout < 4 {
+		return fmt.Errorf("smooth bailout must be at least 4")
+	}
+
+	p.norm = nil
+	if p.BailoutShape != "" && p.BailoutShape != "circle" {
+		norm, ok := bailoutNorms[p.BailoutShape]
+		if !ok {
+			return fmt.Errorf("unknown bailout shape %q", p.BailoutShape)
+		}
+		p.norm = norm
+	}
+
+	switch p.DEMethod {
+	case "", "analytic", "finite":
+	default:
+		return fmt.Errorf("unknown distance estimation method %q", p.DEMethod)
+	}
+
+	if p.Power != 0 && p.Power < 2 {
+		return fmt.Errorf("power must be 2 or higher")
+	}
+	switch p.Fractal {
+	case "", "mandelbrot", "burningship", "tricorn", "julia":
+	case "newton":
+		if err := p.initNewton(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown fractal %q", p.Fractal)
+	}
+
+	p.formula = nil
+	if p.Formula != "" {
+		f, err := compileFormula(p.Formula)
+		if err != nil {
+			return err
+		}
+		p.formula = f
+	}
+	if err := p.initSystem(); err != nil {
+		return err
+	}
+	if !p.quadratic() {
+		switch {
+		case p.norm != nil:
+			return fmt.Errorf("the %s bailout shape only works with z² + c", p.BailoutShape)
+		case p.CurvatureColor:
+			return fmt.Errorf("curvature coloring only works with z² + c")
+		case p.Coloring == "orbittrap" || p.Coloring == "orbit-range":
+			return fmt.Errorf("%s coloring only works with z² + c", p.Coloring)
+		}
+	}
+
+	if err := p.initInterior(); err != nil {
+		return err
+	}
+	if err := p.initAverage(); err != nil {
+		return err
+	}
+	if err := p.initLayers(); err != nil {
+		return err
+	}
+	if err := p.initTransparent(); err != nil {
+		return err
+	}
+
+	// log2(log2(m)) grows with the zoom depth and is zero at m = 2,
+	// below which no shift is needed
+	p.depthShift = 0
+	if mag := math.Abs(p.Magnification); p.DepthNormalize && mag > 2 {
+		p.depthShift = math.Log2(math.Log2(mag))
+	}
+
+	if p.ColorClampMax < 0 {
+		return fmt.Errorf("color clamp must not be negative")
+	}
+
+	if p.OutputGamma < 0 {
+		return fmt.Errorf("output gamma must not be negative")
+	}
+	p.gammaLUT = nil
+	if p.OutputGamma != 0 && p.OutputGamma != 1 {
+		p.gammaLUT = make([]uint8, 256)
+		for i := range p.gammaLUT {
+			p.gammaLUT[i] = uint8(255*math.Pow(float64(i)/255, 1/p.OutputGamma) + 0.5)
+		}
+	}
+
+	if p.AdaptiveThreshold < 0 {
+		return fmt.Errorf("adaptive threshold must not be negative")
+	}
+
+	if p.Passes < 0 {
+		return fmt.Errorf("passes must not be negative")
 	}
 
+	if !p.Output.valid() {
+		return fmt.Errorf("unsupported output format with %d channels at %d bits", p.Output.Channels, p.Output.BitDepth)
+	}
+	if err := p.initQuantize(); err != nil {
+		return err
+	}
+
+	switch p.CropShape {
+	case "", "none", "circle", "ellipse":
+	default:
+		return fmt.Errorf("unknown crop shape %q", p.CropShape)
+	}
+
+	if p.ExpMapOctaves < 0 {
+		return fmt.Errorf("exponential map octaves must not be negative")
+	}
+	if (p.ExpMapAngleStart != 0 || p.ExpMapAngleEnd != 0) && p.ExpMapAngleStart == p.ExpMapAngleEnd {
+		return fmt.Errorf("exponential map angle range must not be empty")
+	}
+	if (p.ExpMapRadiusStart != 0 || p.ExpMapRadiusEnd != 0) && !(p.ExpMapRadiusStart > 0 && p.ExpMapRadiusEnd > 0) {
+		return fmt.Errorf("exponential map radii must both be positive")
+	}
+
+	p.samples = p.AntiAlias * p.AntiAlias
+	if p.SampleCount > 0 && p.SampleCount != p.samples {
+		if !p.sequencePattern() {
+			return fmt.Errorf("%d samples per pixel need the halton or sobol pattern", p.SampleCount)
+		}
+		if opt := p.sampleGridOption(); opt != "" {
+			return fmt.Errorf("%s works from a grid of samples, so it cannot take %d samples per pixel", opt, p.SampleCount)
+		}
+		p.samples = p.SampleCount
+	}
+
+	p.autoIters = 0
+	if auto {
+		p.MaxIterations = p.autoIterations()
+		p.autoIters = p.MaxIterations
+	}
 	return nil
 }
 
-type pixel struct {
-	x, y  int
-	color color.Color
+// checkInit returns an error naming the method if Init has not been
+// called on p, so methods that need Init can report it.
+func (p *Parameters) checkInit(method string) error {
+	if p.AntiAlias < 1 || len(p.subpixOffsets) != p.AntiAlias {
+		return fmt.Errorf("%s cannot be called before Init", method)
+	}
+	return nil
 }
 
-func (p *Parameters) Generate() *image.NRGBA {
-	if len(p.subpixOffsets) != p.AntiAlias {
-		panic("Generate cannot be called before Init")
+// Generate renders the image, or returns an error if Init has not been
+// called. Progress, if set, is called from a separate goroutine as rows
+// finish, so a slow callback does not slow the render, with a count of
+// rows done that only goes up; renders that make several passes add each
+// pass to the total as it starts. Report, if set, is called once the
+// render is done, and gathering it costs a little time on every row.
+func (p *Parameters) Generate() (*image.NRGBA, error) {
+	if err := p.checkInit("Generate"); err != nil {
+		return nil, err
 	}
+	return p.GenerateContext(context.Background())
+}
 
-	// spin up row workers
-	fanout := runtime.GOMAXPROCS(-1)
-	rows := make(chan int)
-	done := make(chan struct{})
-	pixelch := make(chan pixel, p.SizeX)
-	for i := 0; i < fanout; i++ {
-		go func() {
-			for row := range rows {
-				for col := 0; col < p.SizeX; col++ {
-					color := p.CalcPixel(col, row)
-					pixelch <- pixel{col, row, color}
-				}
-			}
-			done <- struct{}{}
-		}()
+// GenerateContext is Generate, but it stops early and returns ctx.Err()
+// if ctx is cancelled before the image is finished. Workers finish the
+// row they are on and then exit.
+func (p *Parameters) GenerateContext(ctx context.Context) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateContext"); err != nil {
+		return nil, err
+	}
+	q := *p
+	q.ctx = ctx
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+	}
+	if p.Report != nil {
+		q.report = newReporter(p)
+	}
+	canvas := q.generate()
+	q.progress.stop()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	decorated := time.Now()
+	q.decorate(canvas)
+	if q.report != nil {
+		p.Report(q.report.finish(&q, decorated))
+	}
+	return canvas, nil
+}
+
+// cancelled reports whether the render's context has been cancelled.
+func (p *Parameters) cancelled() bool {
+	return p.ctx != nil && p.ctx.Err() != nil
+}
+
+// generate renders the image with whichever render path the parameters
+// call for.
+func (p *Parameters) generate() *image.NRGBA {
+	var canvas *image.NRGBA
+	if p.Supersample > 1 {
+		canvas = p.generateSupersampled()
+	} else if len(p.Layers) > 0 {
+		canvas = p.generateLayers()
+	} else if p.pixelColoring() {
+		canvas = p.generatePixels()
+	} else if p.Coloring == "histogram" {
+		canvas = p.computeIterations().Colorize(p)
+	} else if p.Coloring == "distance" {
+		canvas = p.distanceLevels().Colorize(p)
+	} else if p.AutoContrast {
+		canvas = p.generateAutoContrast()
+	} else if p.perturbed() {
+		canvas = p.computeField(p.Continuous).Colorize(p)
+	} else if p.SmartIterations {
+		canvas = p.smartField().Colorize(p)
+	} else if p.Passes > 1 {
+		canvas = p.generatePasses()
+	} else if p.DEMaskedAA && p.samples > 1 && p.quadratic() && p.norm == nil && !p.ExpMap {
+		canvas = p.generateMasked()
+	} else if p.AdaptiveAA && p.samples > 1 {
+		canvas = p.generateAdaptive()
+	} else if p.InteriorTiles && p.quadratic() && p.norm == nil && !p.CurvatureColor && !p.ExpMap {
+		canvas = p.generateTiles()
+	} else {
+		canvas = p.generatePixels()
 	}
+	return canvas
+}
 
-	// allocate the image
-	canvas := image.NewNRGBA(image.Rect(0, 0, p.SizeX, p.SizeY))
+// pixelColoring reports whether every pixel needs more than an escape
+// value to color, so the image can only be rendered with calcPixel.
+func (p *Parameters) pixelColoring() bool {
+	return p.Coloring == "orbit-range" || p.Coloring == "orbittrap" || p.Coloring == "stripe" || p.Coloring == "tia" ||
+		p.Fractal == "newton" || p.interiorColoring()
+}
 
-	// set all pixels using a single worker
-	go func() {
-		for pix := range pixelch {
-			canvas.Set(pix.x, pix.y, pix.color)
-		}
-		done <- struct{}{}
-	}()
+// interiorColoring reports whether the inside of the set is colored by
+// InteriorColoring rather than with InsideColor.
+func (p *Parameters) interiorColoring() bool {
+	return p.InteriorColoring != "" && p.InteriorColoring != "flat"
+}
+
+// plainRender reports whether Generate renders pixel by pixel with
+// CalcPixel, rather than through one of the specialized render paths.
+func (p *Parameters) plainRender() bool {
+	return p.Coloring != "histogram" && p.Coloring != "distance" && !p.perturbed() && !p.AutoContrast && !p.SmartIterations && p.Passes <= 1 && !(p.DEMaskedAA && p.samples > 1) && !(p.AdaptiveAA && p.samples > 1)
+}
+
+// WholeImageOption names the first setting that needs the whole image to
+// render or finish any part of it, or returns "" if there is none. Parts
+// of the image rendered on their own, as GenerateRegion renders them, do
+// not match a full render with such a setting. p must have been
+// initialized.
+func (p *Parameters) WholeImageOption() string {
+	switch {
+	case p.Supersample > 1:
+		return "supersampling"
+	case len(p.Layers) > 0:
+		return "layers"
+	case p.Coloring == "histogram" || p.Coloring == "distance":
+		return p.Coloring + " coloring"
+	case p.AutoContrast:
+		return "AutoContrast"
+	case p.SmartIterations:
+		return "SmartIterations"
+	case p.perturbed():
+		return "perturbation"
+	case p.shaded():
+		return "shading"
+	case p.DensityOverlay > 0:
+		return "the density overlay"
+	case len(p.Contours) > 0:
+		return "contours"
+	case p.LabelDenominator > 0:
+		return "labels"
+	case p.CropShape == "circle" || p.CropShape == "ellipse":
+		return "cropping"
+	}
+	return ""
+}
+
+// sampleGridOption names the first setting that works from the
+// AntiAlias×AntiAlias grid of samples of a field, rather than the samples
+// of each pixel, or returns "" if there is none.
+func (p *Parameters) sampleGridOption() string {
+	switch {
+	case len(p.Layers) > 0:
+		return "layers"
+	case p.Coloring == "histogram" || p.Coloring == "distance":
+		return p.Coloring + " coloring"
+	case p.AutoContrast:
+		return "AutoContrast"
+	case p.SmartIterations:
+		return "SmartIterations"
+	case p.perturbed():
+		return "perturbation"
+	case p.shaded():
+		return "shading"
+	case len(p.Contours) > 0:
+		return "contours"
+	case p.mirrorable():
+		return "symmetry"
+	case p.Output != (OutputSpec{}) && p.Output != NRGBA8:
+		return "output other than 8-bit color"
+	}
+	return ""
+}
 
-	// feed the rows to the workers
-	for row := 0; row < p.SizeY; row++ {
-		rows <- row
+// decorate lights the relief of a finished render, draws the density
+// overlay, contours, and labels over it, and crops it.
+func (p *Parameters) decorate(canvas *image.NRGBA) {
+	if p.shaded() {
+		p.shadeRelief(canvas)
+	}
+	if p.DensityOverlay > 0 {
+		p.overlayDensity(canvas)
 	}
-	close(rows)
+	if len(p.Contours) > 0 {
+		p.drawContours(canvas)
+	}
+	if p.LabelDenominator > 0 {
+		p.drawBulbLabels(canvas)
+	}
+	if p.CropShape == "circle" || p.CropShape == "ellipse" {
+		p.cropImage(canvas)
+	}
+}
 
-	// wait for workers to finish
-	for i := 0; i < fanout; i++ {
-		<-done
+// generatePixels renders the image one pixel at a time with CalcPixel.
+func (p *Parameters) generatePixels() *image.NRGBA {
+	if p.mirrorable() {
+		return p.generateMirrored()
 	}
-	close(pixelch)
-	<-done
+	return p.generateRegion(image.Rect(0, 0, p.SizeX, p.SizeY))
+}
+
+// GenerateRegion renders only the pixels of the image inside rect, and
+// returns an image with rect as its bounds. The pixels are mapped to the
+// plane as part of the full SizeX by SizeY image, so tiles rendered
+// separately, even on different machines, line up exactly, and each pixel
+// is the same as the one Generate produces with its pixel-by-pixel render
+// path. Options that look at the whole image, such as histogram coloring,
+// AutoContrast, SmartIterations, and perturbation, are left out, as are
+// shading, contours, labels, and cropping.
+func (p *Parameters) GenerateRegion(rect image.Rectangle) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateRegion"); err != nil {
+		return nil, err
+	}
+	return p.generateRegion(rect), nil
+}
+
+// GenerateRegionContext is GenerateRegion, but it stops early and returns
+// ctx.Err() if ctx is cancelled before the region is finished.
+func (p *Parameters) GenerateRegionContext(ctx context.Context, rect image.Rectangle) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateRegionContext"); err != nil {
+		return nil, err
+	}
+	q := *p
+	q.ctx = ctx
+	region := q.generateRegion(rect)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return region, nil
+}
+
+// RenderInto renders the pixels of the image inside rect again, straight
+// into canvas, the way GenerateRegion renders them, and leaves the rest of
+// canvas alone. It is for fixing up part of a finished render, such as an
+// area that turned out under-iterated, with more iterations or
+// anti-aliasing than the whole image needed, without rendering all of it
+// again. canvas holds pixels at their places in the full SizeX by SizeY
+// image, so it can be a whole image from Generate or a tile from
+// GenerateRegion, and rect is clipped to it. Options that look at the
+// whole image, or draw over it once it is done, are an error, since the
+// pixels would not match the rest of the render.
+func (p *Parameters) RenderInto(canvas *image.NRGBA, rect image.Rectangle) error {
+	if err := p.checkInit("RenderInto"); err != nil {
+		return err
+	}
+	if opt := p.WholeImageOption(); opt != "" {
+		return fmt.Errorf("%s needs the whole image, so part of it cannot be rendered again", opt)
+	}
+	if !canvas.Bounds().In(image.Rect(0, 0, p.SizeX, p.SizeY)) {
+		return fmt.Errorf("canvas %v does not fit in a %dx%d image", canvas.Bounds(), p.SizeX, p.SizeY)
+	}
+	rect = rect.Intersect(canvas.Bounds())
+	p.forRows(rect.Dy(), func(j int) {
+		p.calcSpan(canvas, rect.Min.X, rect.Max.X, rect.Min.Y+j)
+	})
+	return nil
+}
+
+// generateRegion renders the pixels inside rect a row at a time. Each
+// worker writes its own rows straight into the canvas; rows never share
+// pixels, so no locking is needed.
+func (p *Parameters) generateRegion(rect image.Rectangle) *image.NRGBA {
+	canvas := p.newRegionCanvas(rect)
+	p.forRows(rect.Dy(), func(j int) {
+		p.calcSpan(canvas, rect.Min.X, rect.Max.X, rect.Min.Y+j)
+	})
+	return canvas
+}
+
+// calcSpan renders the pixels of row from start up to end with calcRow,
+// or one at a time with calcPixel for the colorings and options it does
+// not cover.
+func (p *Parameters) calcSpan(canvas *image.NRGBA, start, end, row int) {
+	if !p.pixelColoring() && !(p.AAFastPath && p.samples > 1) {
+		p.calcRow(canvas, start, end, row)
+		return
+	}
+	for col := start; col < end; col++ {
+		c, _ := p.calcPixel(col, row)
+		canvas.SetNRGBA(col, row, c)
+	}
+}
+
+// workers is the number of row workers to use.
+func (p *Parameters) workers() int {
+	if p.pool != nil {
+		return p.pool.workers
+	}
+	if p.Workers > 0 {
+		return p.Workers
+	}
+	return runtime.GOMAXPROCS(-1)
+}
 
+// newCanvas allocates an image of the output size filled with
+// UnrenderedColor.
+func (p *Parameters) newCanvas() *image.NRGBA {
+	return p.newRegionCanvas(image.Rect(0, 0, p.SizeX, p.SizeY))
+}
+
+// newRegionCanvas is newCanvas for part of the image.
+func (p *Parameters) newRegionCanvas(rect image.Rectangle) *image.NRGBA {
+	canvas := image.NewNRGBA(rect)
+	if c := p.UnrenderedColor; c != (color.NRGBA{}) {
+		for i := 0; i < len(canvas.Pix); i += 4 {
+			canvas.Pix[i], canvas.Pix[i+1], canvas.Pix[i+2], canvas.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
 	return canvas
 }
 
-func (p *Parameters) CalcPixel(col, row int) color.Color {
-	if len(p.subpixOffsets) != p.AntiAlias {
-		panic("CalcPixel cannot be called before Init")
+// CalcPixel computes the color of the pixel at col, row on its own, as the
+// pixel-by-pixel render path of Generate does.
+func (p *Parameters) CalcPixel(col, row int) (color.Color, error) {
+	if err := p.checkInit("CalcPixel"); err != nil {
+		return nil, err
+	}
+	c, _ := p.calcPixel(col, row)
+	return c, nil
+}
+
+// calcPixel is CalcPixel, also reporting whether every sample in the
+// pixel was inside the set.
+func (p *Parameters) calcPixel(col, row int) (c color.NRGBA, inside bool) {
+	var sum colorSum
+	inside = p.pixelSum(col, row, &sum)
+	return p.adjust(sum.color()), inside
+}
+
+// pixelSum adds the colors of the samples of the pixel at col, row to
+// sum, and reports whether every one of them was inside the set.
+func (p *Parameters) pixelSum(col, row int, sum *colorSum) (inside bool) {
+	if p.Coloring == "orbit-range" {
+		return p.rangePixel(col, row, sum)
+	}
+	if p.Coloring == "orbittrap" {
+		return p.trapPixel(col, row, sum)
+	}
+	if p.Coloring == "stripe" || p.Coloring == "tia" {
+		return p.averagePixel(col, row, sum)
+	}
+	if p.Fractal == "newton" {
+		return p.newtonPixel(col, row, sum)
+	}
+	if p.AAFastPath && p.samples > 1 && !p.interiorColoring() {
+		if v, ok := p.uniformPixel(col, row); ok {
+			p.report.fastPixel()
+			sum.sample(p.sampleColor(v))
+			return v == 0
+		}
+	}
+
+	// loop over subpixels
+	inside = true
+	for k := 0; k < p.samples; k++ {
+		xoffset, yoffset := p.pixelSample(col, row, k)
+		x, y := p.toPlane(col, row, xoffset, yoffset)
+		v := p.escape(p.MaxIterations, x, y, p.Continuous)
+		inside = inside && v == 0
+		if v == 0 && p.interiorColoring() {
+			sum.sample(p.interiorColor(x, y))
+			continue
+		}
+		sum.sample(p.sampleColor(v))
+	}
+	return inside
+}
+
+// uniformPixel samples the center and the four corners of a pixel and
+// reports whether they all agree: all inside the set, or in discrete mode
+// all escaping on the same iteration. If so, the whole pixel almost
+// certainly shares the center's color, but not always: a filament that
+// slips between the five samples is lost, so a few boundary pixels can
+// come out differently than with the full grid.
+func (p *Parameters) uniformPixel(col, row int) (float64, bool) {
+	x, y := p.toPlane(col, row, 0, 0)
+	center := p.escape(p.MaxIterations, x, y, p.Continuous)
+	if center != 0 && p.Continuous {
+		return 0, false
 	}
+	for _, corner := range [4][2]float64{{-0.5, -0.5}, {0.5, -0.5}, {-0.5, 0.5}, {0.5, 0.5}} {
+		x, y := p.toPlane(col, row, corner[0], corner[1])
+		if p.escape(p.MaxIterations, x, y, p.Continuous) != center {
+			return 0, false
+		}
+	}
+	return center, true
+}
 
+// adjust applies the output gamma to a finished pixel.
+func (p *Parameters) adjust(c color.NRGBA) color.NRGBA {
+	if p.gammaLUT != nil {
+		c.R, c.G, c.B = p.gammaLUT[c.R], p.gammaLUT[c.G], p.gammaLUT[c.B]
+	}
+	return c
+}
+
+// boundsSet reports whether the view is given by explicit bounds. The
+// image then spans exactly that rectangle, stretching pixels if its shape
+// differs from the image's, and Init derives CenterX, CenterY, and
+// Magnification from it. Swapping MinX and MaxX mirrors the view, and
+// exponential maps ignore the bounds.
+func (p *Parameters) boundsSet() bool {
+	return p.MinX != p.MaxX || p.MinY != p.MaxY
+}
+
+// Bounds returns the corners of the rectangle on the complex plane that
+// the image covers with its current center and magnification, measured to
+// the outer edges of the edge pixels. Setting MinX, MinY, MaxX, and MaxY
+// to them renders the same view. For a mirrored view, minX is greater
+// than maxX. Rotation and Transform are left out: the bounds are those of
+// the view before it is turned.
+func (p *Parameters) Bounds() (minX, minY, maxX, maxY float64) {
+	if p.boundsSet() {
+		return p.MinX, p.MinY, p.MaxX, p.MaxY
+	}
 	minsize := p.SizeX
 	if p.SizeY < p.SizeX {
 		minsize = p.SizeY
 	}
+	s := 1 / (p.Magnification * float64(minsize-1))
+	minX = p.CenterX - (float64(p.SizeX/2)+0.5)*s
+	maxX = minX + float64(p.SizeX)*s
+	s = math.Abs(s)
+	maxY = p.CenterY + (float64(p.SizeY/2)+0.5)*s
+	minY = maxY - float64(p.SizeY)*s
+	return minX, minY, maxX, maxY
+}
 
-	// loop over subpixels
-	r, g, b := 0, 0, 0
-	for _, yoffset := range p.subpixOffsets {
-		for _, xoffset := range p.subpixOffsets {
-			x := p.CenterX + (float64(col-p.SizeX/2)+xoffset)/(p.Magnification*float64(minsize-1))
-			y := p.CenterY - (float64(row-p.SizeY/2)-yoffset)/(p.Magnification*float64(minsize-1))
-			rs, gs, bs := p.getColor(mandel(p.MaxIterations, x, y, p.Continuous))
-			r, g, b = r+rs, g+gs, b+bs
+// toPlane maps a sample point, given as a pixel plus an offset from the
+// center of that pixel, to a point on the complex plane.
+func (p *Parameters) toPlane(col, row int, xoffset, yoffset float64) (x, y float64) {
+	dx, dy := p.planeOffset(col, row, xoffset, yoffset)
+	return p.CenterX + dx, p.CenterY + dy
+}
+
+// planeOffset is the offset of a sample point from the center of the
+// image on the complex plane.
+func (p *Parameters) planeOffset(col, row int, xoffset, yoffset float64) (dx, dy float64) {
+	xoffset += p.SampleOffsetX
+	yoffset -= p.SampleOffsetY
+
+	// a negative magnification mirrors the view left to right
+	mag := math.Abs(p.Magnification)
+	if p.ExpMap {
+		// the top edge is a circle of radius 1/Magnification and each
+		// row below it is a little deeper into the zoom
+		start, span := p.expMapAngles()
+		angle := start + span*(float64(col)+0.5+xoffset)/float64(p.SizeX)
+		radius := p.expMapRadius((float64(row) + 0.5 - yoffset) / float64(p.SizeY))
+		dx, dy = float64(radius*math.Cos(angle)), float64(radius*math.Sin(angle))
+	} else if p.boundsSet() {
+		// interpolate across the bounds; a mirrored view has them swapped
+		dx = ((float64(col)+0.5+xoffset)/float64(p.SizeX) - 0.5) * (p.MaxX - p.MinX)
+		dy = (0.5 - (float64(row)+0.5-yoffset)/float64(p.SizeY)) * (p.MaxY - p.MinY)
+		return p.viewOffset(dx, dy)
+	} else {
+		minsize := p.SizeX
+		if p.SizeY < p.SizeX {
+			minsize = p.SizeY
 		}
+		dx = (float64(col-p.SizeX/2) + xoffset) / (mag * float64(minsize-1))
+		dy = -(float64(row-p.SizeY/2) - yoffset) / (mag * float64(minsize-1))
+	}
+	if p.Magnification < 0 {
+		dx = -dx
 	}
+	return p.viewOffset(dx, dy)
+}
+
+func (p *Parameters) getColor(iters float64) (r, g, b, a int) {
+	rf, gf, bf, af := p.sampleColor(iters)
+	return clampChannel(rf), clampChannel(gf), clampChannel(bf), clampChannel(af)
+}
 
-	aa := p.AntiAlias * p.AntiAlias
-	return color.NRGBA{uint8(r / aa), uint8(g / aa), uint8(b / aa), 255}
+// clampChannel truncates a color channel to an integer in [0, 255], so
+// rounding error at the ends of the range cannot wrap around when the
+// channel is later stored in a byte.
+func clampChannel(v float64) int {
+	if !(v > 0) {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return int(v)
 }
 
-func (p *Parameters) getColor(iters float64) (r, g, b int) {
+// sampleColor is the color of a single sample, with channels in [0, 255]
+// kept at full precision.
+func (p *Parameters) sampleColor(iters float64) (r, g, b, a float64) {
+	// trap coloring passes levels for interior samples as well, and
+	// leaves them transparent itself
+	if p.Coloring != "orbittrap" && p.clearSample(iters == 0) {
+		return 0, 0, 0, 0
+	}
+	if p.Colorer != nil {
+		c := p.Colorer.Color(IterationResult{Iterations: iters, Inside: iters == 0, MaxIterations: p.MaxIterations})
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
+	}
 	if iters == 0.0 {
 		c := p.InsideColor
-		return int(c.R), int(c.G), int(c.B)
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
+	}
+	return p.valueColor(iters, p.Coloring == "distance" || p.Coloring == "orbittrap")
+}
+
+// levelColor is the color of a level in (0, 1], spread across the palette
+// once, as distance coloring colors its samples.
+func (p *Parameters) levelColor(level float64) (r, g, b, a float64) {
+	return p.valueColor(level, true)
+}
+
+// valueColor is sampleColor for a sample outside the set, given either
+// its escape value or, when levels is set, a level in (0, 1].
+func (p *Parameters) valueColor(iters float64, levels bool) (r, g, b, a float64) {
+	if !levels {
+		iters = p.colorValue(iters)
+	}
+	if p.duotone() && p.Coloring != "alpha-ramp" {
+		weight := p.level(iters)
+		if p.histogram != nil {
+			weight = p.histogramLevel(iters)
+		} else if levels {
+			weight = iters
+		} else if p.CurvatureColor {
+			weight = iters - 1
+		}
+		c1, c2 := p.Duotone[0], p.Duotone[1]
+		mix := func(u, v uint8) float64 {
+			return float64(float64(u)*(1.0-weight)) + float64(float64(v)*weight)
+		}
+		return mix(c1.R, c2.R), mix(c1.G, c2.G), mix(c1.B, c2.B), mix(c1.A, c2.A)
+	}
+	if levels {
+		// distance and trap colorings pass levels in (0, 1] rather than
+		// escape values
+		iters = p.spread(iters)
+	} else if p.histogram != nil && p.Coloring == "histogram" {
+		// spread the palette once, with equal shares of escaped samples
+		iters = p.spread(p.histogramLevel(iters))
+	} else if p.contrastHi > p.contrastLo && p.Coloring != "alpha-ramp" {
+		// spread the contrast window across the palette once
+		iters = 1 + p.level(iters)*float64(len(p.palette)-1)
+	} else if p.CurvatureColor {
+		// curvature values run from 1 to 2 for turns from 0 to 180°
+		iters = p.spread(iters - 1)
+	}
+	if p.Coloring == "alpha-ramp" {
+		c := over(p.RampColor, p.BackgroundColor, p.level(iters))
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
 	}
+	iters += p.PaletteOffset * float64(len(p.palette))
 	if !p.Continuous {
-		c := p.Palette[int(iters)%len(p.Palette)]
-		return int(c.R), int(c.G), int(c.B)
+		c := p.palette[paletteIndex(iters, len(p.palette))]
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
+	}
+
+	var c1, c2 color.NRGBA
+	var weight float64
+	if p.constantPalette {
+		// a single color has nothing to interpolate along, so shade from
+		// it toward the interior color as points get closer to the set
+		c1, c2 = p.palette[0], p.InsideColor
+		weight = p.level(iters)
+	} else {
+		pos := math.Floor(iters)
+		weight = iters - math.Floor(iters)
+		if math.IsNaN(weight) {
+			weight = 0
+		}
+		// values below 1 blend from the last entry, wrapping around
+		i := paletteIndex(pos, len(p.palette))
+		c1 = p.palette[(i+len(p.palette)-1)%len(p.palette)]
+		c2 = p.palette[i]
+	}
+	weight = math.Max(0, math.Min(1, weight))
+	switch p.Interpolation {
+	case "hsv":
+		c := mixHSV(c1, c2, weight)
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
+	case "lab":
+		c := mixLab(c1, c2, weight)
+		return float64(c.R), float64(c.G), float64(c.B), float64(c.A)
+	}
+	// rounding both products keeps them from being fused into
+	// multiply-adds, as in mandel
+	mix := func(u, v uint8) float64 {
+		return float64(float64(u)*(1.0-weight)) + float64(float64(v)*weight)
+	}
+	r, g, b, a = mix(c1.R, c2.R), mix(c1.G, c2.G), mix(c1.B, c2.B), mix(c1.A, c2.A)
+	return r, g, b, a
+}
+
+// spread maps a level in [0, 1] to a palette position that covers the
+// palette once from start to end.
+func (p *Parameters) spread(t float64) float64 {
+	if p.Continuous {
+		return 1 + t*float64(len(p.palette)-1)
+	}
+	return math.Min(t*float64(len(p.palette)), float64(len(p.palette)-1))
+}
+
+// duotone reports whether Duotone coloring is in use.
+func (p *Parameters) duotone() bool {
+	return p.Duotone != [2]color.NRGBA{}
+}
+
+// colorValue applies depth normalization and the color clamp to an
+// escape value.
+func (p *Parameters) colorValue(iters float64) float64 {
+	iters -= p.depthShift
+	if p.ColorClampMax > 0 && iters > p.ColorClampMax {
+		iters = p.ColorClampMax
+	}
+	return iters
+}
+
+// level maps an escape value onto [0, 1] on a log scale, so points
+// close to the set still spread across most of the range. With
+// AutoContrast, the range is the frame's contrast window instead.
+func (p *Parameters) level(iters float64) float64 {
+	t := math.Log1p(iters) / math.Log1p(float64(p.MaxIterations))
+	if p.contrastHi > p.contrastLo {
+		t = (math.Log1p(iters) - p.contrastLo) / (p.contrastHi - p.contrastLo)
 	}
+	return math.Max(0, math.Min(1, t))
+}
 
-	aa := int(math.Floor(iters))
-	bb := int(math.Floor(iters)) + 1
-	if aa < 1 {
-		aa, bb = 1, 2
+// escape finds the escape value of the point (x, y): the number of
+// iterations before it escapes, smoothed in continuous mode, or 0 if it
+// does not escape within maxIters.
+func (p *Parameters) escape(maxIters int, x, y float64, continuous bool) float64 {
+	if p.report == nil {
+		return p.escapeValue(maxIters, x, y, continuous)
 	}
-	weight := iters - math.Floor(iters)
-	c1 := p.Palette[(aa-1)%len(p.Palette)]
-	c2 := p.Palette[(bb-1)%len(p.Palette)]
-	r = int(float64(c1.R)*(1.0-weight) + float64(c2.R)*weight)
-	g = int(float64(c1.G)*(1.0-weight) + float64(c2.G)*weight)
-	b = int(float64(c1.B)*(1.0-weight) + float64(c2.B)*weight)
-	return r, g, b
+	start := p.report.clock()
+	v := p.escapeValue(maxIters, x, y, continuous)
+	p.report.escaped(maxIters, v)
+	p.report.sampled(p, start, maxIters, []float64{x}, []float64{y}, []float64{v})
+	return v
 }
 
-func mandel(maxIters int, x, y float64, continuous bool) float64 {
-	bailout := float64(4.0)
-	if continuous {
-		bailout = 2 << 16
+// escapeValue is escape without the report.
+func (p *Parameters) escapeValue(maxIters int, x, y float64, continuous bool) float64 {
+	if p.system != nil {
+		return iterateSystem(p.system, maxIters, x, y)
+	}
+	if p.formula != nil {
+		return iterateFormula(p.formula, maxIters, x, y, p.smoother(continuous, 2), p.bailout(continuous))
 	}
+	if !p.quadratic() {
+		power := p.Power
+		if power == 0 {
+			power = 2
+		}
+		smooth, bailout := p.smoother(continuous, power), p.bailout(continuous)
+		if p.Fractal == "julia" {
+			return iteratePower(maxIters, x, y, p.JuliaCX, p.JuliaCY, smooth, bailout, power, p.Fractal)
+		}
+		return iteratePower(maxIters, x, y, x, y, smooth, bailout, power, p.Fractal)
+	}
+	if p.norm != nil {
+		return mandelNorm(maxIters, x, y, p.norm)
+	}
+	if p.CompensatedSum {
+		return mandelCompensated(maxIters, x, y, p.smoother(continuous, 2), p.bailout(continuous))
+	}
+	if p.CurvatureColor {
+		turn := mandelTurn(maxIters, x, y, p.bailout(continuous))
+		if turn < 0 {
+			return 0.0
+		}
+		// keep the field independent of the palette; valueColor spreads
+		// the turn across it
+		return 1 + turn/math.Pi
+	}
+	if p.ExactInterior {
+		return iteratePower(maxIters, x, y, x, y, p.smoother(continuous, 2), p.bailout(continuous), 2, "")
+	}
+	return mandel(maxIters, x, y, p.smoother(continuous, 2), p.bailout(continuous))
+}
+
+// bailout is the squared escape radius.
+func (p *Parameters) bailout(continuous bool) float64 {
+	if p.Bailout > 0 {
+		return float64(p.Bailout * p.Bailout)
+	}
+	if !continuous {
+		return 4.0
+	}
+
+	// once |z|² passes a few hundred the smoothing term is accurate,
+	// so iterating further only costs time
+	if p.SmoothBailout > 0 {
+		return p.SmoothBailout
+	}
+	return 256
+}
+
+// mandel iterates z² + c from z = c. The explicit float64 conversions
+// round each product, which keeps the compiler from fusing them into
+// multiply-adds on platforms that have them, so every platform produces
+// the same escape values.
+func mandel(maxIters int, x, y float64, smooth smoother, bailout float64) float64 {
+	// points in the main cardioid or the period-2 bulb never escape
+	xq := x - 0.25
+	y2 := float64(y * y)
+	q := float64(xq*xq) + y2
+	if float64(q*(q+xq)) <= float64(0.25*y2) || float64((x+1)*(x+1))+y2 <= 0.0625 {
+		return 0.0
+	}
+	return mandelOrbit(maxIters, x, y, smooth, bailout)
+}
+
+// mandelOrbit is mandel without the cardioid and bulb test.
+func mandelOrbit(maxIters int, x, y float64, smooth smoother, bailout float64) float64 {
+	// an orbit that comes back to where it was has settled into a cycle
+	// and never escapes; the saved point moves forward at doubling
+	// intervals so cycles of any length are caught
 	a, b := x, y
+	ra, rb := a, b
+	next, interval := periodStart, periodStart
 	for iters := 1; iters <= maxIters; iters++ {
-		a2 := a * a
-		b2 := b * b
+		a2 := float64(a * a)
+		b2 := float64(b * b)
 		if a2+b2 >= bailout {
-			if continuous {
-				nu := math.Log2(math.Log2(a2+b2) * 0.5)
-				return float64(iters+1) - nu
-			} else {
-				return float64(iters)
+			if smooth != nil {
+				return smooth(iters, a2+b2, bailout)
 			}
+			return float64(iters)
 		}
-		ab := a * b
+		ab := float64(a * b)
 		a = a2 - b2 + x
 		b = ab + ab + y
+		if math.Abs(a-ra) < periodEpsilon && math.Abs(b-rb) < periodEpsilon {
+			return 0.0
+		}
+		if iters == next {
+			ra, rb = a, b
+			interval *= 2
+			next += interval
+		}
+	}
+	return 0.0
+}
+
+// periodicity checking in mandel: the first interval between saved
+// points, and how close the orbit must come back to one to count as a
+// cycle
+const (
+	periodStart   = 16
+	periodEpsilon = 1e-15
+)
+
+// mandelCompensated is mandel with each new z computed as a correctly
+// rounded sum. The products are split into their rounded values and exact
+// rounding errors with fused multiply-adds, and the terms are added with
+// Neumaier's compensated summation.
+func mandelCompensated(maxIters int, x, y float64, smooth smoother, bailout float64) float64 {
+	a, b := x, y
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if a2+b2 >= bailout {
+			if smooth != nil {
+				return smooth(iters, a2+b2, bailout)
+			}
+			return float64(iters)
+		}
+		a2err := math.FMA(a, a, -a2)
+		b2err := math.FMA(b, b, -b2)
+		ab := float64(2 * a * b)
+		aberr := math.FMA(2*a, b, -ab)
+		a = neumaierSum(a2, -b2, x, a2err, -b2err)
+		b = neumaierSum(ab, y, aberr)
 	}
 	return 0.0
 }
+
+// neumaierSum adds its terms, carrying the rounding error of each
+// addition along and adding it back at the end.
+func neumaierSum(terms ...float64) float64 {
+	sum, comp := 0.0, 0.0
+	for _, t := range terms {
+		s := sum + t
+		if math.Abs(sum) >= math.Abs(t) {
+			comp += (sum - s) + t
+		} else {
+			comp += (t - s) + sum
+		}
+		sum = s
+	}
+	return sum + comp
+}
+
+// smoothEscape is the continuous escape value of a point whose orbit
+// first reached |z|² = mag2 >= bailout on iteration iters. The usual
+// log-log correction puts the value somewhere in an interval one unit wide
+// that depends on the bailout; the shift places it in (iters, iters+1] for
+// any bailout, so values run smoothly from 1 upward. Only points that are
+// already far past the bailout radius at the first iteration fall below
+// 1, and those are eased toward 0 without a kink, keeping them above the
+// 0 that marks the interior.
+func smoothEscape(iters int, mag2, bailout float64) float64 {
+	nu := math.Log2(math.Log2(mag2) * 0.5)
+	shift := math.Log2(math.Log2(bailout)) - 1
+	v := float64(iters+1) - nu + shift
+	if v < 1 {
+		v = math.Exp(v - 1)
+	}
+	return v
+}
+
+// bailoutNorms measure z for the escape tests of the non-circular bailout
+// shapes, each on the same scale as |z|² so all of them escape at 4.
+var bailoutNorms = map[string]func(a, b float64) float64{
+	"square": func(a, b float64) float64 { return math.Max(a*a, b*b) },
+	"cross":  func(a, b float64) float64 { return math.Min(a*a, b*b) },
+	"rhombus": func(a, b float64) float64 {
+		s := math.Abs(a) + math.Abs(b)
+		return s * s
+	},
+}
+
+// mandelNorm is mandel in discrete mode with a different escape test. The
+// cross test never fires for orbits that stay on an axis, so anything that
+// gets truly large counts as escaped too.
+func mandelNorm(maxIters int, x, y float64, norm func(a, b float64) float64) float64 {
+	a, b := x, y
+	for iters := 1; iters <= maxIters; iters++ {
+		a2, b2 := float64(a*a), float64(b*b)
+		if norm(a, b) >= 4 || a2+b2 >= 1e20 {
+			return float64(iters)
+		}
+		a, b = a2-b2+x, float64(2*a*b)+y
+	}
+	return 0.0
+}
+
+// mandelTurn iterates like mandel and returns the angle, in [0, π], by
+// which the orbit changes direction on its final step before escaping, or
+// -1 if the point does not escape.
+func mandelTurn(maxIters int, x, y float64, bailout float64) float64 {
+	a, b := x, y
+	// the previous two points of the orbit, starting from z = 0
+	pa, pb := 0.0, 0.0
+	qa, qb := 0.0, 0.0
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if a2+b2 >= bailout {
+			// angle from the step before last to the last step
+			d0a, d0b := pa-qa, pb-qb
+			d1a, d1b := a-pa, b-pb
+			cross := float64(d0a*d1b) - float64(d0b*d1a)
+			dot := float64(d0a*d1a) + float64(d0b*d1b)
+			return math.Abs(math.Atan2(cross, dot))
+		}
+		qa, qb = pa, pb
+		pa, pb = a, b
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+	}
+	return -1
+}
+
+// distance iterates like mandel while tracking the derivative of z with
+// respect to c, and returns the estimated distance |z|·ln|z|/|dz| from
+// (x, y) to the set, or 0 if the point does not escape.
+func distance(maxIters int, x, y float64) float64 {
+	mag, dmag, escaped := derivative(maxIters, x, y)
+	if !escaped {
+		return 0.0
+	}
+	return mag * math.Log(mag) / dmag
+}
+
+// derivative iterates z² + c while tracking dz/dc, and returns |z| and
+// |dz/dc| where the orbit escaped, or after maxIters iterations if it
+// did not.
+func derivative(maxIters int, x, y float64) (mag, dmag float64, escaped bool) {
+	// a large bailout keeps the distance estimate accurate
+	bailout := float64(1 << 20)
+	a, b := x, y
+	da, db := 1.0, 0.0
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if a2+b2 >= bailout {
+			return math.Sqrt(a2 + b2), math.Hypot(da, db), true
+		}
+
+		// dz = 2·z·dz + 1
+		da, db = 2*(float64(a*da)-float64(b*db))+1, 2*(float64(a*db)+float64(b*da))
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+	}
+	return math.Hypot(a, b), math.Hypot(da, db), false
+}
@@ -0,0 +1,486 @@
+package mandel
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/big"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// exactEscape is the discrete escape count of z² + c from z = c, with c =
+// x + yi, iterated in enough precision that no rounding shows.
+func exactEscape(maxIters int, x, y float64) int {
+	const prec = 300
+	newFloat := func() *big.Float { return new(big.Float).SetPrec(prec) }
+	cx, cy := newFloat().SetFloat64(x), newFloat().SetFloat64(y)
+	a, b := newFloat().Set(cx), newFloat().Set(cy)
+	a2, b2, ab, mag2 := newFloat(), newFloat(), newFloat(), newFloat()
+	for iters := 1; iters <= maxIters; iters++ {
+		a2.Mul(a, a)
+		b2.Mul(b, b)
+		if m, _ := mag2.Add(a2, b2).Float64(); m >= 4 {
+			return iters
+		}
+		ab.Mul(a, b)
+		a.Add(a.Sub(a2, b2), cx)
+		b.Add(b.Add(ab, ab), cy)
+	}
+	return 0
+}
+
+// TestCompensatedSum counts how many points along a line through a deep
+// view escape on the same iteration as they do in exact arithmetic, with
+// plain float64 steps and with CompensatedSum. The two do about as well
+// while the points are dozens of ulps apart; from a magnification of about
+// 1e13, where they are a few ulps apart, compensated steps get noticeably
+// more of them right.
+func TestCompensatedSum(t *testing.T) {
+	const (
+		cx, cy   = -0.743643887037158704752191506114774, 0.131825904205311970493132056385139
+		points   = 200
+		maxIters = 5000
+	)
+	var plainDeep, compensatedDeep int
+	for _, mag := range []float64{1e11, 1e12, 1e13, 3e13, 1e14} {
+		plain, compensated := 0, 0
+		for k := 0; k < points; k++ {
+			x := cx + (float64(k)/points-0.5)*1.5/mag
+			want := exactEscape(maxIters, x, cy)
+			if int(iteratePower(maxIters, x, cy, x, cy, nil, 4, 2, "")) == want {
+				plain++
+			}
+			if int(mandelCompensated(maxIters, x, cy, nil, 4)) == want {
+				compensated++
+			}
+		}
+		t.Logf("magnification %g: %d of %d exact with plain steps, %d with compensated", mag, plain, points, compensated)
+		if mag >= 1e13 {
+			plainDeep += plain
+			compensatedDeep += compensated
+		}
+	}
+	if compensatedDeep <= plainDeep {
+		t.Errorf("past 1e13, compensated steps got %d points exact, plain steps %d", compensatedDeep, plainDeep)
+	}
+}
+
+// TestPeriodicity renders views with many interior points off the main
+// cardioid and bulb with and without the periodicity check, which
+// ExactInterior turns off, and requires every pixel to match within 1.
+func TestPeriodicity(t *testing.T) {
+	views := []struct {
+		name      string
+		x, y, mag float64
+	}{
+		{"whole set", -0.75, 0, 0.4},
+		{"seahorse valley", -0.7453, 0.1127, 300},
+		{"elephant valley", 0.2925, 0.0149, 400},
+		{"minibrot", -1.7548776, 0, 2000},
+		{"filaments", -0.1011, 0.9563, 40},
+	}
+	for _, view := range views {
+		for _, continuous := range []bool{false, true} {
+			render := func(exact bool) []uint8 {
+				p := Parameters{CenterX: view.x, CenterY: view.y, Magnification: view.mag, MaxIterations: 3000, SizeX: 96, SizeY: 64, AntiAlias: 2, Continuous: continuous, Palette: DefaultPalette(), ExactInterior: exact}
+				if err := p.Init(); err != nil {
+					t.Fatal(err)
+				}
+				img, err := p.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return img.Pix
+			}
+			got, want := render(false), render(true)
+			for i := range want {
+				if d := int(got[i]) - int(want[i]); d < -1 || d > 1 {
+					t.Errorf("%s, continuous %v: pixel (%d, %d) channel %d is %d with the periodicity check, %d without", view.name, continuous, i/4%96, i/4/96, i%4, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+// TestGenerateRegion checks that regions of every shape, including ones
+// on the edges of the image and of odd sizes, come out pixel for pixel as
+// the same part of the full image from Generate.
+func TestGenerateRegion(t *testing.T) {
+	base := Parameters{CenterX: -0.75, Magnification: 0.5, MaxIterations: 500, SizeX: 41, SizeY: 31, AntiAlias: 3, Continuous: true, Palette: DefaultPalette()}
+	tests := []struct {
+		name string
+		set  func(p *Parameters)
+	}{
+		{"anti-aliased", func(p *Parameters) {}},
+		{"jittered", func(p *Parameters) { p.JitterAA, p.Seed = true, 5 }},
+		{"symmetric", func(p *Parameters) { p.Symmetry = true }},
+		{"mirrored", func(p *Parameters) { p.Magnification = -p.Magnification; p.JitterAA = true }},
+	}
+	regions := []image.Rectangle{
+		image.Rect(0, 0, 41, 31),
+		image.Rect(0, 0, 7, 5),
+		image.Rect(34, 26, 41, 31),
+		image.Rect(0, 15, 41, 16),
+		image.Rect(13, 3, 26, 28),
+		image.Rect(40, 0, 41, 31),
+		image.Rect(20, 15, 21, 16),
+	}
+	for _, test := range tests {
+		p := base
+		test.set(&p)
+		if err := p.Init(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		full, err := p.Generate()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		for _, rect := range regions {
+			region, err := p.GenerateRegion(rect)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if region.Rect != rect {
+				t.Errorf("%s: region %v has bounds %v", test.name, rect, region.Rect)
+				continue
+			}
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					if got, want := region.NRGBAAt(x, y), full.NRGBAAt(x, y); got != want {
+						t.Errorf("%s: region %v has %v at (%d, %d), Generate has %v", test.name, rect, got, x, y, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestNegativeMagnification checks that a negative magnification renders
+// the view mirrored left to right about CenterX, which is the center of
+// column SizeX/2, so with an even width the leftmost column has no mirror
+// image.
+func TestNegativeMagnification(t *testing.T) {
+	for _, size := range []int{40, 41} {
+		for _, aa := range []int{1, 3} {
+			render := func(mag float64) *image.NRGBA {
+				p := Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: mag, MaxIterations: 500, SizeX: size, SizeY: 24, AntiAlias: aa, Continuous: true, Palette: DefaultPalette()}
+				if err := p.Init(); err != nil {
+					t.Fatal(err)
+				}
+				img, err := p.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return img
+			}
+			want, got := render(100), render(-100)
+			for y := 0; y < 24; y++ {
+				for x := 0; x < size; x++ {
+					mirror := 2*(size/2) - x
+					if mirror >= size {
+						continue
+					}
+					if g, w := got.NRGBAAt(x, y), want.NRGBAAt(mirror, y); g != w {
+						t.Errorf("width %d, %dx%d samples: (%d, %d) is %v, its mirror image (%d, %d) is %v", size, aa, aa, x, y, g, mirror, y, w)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestConstantPalette checks that a palette of one color, or of one color
+// repeated, colors escaped points with that color in discrete mode, and in
+// continuous mode shades them steadily from it toward InsideColor as they
+// take longer to escape.
+func TestConstantPalette(t *testing.T) {
+	hue, inside := color.NRGBA{200, 100, 40, 255}, color.NRGBA{0, 0, 0, 255}
+	for _, palette := range [][]color.NRGBA{{hue}, {hue, hue, hue}} {
+		for _, continuous := range []bool{false, true} {
+			p := Parameters{Magnification: 1, MaxIterations: 1000, SizeX: 8, SizeY: 8, AntiAlias: 1, Palette: palette, InsideColor: inside, Continuous: continuous}
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			prev := -1.0
+			for v := 0.5; v <= float64(p.MaxIterations); v *= 1.1 {
+				r, g, b, a := p.valueColor(v, false)
+				if !continuous {
+					if r != float64(hue.R) || g != float64(hue.G) || b != float64(hue.B) || a != float64(hue.A) {
+						t.Errorf("%d entries, discrete: escape value %g gives %g %g %g %g, want %v", len(palette), v, r, g, b, a, hue)
+					}
+					continue
+				}
+				// every color lies on the line from hue to black
+				w := 1 - r/float64(hue.R)
+				if math.Abs(g-float64(hue.G)*(1-w)) > 1e-9 || math.Abs(b-float64(hue.B)*(1-w)) > 1e-9 || math.Abs(a-255) > 1e-9 {
+					t.Errorf("%d entries, continuous: escape value %g gives %g %g %g %g, off the ramp from %v to %v", len(palette), v, r, g, b, a, hue, inside)
+				}
+				if w < prev || w > prev+0.05 && prev >= 0 {
+					t.Errorf("%d entries, continuous: escape value %g is %g of the way to the interior, after %g", len(palette), v, w, prev)
+				}
+				prev = w
+			}
+			if continuous && prev < 0.95 {
+				t.Errorf("%d entries, continuous: the slowest escapes are only %g of the way to the interior", len(palette), prev)
+			}
+		}
+	}
+}
+
+// TestGenerateAllocs checks that the allocations of a render do not grow
+// with the number of pixels, since row workers write straight into the
+// image.
+func TestGenerateAllocs(t *testing.T) {
+	allocs := func(width, height int) float64 {
+		p := Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 50, SizeX: width, SizeY: height, AntiAlias: 1, Palette: DefaultPalette(), Workers: 2}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		return testing.AllocsPerRun(5, func() {
+			if _, err := p.Generate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	small, large := allocs(64, 48), allocs(256, 192)
+	if large > small+20 {
+		t.Errorf("a render of 64×48 makes %g allocations, 256×192 makes %g", small, large)
+	}
+}
+
+// BenchmarkGenerate renders the whole set at a low iteration limit, so
+// the time goes mostly to handling pixels rather than iterating them.
+func BenchmarkGenerate(b *testing.B) {
+	for _, size := range []struct{ width, height int }{{320, 240}, {1280, 960}} {
+		p := Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 50, SizeX: size.width, SizeY: size.height, AntiAlias: 1, Palette: DefaultPalette()}
+		if err := p.Init(); err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("%dx%d", size.width, size.height), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(4 * size.width * size.height))
+			for n := 0; n < b.N; n++ {
+				if _, err := p.Generate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCompensatedSum renders a view near the boundary with plain and
+// with compensated steps.
+func BenchmarkCompensatedSum(b *testing.B) {
+	for _, compensated := range []bool{false, true} {
+		p := Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 200, MaxIterations: 1000, SizeX: 160, SizeY: 120, AntiAlias: 1, Palette: DefaultPalette(), CompensatedSum: compensated}
+		if err := p.Init(); err != nil {
+			b.Fatal(err)
+		}
+		name := "plain"
+		if compensated {
+			name = "compensated"
+		}
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				if _, err := p.Generate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestBoundsAspect checks on non-square images that one pixel spans the
+// same distance on the plane along both axes, whether the view is given by
+// center and magnification, by the bounds that Bounds returns for it, or
+// by FitBounds, and that the bounds view puts every pixel where the center
+// view does.
+func TestBoundsAspect(t *testing.T) {
+	for _, size := range []image.Point{{300, 100}, {100, 300}, {301, 97}} {
+		center := Parameters{CenterX: -0.5, CenterY: 0.25, Magnification: 0.8, MaxIterations: 100, SizeX: size.X, SizeY: size.Y, AntiAlias: 1, Palette: DefaultPalette()}
+		bounds := center
+		bounds.MinX, bounds.MinY, bounds.MaxX, bounds.MaxY = center.Bounds()
+		fit := center
+		fit.FitBounds(-2, -0.5, 1, 1)
+		for name, p := range map[string]*Parameters{"center": &center, "bounds": &bounds, "fit": &fit} {
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			x0, y0, _ := p.PixelToComplex(0.5, 0.5)
+			x1, y1, _ := p.PixelToComplex(float64(size.X)-0.5, float64(size.Y)-0.5)
+			dx, dy := (x1-x0)/float64(size.X-1), (y0-y1)/float64(size.Y-1)
+			if math.Abs(dx-dy) > 1e-12*dx {
+				t.Errorf("%dx%d %s: a pixel is %g wide and %g tall", size.X, size.Y, name, dx, dy)
+			}
+		}
+		for _, pt := range []image.Point{{0, 0}, {size.X - 1, 0}, {size.X / 2, size.Y / 2}, {size.X - 1, size.Y - 1}} {
+			fx, fy := float64(pt.X)+0.5, float64(pt.Y)+0.5
+			wx, wy, _ := center.PixelToComplex(fx, fy)
+			gx, gy, _ := bounds.PixelToComplex(fx, fy)
+			if math.Abs(gx-wx) > 1e-12 || math.Abs(gy-wy) > 1e-12 {
+				t.Errorf("%dx%d: pixel %v is at (%g, %g) by bounds, (%g, %g) by center", size.X, size.Y, pt, gx, gy, wx, wy)
+			}
+		}
+	}
+}
+
+// TestAAFastPath compares AAFastPath with the full anti-aliasing grid on
+// views along the boundary. The fast path is an approximation, so a few
+// pixels may differ where detail slips between its samples, but it must
+// take most of the uniform pixels and leave nearly all of them unchanged.
+func TestAAFastPath(t *testing.T) {
+	for _, v := range [][3]float64{{-0.75, 0, 0.4}, {-0.7453, 0.1127, 100}, {-1.25, 0, 5}} {
+		for _, continuous := range []bool{false, true} {
+			var fast int64
+			render := func(aafast bool) *image.NRGBA {
+				p := Parameters{CenterX: v[0], CenterY: v[1], Magnification: v[2], MaxIterations: 1000, SizeX: 120, SizeY: 80, AntiAlias: 3, Continuous: continuous, AAFastPath: aafast, Palette: DefaultPalette()}
+				p.Report = func(r *RenderReport) { fast = r.FastPixels }
+				if err := p.Init(); err != nil {
+					t.Fatal(err)
+				}
+				img, err := p.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return img
+			}
+			want, got := render(false), render(true)
+			differ := 0
+			for y := 0; y < 80; y++ {
+				for x := 0; x < 120; x++ {
+					if got.NRGBAAt(x, y) != want.NRGBAAt(x, y) {
+						differ++
+					}
+				}
+			}
+			if fast < 120*80/10 {
+				t.Errorf("%v continuous %v: only %d pixels took the fast path", v, continuous, fast)
+			}
+			if differ > 120*80/100 {
+				t.Errorf("%v continuous %v: %d of %d pixels differ from the full grid", v, continuous, differ, 120*80)
+			}
+		}
+	}
+}
+
+// TestPowerTwo checks that asking for Power 2 and the "mandelbrot" fractal
+// by name renders the same bytes as leaving them at their defaults.
+func TestPowerTwo(t *testing.T) {
+	for _, continuous := range []bool{false, true} {
+		for _, aa := range []int{1, 3} {
+			render := func(power int, fractal string) []uint8 {
+				p := Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 100, MaxIterations: 500, SizeX: 64, SizeY: 48, AntiAlias: aa, Continuous: continuous, Power: power, Fractal: fractal, Palette: DefaultPalette()}
+				if err := p.Init(); err != nil {
+					t.Fatal(err)
+				}
+				img, err := p.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return img.Pix
+			}
+			want := render(0, "")
+			for _, fractal := range []string{"", "mandelbrot"} {
+				got := render(2, fractal)
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("continuous %v, %dx%d samples, fractal %q: Power 2 differs from the default at byte %d", continuous, aa, aa, fractal, i)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestInteriorCheck checks that the main cardioid and period-2 bulb test
+// in mandel changes nothing: at every sample of a render of the whole set,
+// discrete and continuous, mandel gives exactly what the iteration without
+// the test does.
+func TestInteriorCheck(t *testing.T) {
+	p := Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 2000, SizeX: 160, SizeY: 120, AntiAlias: 3, Palette: DefaultPalette()}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	skipped := 0
+	for _, smooth := range []smoother{nil, smoothEscape} {
+		bailout := 4.0
+		if smooth != nil {
+			bailout = 256
+		}
+		for row := 0; row < p.SizeY; row++ {
+			for col := 0; col < p.SizeX; col++ {
+				for k := 0; k < p.samples; k++ {
+					xoffset, yoffset := p.pixelSample(col, row, k)
+					x, y := p.toPlane(col, row, xoffset, yoffset)
+					got, want := mandel(p.MaxIterations, x, y, smooth, bailout), mandelOrbit(p.MaxIterations, x, y, smooth, bailout)
+					if got != want {
+						t.Fatalf("(%g, %g): %g with the interior check, %g without", x, y, got, want)
+					}
+					if xq := x - 0.25; (xq*xq+y*y)*(xq*xq+y*y+xq) <= 0.25*y*y {
+						skipped++
+					}
+				}
+			}
+		}
+	}
+	if skipped == 0 {
+		t.Errorf("no samples fell in the main cardioid")
+	}
+}
+
+// TestGenerateContextCancel cancels renders partway through, once some
+// rows are done, and checks that GenerateContext returns context.Canceled
+// promptly and that every goroutine it started exits.
+func TestGenerateContextCancel(t *testing.T) {
+	base := Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 20000, SizeX: 200, SizeY: 150, AntiAlias: 2, Palette: DefaultPalette()}
+	cases := map[string]func(p *Parameters){
+		"plain":       func(p *Parameters) {},
+		"one row":     func(p *Parameters) { p.ChunkRows = 1 },
+		"adaptive":    func(p *Parameters) { p.AdaptiveAA = true },
+		"supersample": func(p *Parameters) { p.Supersample = 2 },
+		"histogram":   func(p *Parameters) { p.Coloring = "histogram" },
+	}
+	before := runtime.NumGoroutine()
+	for name, setup := range cases {
+		p := base
+		setup(&p)
+		ctx, cancel := context.WithCancel(context.Background())
+		p.Progress = func(done, total int) {
+			if done > 0 {
+				cancel()
+			}
+		}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		errs := make(chan error, 1)
+		go func() {
+			_, err := p.GenerateContext(ctx)
+			errs <- err
+		}()
+		select {
+		case err := <-errs:
+			if err != context.Canceled {
+				t.Errorf("%s: returned %v, want %v", name, err, context.Canceled)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s: no return 10s after cancelling", name)
+		}
+		cancel()
+	}
+
+	// the workers may still be on their way out when GenerateContext
+	// returns, so give them a moment
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before the renders, %d after", before, after)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"os"
+
+	"github.com/russross/mandel"
+)
+
+// animation saves the frames of a zoom or palette cycle as one animated
+// image: a GIF, with each frame quantized by QuantizeImage, or an APNG
+// for any other file name. GIF frames are kept until close; APNG frames
+// are written as they come.
+type animation struct {
+	p        *mandel.Parameters
+	filename string
+	fp       *os.File
+	gif      *gif.GIF
+	apng     *mandel.APNGWriter
+	delay    int
+}
+
+// animationFlags holds the -animate, -delay, -duration, and -loops flags.
+type animationFlags struct {
+	animate  bool
+	delay    int
+	duration float64
+	loops    int
+}
+
+// start starts an animation of frames frames saved to filename as the
+// flags ask.
+func (f animationFlags) start(p *mandel.Parameters, filename string, frames int) (*animation, error) {
+	return newAnimation(p, filename, frames, f.delay, f.duration, f.loops)
+}
+
+// newAnimation starts an animation of frames frames saved to filename,
+// with delay hundredths of a second between them, or duration seconds in
+// all if duration is not 0, that plays loops times, or forever if loops
+// is 0.
+func newAnimation(p *mandel.Parameters, filename string, frames, delay int, duration float64, loops int) (*animation, error) {
+	if duration > 0 {
+		delay = int(duration*100/float64(frames) + 0.5)
+	}
+	if delay < 1 {
+		delay = 1
+	}
+	if loops < 0 {
+		return nil, fmt.Errorf("Loop count must not be negative")
+	}
+	a := &animation{p: p, filename: filename, delay: delay}
+	switch imageFormat(filename) {
+	case "gif":
+		// GIF counts the repeats after the first showing, with 0 for
+		// forever and -1 for none
+		a.gif = &gif.GIF{LoopCount: loops - 1}
+		switch loops {
+		case 0:
+			a.gif.LoopCount = 0
+		case 1:
+			a.gif.LoopCount = -1
+		}
+		return a, nil
+	case "png":
+		fp, err := os.Create(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating file %s: %v", filename, err)
+		}
+		if a.apng, err = mandel.NewAPNGWriter(fp, p.SizeX, p.SizeY, frames, loops); err != nil {
+			fp.Close()
+			return nil, err
+		}
+		a.fp = fp
+		return a, nil
+	}
+	return nil, fmt.Errorf("Animations are saved as GIF or PNG, not %s", imageFormat(filename))
+}
+
+// addFrame adds the next frame to the animation.
+func (a *animation) addFrame(img *image.NRGBA) error {
+	if a.apng != nil {
+		return a.apng.WriteFrame(img, a.delay)
+	}
+	paletted, err := a.p.QuantizeImage(img)
+	if err != nil {
+		return err
+	}
+	a.gif.Image = append(a.gif.Image, paletted)
+	a.gif.Delay = append(a.gif.Delay, a.delay)
+	return nil
+}
+
+// close finishes the animation and saves it.
+func (a *animation) close() error {
+	if a.apng == nil {
+		return saveGIF(a.filename, a.gif)
+	}
+	err := a.apng.Close()
+	if err == nil {
+		err = a.fp.Close()
+	} else {
+		a.fp.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("Error encoding image: %v", err)
+	}
+	return nil
+}
+
+// saveGIF writes an animated GIF.
+func saveGIF(filename string, anim *gif.GIF) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %v", filename, err)
+	}
+	if err = gif.EncodeAll(fp, anim); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("Error encoding image: %v", err)
+	}
+	return nil
+}
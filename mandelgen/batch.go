@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/russross/mandel"
+)
+
+// runBatch renders every job in a JSON file holding an array of
+// parameter objects, each with a "filename" for its image. The options on
+// the command line are the starting point for every job, and each job
+// overrides them with its own fields. Jobs render one after another, each
+// using all of the rendering goroutines, and a job that fails is reported
+// without stopping the rest. With -cache, a job whose image is already in
+// the cache is copied from there instead of being rendered.
+func runBatch(base *mandel.Parameters, jobfile string) {
+	raw, err := ioutil.ReadFile(jobfile)
+	if err != nil {
+		log.Fatalf("Error reading job file %s: %v", jobfile, err)
+	}
+	var jobs []json.RawMessage
+	if err := json.Unmarshal(raw, &jobs); err != nil {
+		log.Fatalf("Error parsing job file %s: %v", jobfile, err)
+	}
+
+	// every job starts from its own copy of the base parameters, so no
+	// job sees the slices of another
+	defaults, err := json.Marshal(base)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := interruptContext()
+	cache := openDiskCache()
+	start := time.Now()
+	failed := 0
+	for n, job := range jobs {
+		label := fmt.Sprintf("job %d of %d", n+1, len(jobs))
+		filename, err := renderJob(ctx, cache, defaults, job, label)
+		if err != nil {
+			failed++
+			log.Printf("%s failed: %v", label, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		log.Printf("%s finished %s", label, filename)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d jobs failed", failed, len(jobs))
+	}
+	log.Printf("finished %d jobs in %v", len(jobs), time.Since(start).Round(time.Millisecond))
+}
+
+// renderJob renders one job from a batch, returning the name of the file
+// it saved.
+func renderJob(ctx context.Context, cache *mandel.DiskCache, defaults, job []byte, label string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(job, &fields); err != nil {
+		return "", err
+	}
+	var filename string
+	if err := json.Unmarshal(fields["filename"], &filename); err != nil || filename == "" {
+		return "", fmt.Errorf("no filename given")
+	}
+	p := new(mandel.Parameters)
+	if err := json.Unmarshal(defaults, p); err != nil {
+		return "", err
+	}
+
+	// a new center replaces a precise one from the command line
+	_, x := fields["x"]
+	_, y := fields["y"]
+	if x || y {
+		p.PreciseX, p.PreciseY = "", ""
+	}
+	if err := json.Unmarshal(job, p); err != nil {
+		return "", err
+	}
+	if err := p.Init(); err != nil {
+		return "", err
+	}
+	// the key covers the encoding as well as the parameters
+	key := p.CacheKey(fmt.Sprintf("batch %s %d", imageFormat(filename), encoding.quality))
+	if data, ok := cache.Get(key); ok {
+		if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+			return "", fmt.Errorf("Error writing file %s: %v", filename, err)
+		}
+		log.Printf("%s found in the cache", label)
+		return filename, nil
+	}
+	p.Progress = labeledProgressBar(os.Stderr, label)
+	canvas, err := p.GenerateImageContext(ctx)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	encoding.params = p
+	encoding.colors = p
+	if err := saveImage(filename, canvas); err != nil {
+		return "", err
+	}
+	if cache != nil {
+		data, err := ioutil.ReadFile(filename)
+		if err == nil {
+			err = cache.Put(key, data)
+		}
+		if err != nil {
+			log.Printf("%s not cached: %v", label, err)
+		}
+	}
+	return filename, nil
+}
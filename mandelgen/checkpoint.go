@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/russross/mandel"
+)
+
+// recoverCheckpoint saves the rows finished so far in a checkpoint file
+// from -resume, for a render that cannot be resumed.
+func recoverCheckpoint(checkpoint, filename string) {
+	if checkpoint == "" {
+		log.Fatalf("The recover command needs a checkpoint file given by -resume")
+	}
+	fp, err := os.Open(checkpoint)
+	if err != nil {
+		log.Fatalf("Error opening checkpoint %s: %v", checkpoint, err)
+	}
+	defer fp.Close()
+	img, rows, err := mandel.ReadCheckpoint(fp)
+	if err != nil {
+		log.Fatalf("Error reading checkpoint %s: %v", checkpoint, err)
+	}
+	if err := saveImage(filename, img); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("recovered %d of %d rows from %s into %s", rows, img.Bounds().Dy(), checkpoint, filename)
+}
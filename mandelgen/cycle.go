@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// cycle renders a palette cycling animation, turning the palette the
+// number of times given by -cycles over -frames frames. A .gif filename,
+// or any filename with -animate, gets one animated image, as saved by
+// animation; otherwise the frames are saved next to filename as
+// name0001.png, name0002.png, and so on, like the zoom command.
+func cycle(p *mandel.Parameters, filename string, frames int, cycles float64, anim animationFlags) {
+	if cycles == 0 {
+		cycles = 1
+	}
+	if err := p.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	var a *animation
+	if anim.animate || imageFormat(filename) == "gif" {
+		var err error
+		if a, err = anim.start(p, filename, frames); err != nil {
+			log.Fatal(err)
+		}
+	}
+	err := p.GeneratePaletteCycle(frames, cycles, func(n int, img *image.NRGBA) error {
+		if a != nil {
+			return a.addFrame(img)
+		}
+		name := fmt.Sprintf("%s%04d%s", base, n+1, ext)
+		log.Printf("frame %d of %d: %s", n+1, frames, name)
+		return saveImage(name, img)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if a != nil {
+		if err := a.close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("finished %d frames of %s: %g palette cycles", frames, filename, cycles)
+}
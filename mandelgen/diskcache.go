@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	"github.com/russross/mandel"
+)
+
+// disk cache settings from the command line
+var diskCache struct {
+	dir    string
+	sizeMB int
+	bypass bool
+	clear  bool
+}
+
+// openDiskCache opens the cache given by -cache, emptying it first with
+// -clearcache. It returns nil, which caches nothing, without -cache or
+// with -nocache.
+func openDiskCache() *mandel.DiskCache {
+	if diskCache.dir == "" {
+		return nil
+	}
+	c, err := mandel.OpenDiskCache(diskCache.dir, int64(diskCache.sizeMB)<<20)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if diskCache.clear {
+		if err := c.Clear(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("cleared the cache in %s", diskCache.dir)
+	}
+	if diskCache.bypass {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// image encoding settings from the command line
+var encoding struct {
+	format  string             // png, jpeg, gif, or tiff; blank to go by the file extension
+	quality int                // JPEG quality
+	colors  *mandel.Parameters // palette and quantizing settings for GIF files
+	params  *mandel.Parameters // saved in PNG files, if set
+}
+
+// imageFormat picks the format for a file: the -format flag if given,
+// or else the file extension, with PNG as the default.
+func imageFormat(filename string) string {
+	if encoding.format != "" {
+		return encoding.format
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".gif":
+		return "gif"
+	case ".tif", ".tiff":
+		return "tiff"
+	}
+	return "png"
+}
+
+// streamImage renders straight to a file with GenerateTo.
+func streamImage(p *mandel.Parameters, filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %v", filename, err)
+	}
+	if err = p.GenerateTo(fp, imageFormat(filename)); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("Error encoding image: %v", err)
+	}
+	return nil
+}
+
+// saveImage writes an image in the format chosen by imageFormat.
+func saveImage(filename string, img image.Image) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %v", filename, err)
+	}
+	defer fp.Close()
+	switch imageFormat(filename) {
+	case "jpeg":
+		err = jpeg.Encode(fp, img, &jpeg.Options{Quality: encoding.quality})
+	case "gif":
+		var paletted *image.Paletted
+		if paletted, err = quantizeGIF(img); err == nil {
+			err = gif.Encode(fp, paletted, nil)
+		}
+	case "tiff":
+		err = mandel.EncodeTIFF(fp, img)
+	default:
+		if encoding.params != nil {
+			err = encoding.params.EncodePNG(fp, img)
+		} else {
+			err = png.Encode(fp, img)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Error encoding image: %v", err)
+	}
+	return nil
+}
+
+// quantizeGIF maps an image onto the colors of -quantize with -dither for
+// a still GIF, the same way animation quantizes the frames of an animated
+// one.
+func quantizeGIF(img image.Image) (*image.Paletted, error) {
+	if paletted, ok := img.(*image.Paletted); ok {
+		return paletted, nil
+	}
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+
+	// only the coloring settings matter, so the view need not be valid
+	src := encoding.colors
+	q := &mandel.Parameters{
+		Magnification:   1,
+		MaxIterations:   1,
+		SizeX:           nrgba.Rect.Dx(),
+		SizeY:           nrgba.Rect.Dy(),
+		AntiAlias:       1,
+		Palette:         src.Palette,
+		InsideColor:     src.InsideColor,
+		Transparent:     src.Transparent,
+		QuantizePalette: src.QuantizePalette,
+		Dither:          src.Dither,
+	}
+	if err := q.Init(); err != nil {
+		return nil, err
+	}
+	return q.QuantizeImage(nrgba)
+}
+
+// readPNGParameters loads the parameters saved in a PNG file.
+func readPNGParameters(filename string) (*mandel.Parameters, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening %s: %v", filename, err)
+	}
+	defer fp.Close()
+	p, err := mandel.ReadPNGParameters(fp)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading parameters from %s: %v", filename, err)
+	}
+	return p, nil
+}
+
+// printInfo prints the parameters saved in a PNG file as JSON.
+func printInfo(filename string) {
+	p, err := readPNGParameters(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := p.Save(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
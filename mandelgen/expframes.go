@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// expFrames turns an exponential map strip saved by mandelgen -expmap
+// into the frames of a zoom into its center, resampling the strip instead
+// of rendering each frame. Frames are the size given by -px and -py with
+// -a×-a samples per pixel, and zoom steadily from the widest view whose
+// corners the strip covers to the deepest view it fills, saved next to
+// filename as name0001.png, name0002.png, and so on, like the zoom
+// command.
+func expFrames(frame *mandel.Parameters, stripfile, filename string, frames int) {
+	if stripfile == "" {
+		log.Fatalf("The expframes command needs an exponential map given by -strip")
+	}
+	if frames < 1 {
+		log.Fatalf("The expframes command needs at least one frame")
+	}
+	p, err := readPNGParameters(stripfile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fp, err := os.Open(stripfile)
+	if err != nil {
+		log.Fatalf("Error opening %s: %v", stripfile, err)
+	}
+	strip, err := png.Decode(fp)
+	fp.Close()
+	if err != nil {
+		log.Fatalf("Error decoding %s: %v", stripfile, err)
+	}
+	outer, inner, err := p.ExpMapZoomRange(frame.SizeX, frame.SizeY)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if inner < outer {
+		log.Fatalf("%s is too shallow to fill a %d×%d frame", stripfile, frame.SizeX, frame.SizeY)
+	}
+
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	var img image.Image
+	for n := 0; n < frames; n++ {
+		t := 0.0
+		if frames > 1 {
+			t = float64(n) / float64(frames-1)
+		}
+		mag := outer * math.Pow(inner/outer, t)
+		if img, err = p.ExpMapFrame(strip, mag, frame.SizeX, frame.SizeY, frame.AntiAlias); err != nil {
+			log.Fatal(err)
+		}
+		name := fmt.Sprintf("%s%04d%s", base, n+1, ext)
+		log.Printf("frame %d of %d: %s at magnification %.6g", n+1, frames, name, mag)
+		if err := saveImage(name, img); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("finished %d frames of %s from %s", frames, filename, stripfile)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/russross/mandel"
+)
+
+// candidates on a side that -explore scatters across the view
+const exploreCandidates = 64
+
+// exploreView prints the most interesting places to zoom into within the
+// view, best first, as flags that render them.
+func exploreView(p *mandel.Parameters, count int) {
+	targets, err := p.Explore(exploreCandidates, count)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(targets) == 0 {
+		log.Fatal("found nothing near the boundary of the set to zoom into")
+	}
+	for _, t := range targets {
+		fmt.Printf("-x %.17g -y %.17g -m %.6g # score %.3f\n", t.X, t.Y, t.Magnification, t.Score)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/russross/mandel"
+	"github.com/russross/mandel/farm"
+)
+
+// worker runs an HTTP server that renders bands of images for the farm
+// command.
+func worker(listen string, maxIterations int) {
+	http.Handle(farm.BandPath, farm.NewWorker(4096*4096, maxIterations))
+	log.Printf("rendering bands on http://%s%s", listen, farm.BandPath)
+	log.Fatal(http.ListenAndServe(listen, nil))
+}
+
+// farmRender renders the image on the workers listed in hosts, saving
+// finished bands to the checkpoint file from -resume so an interrupted
+// render can pick up where it left off. Without -resume the checkpoint
+// goes in a temporary file that is removed afterward.
+func farmRender(p *mandel.Parameters, filename, hosts, checkpoint string, retries int) {
+	if hosts == "" {
+		log.Fatalf("The farm command needs a list of workers given by -hosts")
+	}
+	coordinator, err := farm.NewCoordinator(strings.Split(hosts, ","), retries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := p.Init(); err != nil {
+		log.Fatal(err)
+	}
+	temporary := checkpoint == ""
+	if temporary {
+		fp, err := ioutil.TempFile("", "mandel-farm-*.ckpt")
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkpoint = fp.Name()
+		fp.Close()
+		os.Remove(checkpoint)
+	}
+	p.Progress = progressBar(os.Stderr)
+	canvas, err := coordinator.Generate(interruptContext(), p, checkpoint)
+	fmt.Fprintln(os.Stderr)
+	if temporary {
+		os.Remove(checkpoint)
+	}
+	if err != nil {
+		log.Fatalf("Render stopped: %v", err)
+	}
+	encoding.params = p
+	if err := saveImage(filename, canvas); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("finished %s: -x %s -y %s -m %.17g -i %d", filename, &coordinate{&p.CenterX, &p.PreciseX}, &coordinate{&p.CenterY, &p.PreciseY}, p.Magnification, p.MaxIterations)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"image"
+	"log"
+	"os"
+
+	"github.com/russross/mandel"
+)
+
+// computeField computes the escape values, saves them to fieldfile, and
+// colors them in the format of -output and -bits, so the same render can
+// be recolored later without iterating again.
+func computeField(p *mandel.Parameters, fieldfile string) image.Image {
+	f, err := p.ComputeIterations()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fp, err := os.Create(fieldfile)
+	if err != nil {
+		log.Fatalf("Error creating file %s: %v", fieldfile, err)
+	}
+	if err = f.WriteBinary(fp); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		log.Fatalf("Error saving escape values: %v", err)
+	}
+	img, err := f.ColorizeImage(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return img
+}
+
+// recolor colors the escape values saved by -savefield with the coloring
+// settings from the command line. Only the coloring settings and the
+// maximum iterations matter, so the rest of the parameters are only
+// checked against the field.
+func recolor(p *mandel.Parameters, fieldfile, filename string) {
+	if fieldfile == "" {
+		log.Fatalf("The recolor command needs a file of escape values given by -field")
+	}
+	if err := p.Init(); err != nil {
+		log.Fatal(err)
+	}
+	fp, err := os.Open(fieldfile)
+	if err != nil {
+		log.Fatalf("Error opening %s: %v", fieldfile, err)
+	}
+	f, err := mandel.ReadField(fp)
+	fp.Close()
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", fieldfile, err)
+	}
+	if f.Matches(p) {
+		// the parameters describe the image, so they can go in it
+		encoding.params = p
+	} else {
+		log.Printf("warning: %s was computed with different parameters; -params with the original image or settings will match it", fieldfile)
+	}
+	img, err := f.ColorizeImage(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := saveImage(filename, img); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("finished %s from %s", filename, fieldfile)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/russross/mandel"
+)
+
+// layerSpec is a layer as a -layers file gives it, with the palette named
+// the way -palette names it, as a file or a built-in palette.
+type layerSpec struct {
+	mandel.Layer
+	Palette string `json:"palette"`
+}
+
+// loadLayers reads a JSON array of layers, such as
+//
+//	[{"palette": "ice"},
+//	 {"source": "distance", "palette": "fire", "blend": "multiply", "opacity": 0.6},
+//	 {"palette": "ultra", "min": 50, "max": 200, "blend": "screen"}]
+func loadLayers(filename string) ([]mandel.Layer, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading layers file %s: %v", filename, err)
+	}
+	var specs []layerSpec
+	if err := json.Unmarshal(raw, &specs); err != nil {
+		return nil, fmt.Errorf("Error parsing layers file %s: %v", filename, err)
+	}
+	var layers []mandel.Layer
+	for _, spec := range specs {
+		layer := spec.Layer
+		if layer.Palette, err = loadPalette(spec.Palette); err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
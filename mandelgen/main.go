@@ -1,83 +1,606 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"image"
 	"image/color"
-	"image/png"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/russross/mandel"
+	"github.com/russross/mandel/palette"
 )
 
 func main() {
 	// use multiple CPUs if available
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	// an optional subcommand comes before the options
+	command := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	// parse options
 	p := new(mandel.Parameters)
-	var filename, palettefile string
+	var filename, palettefile, layersfile, paramsfile, saveparams string
+	var gradient, gradientmode string
+	var gradientsize int
+	var inside, ramp, background, duotone, contours, contourcolor, labelcolor, nebula, newton, transform, densitycolor string
+	var labels, overlap, retries, bits, buddhabrot, tilesize, cachetiles, prefetch, maxiterations, explore int
+	var pages, output, resume, listen, info, savefield, fieldfile, hosts, batch, strip string
+	var statsfile, iterationmap, timemap string
+	var stream, julia, anti bool
+	var seq mandel.ZoomSequence
+	var anim animationFlags
 
-	flag.Float64Var(&p.CenterX, "x", -0.75, "Center point of the image, real part")
-	flag.Float64Var(&p.CenterY, "y", 0.0, "Center point of the image, imaginary part")
+	p.CenterX = -0.75
+	flag.Var(&coordinate{&p.CenterX, &p.PreciseX}, "x", "Center point of the image, real part, with as many digits as a deep zoom needs")
+	flag.Var(&coordinate{&p.CenterY, &p.PreciseY}, "y", "Center point of the image, imaginary part, with as many digits as a deep zoom needs")
 	flag.Float64Var(&p.Magnification, "m", 0.4, "Magnification level")
-	flag.IntVar(&p.MaxIterations, "i", 1000, "Maximum iterations per point")
+	flag.IntVar(&p.MaxIterations, "i", 1000, "Maximum iterations per point (0 to choose from the magnification)")
+	flag.IntVar(&p.IterationProbes, "probes", 0, "With -i 0, refine the choice by iterating this many probe points on a side")
 	flag.IntVar(&p.SizeX, "px", 1024, "Horizontal size of the image in pixels")
 	flag.IntVar(&p.SizeY, "py", 768, "Vertical size of the image in pixels")
+	flag.IntVar(&p.Workers, "workers", 0, "Number of rendering goroutines (0 for one per CPU)")
+	flag.IntVar(&p.ChunkRows, "chunkrows", 1, "Rows handed to a rendering goroutine at a time")
+	flag.BoolVar(&stream, "stream", false, "Render and write a PNG a band of rows at a time, for images too large to hold in memory")
+	flag.IntVar(&p.BandRows, "bandrows", 64, "Rows held in memory at a time with -stream")
+	flag.StringVar(&resume, "resume", "", "Save finished rows to this checkpoint file, and resume from it if it exists")
+	flag.IntVar(&tilesize, "tilesize", 1024, "Width and height of each tile for the tiles command")
 	flag.IntVar(&p.AntiAlias, "a", 2, "Anti-aliasing level for smoother image (1 is off)")
+	flag.Float64Var(&p.SampleOffsetX, "sx", 0, "Shift all samples right by this fraction of a pixel")
+	flag.Float64Var(&p.SampleOffsetY, "sy", 0, "Shift all samples down by this fraction of a pixel")
+	flag.IntVar(&buddhabrot, "buddhabrot", 0, "Render the Buddhabrot from this many random points instead (0 for off)")
+	flag.IntVar(&p.BuddhabrotMin, "buddhabrotmin", 0, "Leave orbits that escape in fewer iterations out of the Buddhabrot")
+	flag.BoolVar(&anti, "anti", false, "With -buddhabrot, trace the orbits of the points that never escape instead, for the anti-Buddhabrot")
+	flag.IntVar(&p.DensityOverlay, "density", 0, "Overlay the anti-Buddhabrot traced from this many random points (0 for off)")
+	flag.StringVar(&p.DensityBlend, "densityblend", "screen", "Blending of the -density overlay: screen, add, multiply, or normal")
+	flag.Float64Var(&p.DensityOpacity, "densityopacity", 1, "Opacity of the -density overlay from 0 to 1")
+	flag.StringVar(&p.Shading, "shading", "none", "Light the outside of the set as a relief: none, lambert, or phong")
+	flag.Float64Var(&p.LightAngle, "lightangle", 45, "Direction of the -shading light in degrees counterclockwise from the right")
+	flag.Float64Var(&p.LightElevation, "lightelevation", 45, "Height of the -shading light in degrees above the image")
+	flag.StringVar(&densitycolor, "densitycolor", "#ffffff", "Color of the -density overlay as #rrggbb or #rrggbbaa")
+	flag.StringVar(&nebula, "nebula", "", "Render a Nebulabrot with -buddhabrot, using these red,green,blue iteration limits (e.g. 5000,500,50)")
+	flag.IntVar(&p.Passes, "passes", 1, "Average this many renders with jittered samples")
+	flag.Int64Var(&p.Seed, "seed", 0, "Seed for random sample jitter")
+	flag.BoolVar(&p.JitterAA, "jitter", false, "Jitter anti-aliasing samples within their grid cells")
+	flag.StringVar(&p.SamplePattern, "pattern", "grid", "Anti-aliasing sample pattern: grid, rotated, halton, or sobol")
+	flag.IntVar(&p.SampleCount, "samples", 0, "Anti-aliasing samples per pixel for -pattern halton or sobol (0 for the square of -a)")
+	flag.IntVar(&p.Supersample, "supersample", 1, "Render at this many times the size and shrink the result, on top of anti-aliasing")
+	flag.StringVar(&p.SupersampleFilter, "filter", "lanczos", "Filter for shrinking a supersampled render: lanczos, mitchell, or box")
+	flag.BoolVar(&p.InteriorTiles, "interiortiles", false, "Fill tiles whose edges are inside the set without iterating them")
+	flag.BoolVar(&p.DEMaskedAA, "demask", false, "Anti-alias only pixels near the boundary of the set")
+	flag.BoolVar(&p.AdaptiveAA, "adaptive", false, "Anti-alias only pixels whose color differs from a neighbor's")
+	flag.IntVar(&p.AdaptiveThreshold, "adaptivethreshold", 0, "Color difference per channel that triggers -adaptive (0 for the default)")
+	flag.BoolVar(&p.AAFastPath, "aafast", false, "Skip anti-aliasing for pixels whose center and corners agree")
+	flag.BoolVar(&p.Symmetry, "symmetry", false, "Mirror rows of views centered on the real axis instead of rendering both halves")
 	flag.BoolVar(&p.Continuous, "c", false, "Enable continuous color gradient")
+	flag.StringVar(&p.Detail, "detail", "", "Quality preset: draft, normal, high, or ultra (-i and -a override it)")
+	flag.BoolVar(&p.AutoContrast, "autocontrast", false, "Stretch the frame's range of escape values across the palette")
+	flag.BoolVar(&p.DepthNormalize, "depthnormalize", false, "Compensate colors for zoom depth so they hold steady through a zoom")
+	flag.Float64Var(&p.ColorClampMax, "clamp", 0, "Cap escape values used for coloring at this level (0 for no cap)")
+	flag.Float64Var(&p.OutputGamma, "gamma", 1, "Gamma correction applied to the output colors")
+	flag.IntVar(&p.Power, "power", 2, "Iterate z^power + c")
+	flag.StringVar(&p.InteriorColoring, "interior", "flat", "Interior coloring: flat, magnitude, average, or distance")
+	flag.StringVar(&p.Transparent, "transparent", "", "Leave the exterior or interior of the set transparent, for compositing (leave blank for neither)")
+	flag.StringVar(&p.Fractal, "fractal", "mandelbrot", "Fractal family: mandelbrot, burningship, tricorn, julia, or newton")
+	flag.StringVar(&newton, "newton", "", "Comma-separated polynomial coefficients, highest power first, for a Newton fractal (implies -fractal newton)")
+	flag.BoolVar(&julia, "julia", false, "Render the Julia set for -cx and -cy (same as -fractal julia)")
+	flag.Float64Var(&p.JuliaCX, "cx", 0, "Julia set constant, real part")
+	flag.Float64Var(&p.JuliaCY, "cy", 0, "Julia set constant, imaginary part")
+	flag.StringVar(&p.Formula, "formula", "", "Custom iteration formula in z and c, such as \"z*z*z + sin(z) + c\"")
+	flag.BoolVar(&p.CompensatedSum, "compensated", false, "Use compensated arithmetic for slightly deeper zooms")
+	flag.BoolVar(&p.ExactInterior, "exactinterior", false, "Iterate interior points fully instead of detecting them early")
+	flag.UintVar(&p.Precision, "precision", 0, "Mantissa bits for deep zoom reference orbits (0 for automatic)")
+	flag.BoolVar(&p.SmartIterations, "smart", false, "Limit iterations far from the boundary of the set")
+	flag.Float64Var(&p.Bailout, "bailout", 0, "Escape radius for both coloring modes (0 for 2, or the square root of -smoothbailout with -c)")
+	flag.Float64Var(&p.SmoothBailout, "smoothbailout", 256, "Squared escape radius for continuous coloring when -bailout is 0")
+	flag.Float64Var(&p.ExpMapAngleStart, "anglestart", 0, "Starting angle in degrees of an exponential map sector")
+	flag.Float64Var(&p.ExpMapAngleEnd, "angleend", 0, "Ending angle in degrees of an exponential map sector")
+	flag.Float64Var(&p.ExpMapRadiusStart, "radiusstart", 0, "Radius at the top edge of an exponential map (overrides -m and -octaves)")
+	flag.Float64Var(&p.ExpMapRadiusEnd, "radiusend", 0, "Radius at the bottom edge of an exponential map")
+	flag.StringVar(&p.BailoutShape, "bailoutshape", "circle", "Shape of the escape test: circle, square, cross, or rhombus")
+	flag.Float64Var(&p.MinX, "minx", 0, "Left edge of the view (with -maxx, -miny, and -maxy, overrides -x, -y, and -m)")
+	flag.Float64Var(&p.MaxX, "maxx", 0, "Right edge of the view")
+	flag.Float64Var(&p.MinY, "miny", 0, "Bottom edge of the view")
+	flag.Float64Var(&p.MaxY, "maxy", 0, "Top edge of the view")
+	flag.Float64Var(&p.Rotation, "rotate", 0, "Turn the image counterclockwise by this many degrees")
+	flag.StringVar(&transform, "transform", "", "Comma-separated 2x2 matrix, row by row, applied to the view before -rotate (e.g. 1,0.5,0,1 to shear)")
+	flag.BoolVar(&p.ExpMap, "expmap", false, "Render an exponential map of the zoom into the center point")
+	flag.StringVar(&strip, "strip", "", "Exponential map PNG for the expframes command to turn into zoom frames")
+	flag.Float64Var(&p.ExpMapOctaves, "octaves", 0, "Zoom octaves covered by an exponential map (0 for square pixels)")
+
+	flag.Float64Var(&p.PaletteOffset, "offset", 0, "Rotate the palette by this fraction of its length")
+	flag.StringVar(&p.Smoothing, "smoothing", "log", "Continuous escape value formula: log or linear")
+	flag.StringVar(&p.Interpolation, "interpolation", "rgb", "Color space for continuous gradients: rgb, hsv, or lab")
+	flag.BoolVar(&p.PerceptualPalette, "perceptual", false, "Resample the palette into perceptually even steps")
+	flag.BoolVar(&p.CurvatureColor, "curvature", false, "Color by how sharply each orbit turns on its last step")
+	flag.StringVar(&p.Coloring, "coloring", "palette", "Coloring method: palette, alpha-ramp, histogram, orbit-range, distance, orbittrap, stripe, or tia")
+	flag.Float64Var(&p.StripeDensity, "stripes", 5, "Stripes around the origin for stripe coloring")
+	flag.Var(flag.Lookup("coloring").Value, "color", "Same as -coloring")
+	flag.StringVar(&p.TrapShape, "trap", "point", "Orbit trap shape: point, circle, cross, line, real, or imaginary")
+	flag.Float64Var(&p.TrapX, "trapx", 0, "Orbit trap center, real part")
+	flag.Float64Var(&p.TrapY, "trapy", 0, "Orbit trap center, imaginary part")
+	flag.Float64Var(&p.TrapRadius, "trapradius", 0, "Orbit trap circle radius (0 means 1)")
+	flag.Float64Var(&p.TrapAngle, "trapangle", 0, "Orbit trap rotation in degrees")
+	flag.Float64Var(&p.TrapBlend, "trapblend", 0, "Blend of escape level into orbit trap coloring, 0 to 1")
+	flag.StringVar(&ramp, "ramp", "#ffffff", "Foreground color for alpha-ramp coloring")
+	flag.StringVar(&background, "background", "#000000", "Background color for alpha-ramp coloring")
+	flag.StringVar(&duotone, "duotone", "", "Two comma-separated colors to shade between instead of using the palette")
+	flag.StringVar(&inside, "inside", "#000000", "Color of points inside the set as #rrggbb or #rrggbbaa")
+	flag.StringVar(&contours, "contours", "", "Comma-separated iteration levels to outline")
+	flag.StringVar(&contourcolor, "contourcolor", "#ffffff60", "Color of contour lines as #rrggbb or #rrggbbaa")
+	flag.IntVar(&labels, "labels", 0, "Label cardioid bulbs with rotation numbers up to this denominator")
+	flag.StringVar(&labelcolor, "labelcolor", "#ffffff", "Color of bulb labels")
+
+	flag.StringVar(&p.CropShape, "crop", "none", "Crop the image to a shape: none, circle, or ellipse")
 
+	flag.StringVar(&pages, "pages", "2x2", "Poster layout as columns x rows")
+	flag.IntVar(&overlap, "overlap", 32, "Poster page overlap in pixels, marked with crop marks")
+	flag.StringVar(&listen, "listen", "localhost:8080", "Address for the serve or worker command to listen on")
+	flag.IntVar(&cachetiles, "cachetiles", 4096, "Tiles the serve command keeps in memory")
+	flag.StringVar(&diskCache.dir, "cache", "", "Directory where the serve and batch commands keep rendered images to reuse for identical requests")
+	flag.IntVar(&diskCache.sizeMB, "cachesize", 1024, "Most megabytes the -cache directory holds before the least recently used images are removed (0 for no limit)")
+	flag.BoolVar(&diskCache.bypass, "nocache", false, "Neither read nor write the -cache directory")
+	flag.BoolVar(&diskCache.clear, "clearcache", false, "Empty the -cache directory before starting")
+	flag.IntVar(&prefetch, "prefetch", 2, "CPUs the serve command uses to render neighboring tiles ahead of time")
+	flag.IntVar(&maxiterations, "maxiterations", 100000, "Most iterations a request to the serve or worker command may ask for")
+	flag.StringVar(&hosts, "hosts", "", "Comma-separated host:port addresses of workers for the farm command")
+	flag.IntVar(&retries, "retries", 3, "Times the farm command retries a failed band on another worker")
+
+	flag.Float64Var(&seq.EndX, "tox", -0.75, "Zoom sequence end point, real part")
+	flag.Float64Var(&seq.EndY, "toy", 0.0, "Zoom sequence end point, imaginary part")
+	flag.Float64Var(&seq.EndMagnification, "tom", 100, "Zoom sequence end magnification")
+	flag.IntVar(&seq.Frames, "frames", 100, "Frames in a zoom sequence, palette cycle, or expframes zoom")
+	flag.StringVar(&seq.Easing, "easing", "exponential", "Zoom sequence easing: exponential or linear")
+	flag.Float64Var(&seq.PaletteCycles, "cycles", 0, "Times to rotate the palette over a zoom sequence or palette cycle (default 1 for a palette cycle)")
+	flag.BoolVar(&anim.animate, "animate", false, "Save a zoom sequence or palette cycle as one animated GIF or APNG, by the file name, instead of numbered frames")
+	flag.IntVar(&anim.delay, "delay", 4, "Delay between frames of an animation, in hundredths of a second")
+	flag.Float64Var(&anim.duration, "duration", 0, "Length of an animation in seconds, overriding -delay (0 to go by -delay)")
+	flag.IntVar(&anim.loops, "loops", 0, "Times an animation plays (0 to loop forever)")
+
+	flag.StringVar(&output, "output", "nrgba8", "Output format: nrgba8, nrgba64, gray8, gray16, alpha, or paletted")
+	flag.StringVar(&p.QuantizePalette, "quantize", "render", "Colors for paletted output: render, websafe, or plan9")
+	flag.StringVar(&p.Dither, "dither", "floyd-steinberg", "Dithering for paletted output: floyd-steinberg, ordered, or none")
+	flag.IntVar(&bits, "bits", 8, "Bits per channel, 8 or 16, for color and gray output")
 	flag.StringVar(&filename, "o", "mandelbrot.png", "Output file name")
-	flag.StringVar(&palettefile, "palette", "", "Palette JSON file (leave blank for default)")
+	flag.StringVar(&encoding.format, "format", "", "Image file format: png, jpeg, gif, or tiff (leave blank to go by the file name)")
+	flag.IntVar(&encoding.quality, "quality", 90, "JPEG quality from 1 to 100")
+	flag.StringVar(&palettefile, "palette", "", "Palette file (.json, .ggr, .gpl, .map, or .ugr) or built-in palette name: fire, ice, ultra, grayscale, or rainbow (leave blank for default)")
+	flag.StringVar(&gradient, "gradient", "", "Build the palette from comma-separated colors, such as \"black,#1e90ff,white,orange\"")
+	flag.IntVar(&gradientsize, "gradientsize", 256, "Number of colors in a -gradient palette")
+	flag.StringVar(&gradientmode, "gradientmode", "rgb", "Interpolation between -gradient colors: rgb, spline, hsv, or lab")
+	flag.IntVar(&explore, "explore", 0, "Search the view for this many interesting places to zoom into, print their coordinates, and exit")
+	flag.StringVar(&batch, "batch", "", "Render every job in this JSON array of parameters, each with a \"filename\" for its image; other flags set the defaults")
+	flag.StringVar(&layersfile, "layers", "", "JSON file of layers that color the image in place of the palette, each with a source (escape, distance, or orbittrap), palette, min and max escape values, blend (normal, multiply, screen, or add), and opacity")
+	flag.StringVar(&paramsfile, "params", "", "Load all parameters from a JSON file or a PNG saved by mandelgen; other flags given override it")
+	flag.StringVar(&saveparams, "saveparams", "", "Save the parameters used to a JSON file")
+	flag.StringVar(&info, "info", "", "Print the parameters saved in a PNG file and exit")
+	flag.StringVar(&savefield, "savefield", "", "Also save the raw escape values to this file for the recolor command")
+	flag.StringVar(&statsfile, "stats", "", "Save a JSON report of the render's timings, iterations, shortcuts, and pixels to this file")
+	flag.StringVar(&iterationmap, "iterationmap", "", "Also save a grayscale map of the iterations every pixel of the render ran to this file")
+	flag.StringVar(&timemap, "timemap", "", "Also save a grayscale map of the time every pixel of the render took to this file")
+	flag.StringVar(&fieldfile, "field", "", "Escape values saved by -savefield for the recolor command to color")
 	flag.Parse()
+	if info != "" {
+		printInfo(info)
+		return
+	}
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// a parameters file replaces the defaults, but not flags given
+	// explicitly, so parse them again over it
+	if strings.ToLower(filepath.Ext(paramsfile)) == ".png" {
+		saved, err := readPNGParameters(paramsfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*p = *saved
+		flag.Parse()
+	} else if paramsfile != "" {
+		raw, err := ioutil.ReadFile(paramsfile)
+		if err != nil {
+			log.Fatalf("Error reading parameters file %s: %v", paramsfile, err)
+		}
+		if err = json.Unmarshal(raw, p); err != nil {
+			log.Fatalf("Error parsing parameters JSON data: %v", err)
+		}
+		flag.Parse()
+	}
+	use := func(name string) bool { return paramsfile == "" || set[name] }
 
-	if p.AntiAlias < 1 {
+	// let the detail level choose anything not given explicitly
+	if p.Detail != "" && paramsfile == "" {
+		if !set["i"] {
+			p.MaxIterations = 0
+		}
+		if !set["a"] {
+			p.AntiAlias = 0
+		}
+	}
+
+	if julia {
+		p.Fractal = "julia"
+	}
+	if newton != "" {
+		p.Fractal, p.NewtonCoefficients = "newton", nil
+		for _, s := range strings.Split(newton, ",") {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				log.Fatalf("Invalid Newton coefficient %q", s)
+			}
+			p.NewtonCoefficients = append(p.NewtonCoefficients, n)
+		}
+	}
+	if transform != "" {
+		p.Transform = nil
+		for _, s := range strings.Split(transform, ",") {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				log.Fatalf("Invalid transform entry %q", s)
+			}
+			p.Transform = append(p.Transform, n)
+		}
+	}
+
+	if p.Detail == "" && p.AntiAlias < 1 {
 		log.Fatalf("Anti-aliasing level must be 1 or higher")
 	}
-	p.Palette = loadPalette(palettefile)
+	var err error
+	if use("palette") || len(p.Palette) == 0 {
+		if p.Palette, err = loadPalette(palettefile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if layersfile != "" {
+		if p.Layers, err = loadLayers(layersfile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if gradient != "" {
+		var stops []color.NRGBA
+		for _, s := range strings.Split(gradient, ",") {
+			c, err := parseColor(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatal(err)
+			}
+			stops = append(stops, c)
+		}
+		if p.Palette, err = mandel.Gradient(stops, gradientsize, gradientmode); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if use("inside") {
+		if p.InsideColor, err = parseColor(inside); err != nil {
+			log.Fatal(err)
+		}
+		if p.InsideColor.A == 0 && p.Transparent == "" {
+			p.Transparent = "interior"
+		}
+	}
+	if use("ramp") {
+		if p.RampColor, err = parseColor(ramp); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if use("background") {
+		if p.BackgroundColor, err = parseColor(background); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if duotone != "" {
+		colors := strings.Split(duotone, ",")
+		if len(colors) != 2 {
+			log.Fatalf("Duotone needs two colors separated by a comma")
+		}
+		for i, s := range colors {
+			if p.Duotone[i], err = parseColor(strings.TrimSpace(s)); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	var limits [3]int
+	if nebula != "" {
+		fields := strings.Split(nebula, ",")
+		if len(fields) != 3 {
+			log.Fatalf("Nebulabrot needs three iteration limits separated by commas")
+		}
+		for i, s := range fields {
+			if limits[i], err = strconv.Atoi(strings.TrimSpace(s)); err != nil || limits[i] < 0 {
+				log.Fatalf("Invalid Nebulabrot iteration limit %q", s)
+			}
+		}
+	}
+	if labels > 0 {
+		c, err := parseColor(labelcolor)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.LabelBulbs(labels, c)
+	}
+	if contours != "" {
+		for _, level := range strings.Split(contours, ",") {
+			n, err := strconv.ParseFloat(strings.TrimSpace(level), 64)
+			if err != nil {
+				log.Fatalf("Invalid contour level %q", level)
+			}
+			p.Contours = append(p.Contours, n)
+		}
+	}
+	if use("densitycolor") {
+		if p.DensityColor, err = parseColor(densitycolor); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if use("contourcolor") {
+		if p.ContourColor, err = parseColor(contourcolor); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if use("output") {
+		if p.Output, err = mandel.ParseOutputSpec(output); err != nil {
+			log.Fatal(err)
+		}
+	}
+	switch {
+	case bits == 8:
+	case bits == 16 && p.Output == mandel.NRGBA8:
+		p.Output = mandel.NRGBA64
+	case bits == 16 && p.Output == mandel.Gray8:
+		p.Output = mandel.Gray16
+	case bits == 16 && p.Output.BitDepth == 16:
+	default:
+		log.Fatalf("Unsupported bit depth %d for %s output", bits, output)
+	}
+	switch encoding.format {
+	case "", "png", "jpeg", "gif", "tiff":
+	default:
+		log.Fatalf("Unknown image format %q", encoding.format)
+	}
+	if p.Transparent != "" && imageFormat(filename) == "jpeg" {
+		log.Fatalf("JPEG images cannot hold the transparency -transparent asks for")
+	}
+	encoding.colors = p
+
+	if saveparams != "" {
+		// save the parameters as Init completes them, with the iterations
+		// and anti-aliasing that a detail level or automatic iterations
+		// choose, rather than the raw flags
+		q := *p
+		if err := q.Init(); err != nil {
+			log.Fatal(err)
+		}
+		if err := saveParams(&q, saveparams); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch command {
+	case "":
+	case "repl":
+		repl(p, filename)
+		return
+	case "nucleus":
+		nucleus(p)
+		return
+	case "poster":
+		poster(p, filename, pages, overlap)
+		return
+	case "zoom":
+		zoom(p, filename, seq, anim)
+		return
+	case "expframes":
+		expFrames(p, strip, filename, seq.Frames)
+		return
+	case "cycle":
+		cycle(p, filename, seq.Frames, seq.PaletteCycles, anim)
+		return
+	case "tiles":
+		tiles(p, filename, tilesize)
+		return
+	case "serve":
+		serve(p, listen, cachetiles, prefetch, maxiterations)
+		return
+	case "worker":
+		worker(listen, maxiterations)
+		return
+	case "farm":
+		farmRender(p, filename, hosts, resume, retries)
+		return
+	case "recolor":
+		recolor(p, fieldfile, filename)
+		return
+	case "recover":
+		recoverCheckpoint(resume, filename)
+		return
+	case "selftest":
+		selftest()
+		return
+	case "golden":
+		writeGolden(filename)
+		return
+	default:
+		log.Fatalf("Unknown command %q", command)
+	}
 
+	if batch != "" {
+		runBatch(p, batch)
+		return
+	}
+	autoIters := p.MaxIterations == 0
 	if err := p.Init(); err != nil {
 		log.Fatal(err)
 	}
-	canvas := p.Generate()
+	if autoIters {
+		log.Printf("chose %d iterations for magnification %.6g", p.MaxIterations, p.Magnification)
+	}
+	if explore > 0 {
+		exploreView(p, explore)
+		return
+	}
+	p.Progress = progressBar(os.Stderr)
+	var report *mandel.RenderReport
+	p.Report = func(r *mandel.RenderReport) { report = r }
+	p.ReportPixels = statsfile != "" || iterationmap != "" || timemap != ""
+	if stream {
+		if err := streamImage(p, filename); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(os.Stderr)
+		log.Printf("finished %s: -x %s -y %s -m %.17g -i %d", filename, &coordinate{&p.CenterX, &p.PreciseX}, &coordinate{&p.CenterY, &p.PreciseY}, p.Magnification, p.MaxIterations)
+		saveReport(report, statsfile)
+		savePixelMaps(report, iterationmap, timemap)
+		return
+	}
+
+	var canvas image.Image
+	if buddhabrot > 0 && nebula != "" {
+		var err error
+		if canvas, err = p.GenerateNebulabrot(buddhabrot, limits); err != nil {
+			log.Fatal(err)
+		}
+	} else if buddhabrot > 0 && anti {
+		var err error
+		if canvas, err = p.GenerateAntiBuddhabrot(buddhabrot); err != nil {
+			log.Fatal(err)
+		}
+	} else if buddhabrot > 0 {
+		var err error
+		if canvas, err = p.GenerateBuddhabrot(buddhabrot); err != nil {
+			log.Fatal(err)
+		}
+	} else if savefield != "" {
+		canvas = computeField(p, savefield)
+	} else if resume != "" {
+		var err error
+		if canvas, err = p.GenerateCheckpointed(interruptContext(), resume); err != nil {
+			fmt.Fprintln(os.Stderr)
+			log.Fatalf("Render stopped: %v", err)
+		}
+	} else {
+		var err error
+		if canvas, err = p.GenerateImageContext(interruptContext()); err != nil {
+			fmt.Fprintln(os.Stderr)
+			log.Fatalf("Render stopped: %v", err)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
 
 	// save the image
-	fp, err := os.Create(filename)
+	encoding.params = p
+	if err := saveImage(filename, canvas); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("finished %s: -x %s -y %s -m %.17g -i %d", filename, &coordinate{&p.CenterX, &p.PreciseX}, &coordinate{&p.CenterY, &p.PreciseY}, p.Magnification, p.MaxIterations)
+	saveReport(report, statsfile)
+	savePixelMaps(report, iterationmap, timemap)
+}
+
+// interruptContext returns a context that is cancelled by the first
+// Ctrl-C. A second Ctrl-C kills the program as usual.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+	}()
+	return ctx
+}
+
+func loadPalette(filename string) ([]color.NRGBA, error) {
+	if filename == "" {
+		return mandel.DefaultPalette(), nil
+	} else if _, err := os.Stat(filename); os.IsNotExist(err) && filepath.Ext(filename) == "" {
+		// a bare name with no such file is a built-in palette
+		return mandel.NamedPalette(filename)
+	}
+	colors, err := palette.Load(filename)
 	if err != nil {
-		log.Fatalf("Error creating file %s: %v", filename, err)
+		return nil, fmt.Errorf("Error reading palette file %s: %v", filename, err)
 	}
-	defer fp.Close()
-	if err = png.Encode(fp, canvas); err != nil {
-		log.Fatalf("Error encoding image: %v", err)
+	return colors, nil
+}
+
+// coordinate is a flag for one coordinate of the center. Values with more
+// digits than the shortest decimal form of the nearest float64 are kept in
+// full as a precise center too.
+type coordinate struct {
+	value   *float64
+	precise *string
+}
+
+func (c *coordinate) String() string {
+	if c.value == nil {
+		return ""
+	}
+	if *c.precise != "" {
+		return *c.precise
 	}
-	log.Printf("finished")
+	return strconv.FormatFloat(*c.value, 'g', 17, 64)
 }
 
-func loadPalette(filename string) []color.NRGBA {
-	var palette []color.NRGBA
-	var colors [][]uint8
-	if filename == "" {
-		colors = defaultColors
-	} else {
-		raw, err := ioutil.ReadFile(filename)
-		if err != nil {
-			log.Fatalf("Error reading palette file %s: %v", filename, err)
-		}
-		if err = json.Unmarshal(raw, &colors); err != nil {
-			log.Fatalf("Error parsing palette JSON data: %v", err)
-		}
-		if len(colors) < 1 {
-			log.Fatalf("Palette must have at least color")
-		}
+func (c *coordinate) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
 	}
-	for _, c := range colors {
-		if len(c) != 4 {
-			log.Fatalf("Error in palette file: each color must have exactly 4 elements: red, green, blue, and alpha: found %v", c)
-		}
-		palette = append(palette, color.NRGBA{c[0], c[1], c[2], c[3]})
+	full, _, err := big.ParseFloat(s, 10, 1024, big.ToNearestEven)
+	if err != nil {
+		return err
+	}
+	short, _, _ := big.ParseFloat(strconv.FormatFloat(v, 'g', -1, 64), 10, 1024, big.ToNearestEven)
+	*c.value, *c.precise = v, ""
+	if full.Cmp(short) != 0 {
+		*c.precise = s
+	}
+	return nil
+}
+
+// colors that can be given by name instead of #rrggbb
+var colorNames = map[string]color.NRGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"red":     {255, 0, 0, 255},
+	"orange":  {255, 165, 0, 255},
+	"yellow":  {255, 255, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"blue":    {0, 0, 255, 255},
+	"navy":    {0, 0, 128, 255},
+	"purple":  {128, 0, 128, 255},
+	"magenta": {255, 0, 255, 255},
+}
+
+// parseColor reads a color written as #rrggbb, #rrggbbaa, or a basic
+// color name such as black or orange.
+func parseColor(s string) (color.NRGBA, error) {
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("Invalid color %q: expected #rrggbb, #rrggbbaa, or a color name", s)
 	}
-	return palette
+	return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
 }
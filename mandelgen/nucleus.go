@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/russross/mandel"
+)
+
+// nucleus prints the center and period of the minibrot nearest the center
+// of the view, searching within the visible area.
+func nucleus(p *mandel.Parameters) {
+	n, err := mandel.FindNucleus(p.CenterX, p.CenterY, 0.5/math.Abs(p.Magnification), p.MaxIterations, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// print enough digits to pin down the nucleus at this precision
+	digits := int(float64(n.X.Prec())*math.Log10(2)) + 1
+	fmt.Printf("period %d\n", n.Period)
+	fmt.Printf("-x %s -y %s\n", n.X.Text('g', digits), n.Y.Text('g', digits))
+}
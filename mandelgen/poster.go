@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// poster renders the image and slices it into a grid of pages for
+// printing, given as columns x rows. Each page extends overlap pixels past
+// its share of the image on every side, with crop marks at the corners of
+// the share so the pages can be trimmed and butted together. Pages are
+// saved next to filename as name-row-col.png.
+func poster(p *mandel.Parameters, filename, pages string, overlap int) {
+	var cols, rows int
+	if _, err := fmt.Sscanf(pages, "%dx%d", &cols, &rows); err != nil || cols < 1 || rows < 1 {
+		log.Fatalf("Invalid page layout %q: expected columns x rows, such as 3x2", pages)
+	}
+	if overlap < 0 {
+		log.Fatalf("Page overlap must not be negative")
+	}
+	if err := p.Init(); err != nil {
+		log.Fatal(err)
+	}
+	canvas, err := p.Generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			trim := image.Rect(col*p.SizeX/cols, row*p.SizeY/rows, (col+1)*p.SizeX/cols, (row+1)*p.SizeY/rows)
+			bounds := trim.Inset(-overlap).Intersect(canvas.Rect)
+			page := image.NewNRGBA(bounds)
+			draw.Draw(page, bounds, canvas, bounds.Min, draw.Src)
+			drawCropMarks(page, trim, overlap)
+
+			name := fmt.Sprintf("%s-%d-%d%s", base, row+1, col+1, ext)
+			if err := saveImage(name, page); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	log.Printf("finished %d pages of %s: -x %.17g -y %.17g -m %.17g -i %d", rows*cols, filename, p.CenterX, p.CenterY, p.Magnification, p.MaxIterations)
+}
+
+// drawCropMarks draws short lines in the margin around trim, lined up
+// with its edges, leaving a small gap at each corner.
+func drawCropMarks(page *image.NRGBA, trim image.Rectangle, length int) {
+	const gap = 2
+	mark := color.NRGBA{255, 255, 255, 255}
+	corners := []struct{ x, y, dx, dy int }{
+		{trim.Min.X, trim.Min.Y, -1, -1},
+		{trim.Max.X - 1, trim.Min.Y, 1, -1},
+		{trim.Min.X, trim.Max.Y - 1, -1, 1},
+		{trim.Max.X - 1, trim.Max.Y - 1, 1, 1},
+	}
+	for _, c := range corners {
+		for i := gap + 1; i <= length; i++ {
+			// one line outward along each edge through the corner
+			for _, pt := range []image.Point{{c.x + c.dx*i, c.y}, {c.x, c.y + c.dy*i}} {
+				if pt.In(page.Rect) {
+					page.SetNRGBA(pt.X, pt.Y, mark)
+				}
+			}
+		}
+	}
+}
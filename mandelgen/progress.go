@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// width of the progress bar in characters
+const barWidth = 40
+
+// progressBar returns a Progress callback that draws a bar with the
+// percentage done and an estimate of the time left, redrawing it in place
+// whenever the percentage changes and at least once a second.
+func progressBar(w io.Writer) func(rowsDone, rowsTotal int) {
+	return labeledProgressBar(w, "")
+}
+
+// labeledProgressBar is progressBar with a label in front of the bar.
+func labeledProgressBar(w io.Writer, label string) func(rowsDone, rowsTotal int) {
+	if label != "" {
+		label += " "
+	}
+	start := time.Now()
+	var drawn time.Time
+	last := -1
+	return func(rowsDone, rowsTotal int) {
+		percent := 100 * rowsDone / rowsTotal
+		if percent == last && time.Since(drawn) < time.Second {
+			return
+		}
+		last, drawn = percent, time.Now()
+
+		filled := barWidth * rowsDone / rowsTotal
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+		eta := "--"
+		if rowsDone > 0 {
+			elapsed := time.Since(start)
+			left := time.Duration(float64(elapsed) * float64(rowsTotal-rowsDone) / float64(rowsDone))
+			eta = left.Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "\r%s[%s] %3d%% ETA %-10s", label, bar, percent, eta)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+const (
+	previewFile  = "preview.png"
+	previewWidth = 256
+
+	// width of the preview drawn in the terminal, in characters
+	terminalWidth = 80
+)
+
+const replHelp = `Commands:
+  zoom N                multiply the magnification by N
+  left, right, up, down [N]
+                        pan by N quarter-views (default 1)
+  center X Y            move the center point
+  iter N                set the maximum iterations
+  aa N                  set the anti-aliasing level for saved images
+  continuous on|off     toggle continuous coloring
+  palette FILE          load a palette file (blank for default)
+  size W H              set the size of saved images
+  view on|off           also draw the preview in the terminal
+  show                  print the current parameters
+  params FILE           save the current parameters as JSON
+  save [FILE]           render the full-size image
+  h j k l + -           pan left, down, up, or right, or zoom in or out
+                        by 2; a line of these runs each one in turn
+  help                  print this message
+  quit                  leave the repl
+`
+
+// repl renders a small preview after every change so the parameters
+// can be explored interactively, then saves full-size images on request.
+func repl(p *mandel.Parameters, filename string) {
+	view := false
+	render := func() {
+		img, err := renderPreview(p)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if view {
+			drawTerminal(os.Stdout, img)
+		}
+		fmt.Printf("wrote %s\n", previewFile)
+	}
+	render()
+
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !in.Scan() {
+			fmt.Println()
+			return
+		}
+		fields := strings.Fields(in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		cmd, args := fields[0], fields[1:]
+		if len(fields) == 1 && strings.Trim(cmd, "hjkl+-") == "" {
+			for _, key := range cmd {
+				moveKey(p, key)
+			}
+			render()
+			continue
+		}
+
+		// parse numeric arguments up front
+		nums := make([]float64, len(args))
+		bad := false
+		for i, arg := range args {
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				bad = true
+				break
+			}
+			nums[i] = n
+		}
+		needs := func(count int) bool {
+			if bad || len(nums) != count {
+				fmt.Printf("%s expects %d numeric argument(s)\n", cmd, count)
+				return false
+			}
+			return true
+		}
+
+		// pan by quarters of the smaller view dimension
+		step := 0.25 / math.Abs(p.Magnification)
+		if !bad && len(nums) == 1 {
+			step *= nums[0]
+		}
+
+		switch cmd {
+		case "zoom":
+			if !needs(1) {
+				continue
+			}
+			if nums[0] <= 0 {
+				fmt.Println("zoom factor must be positive")
+				continue
+			}
+			p.Magnification *= nums[0]
+		case "left":
+			p.CenterX -= math.Copysign(step, p.Magnification)
+			p.PreciseX, p.PreciseY = "", ""
+		case "right":
+			p.CenterX += math.Copysign(step, p.Magnification)
+			p.PreciseX, p.PreciseY = "", ""
+		case "up":
+			p.CenterY += step
+			p.PreciseX, p.PreciseY = "", ""
+		case "down":
+			p.CenterY -= step
+			p.PreciseX, p.PreciseY = "", ""
+		case "center":
+			if !needs(2) {
+				continue
+			}
+			p.CenterX, p.CenterY = nums[0], nums[1]
+			p.PreciseX, p.PreciseY = "", ""
+		case "iter":
+			if !needs(1) {
+				continue
+			}
+			p.MaxIterations = int(nums[0])
+		case "aa":
+			if !needs(1) {
+				continue
+			}
+			p.AntiAlias = int(nums[0])
+			continue
+		case "continuous":
+			if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+				fmt.Println("continuous expects on or off")
+				continue
+			}
+			p.Continuous = args[0] == "on"
+		case "palette":
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			palette, err := loadPalette(name)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			p.Palette = palette
+		case "size":
+			if !needs(2) {
+				continue
+			}
+			p.SizeX, p.SizeY = int(nums[0]), int(nums[1])
+		case "view":
+			if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+				fmt.Println("view expects on or off")
+				continue
+			}
+			view = args[0] == "on"
+		case "params":
+			if len(args) != 1 {
+				fmt.Println("params expects a file name")
+				continue
+			}
+			if err := saveParams(p, args[0]); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("wrote %s\n", args[0])
+			continue
+		case "show":
+			fmt.Printf("-x %.17g -y %.17g -m %.17g -i %d -px %d -py %d -a %d -c=%v\n",
+				p.CenterX, p.CenterY, p.Magnification, p.MaxIterations,
+				p.SizeX, p.SizeY, p.AntiAlias, p.Continuous)
+			continue
+		case "save":
+			name := filename
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err := p.Init(); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			img, err := p.Generate()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			if err := saveImage(name, img); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("wrote %s\n", name)
+			continue
+		case "help":
+			fmt.Print(replHelp)
+			continue
+		case "quit", "exit":
+			return
+		default:
+			fmt.Printf("unknown command %q; try help\n", cmd)
+			continue
+		}
+		render()
+	}
+}
+
+// moveKey pans by a quarter view or zooms by a factor of 2 for one of the
+// keys h, j, k, l, +, and -.
+func moveKey(p *mandel.Parameters, key rune) {
+	step := 0.25 / math.Abs(p.Magnification)
+	switch key {
+	case 'h':
+		p.CenterX -= math.Copysign(step, p.Magnification)
+	case 'l':
+		p.CenterX += math.Copysign(step, p.Magnification)
+	case 'k':
+		p.CenterY += step
+	case 'j':
+		p.CenterY -= step
+	case '+':
+		p.Magnification *= 2
+		return
+	case '-':
+		p.Magnification /= 2
+		return
+	}
+	p.PreciseX, p.PreciseY = "", ""
+}
+
+// saveParams writes the parameters as JSON, as -saveparams does.
+func saveParams(p *mandel.Parameters, filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %v", filename, err)
+	}
+	if err = p.Save(fp); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("Error saving parameters: %v", err)
+	}
+	return nil
+}
+
+// drawTerminal draws an image terminalWidth characters wide with 24-bit
+// color escape codes, two pixels to a character: the upper half block
+// takes the top pixel as its color and the bottom pixel as its background.
+func drawTerminal(w io.Writer, img *image.NRGBA) {
+	b := img.Bounds()
+	cols := terminalWidth
+	if b.Dx() < cols {
+		cols = b.Dx()
+	}
+	// terminal characters are about twice as tall as they are wide
+	rows := b.Dy() * cols / b.Dx() / 2
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	at := func(col, row int) color.NRGBA {
+		return img.NRGBAAt(b.Min.X+col*b.Dx()/cols, b.Min.Y+row*b.Dy()/(2*rows))
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			top, bottom := at(col, 2*row), at(col, 2*row+1)
+			fmt.Fprintf(out, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm\u2580", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		fmt.Fprint(out, "\x1b[0m\n")
+	}
+}
+
+// renderPreview draws a reduced-size, non-anti-aliased copy of p, saves
+// it to previewFile, and returns it.
+func renderPreview(p *mandel.Parameters) (*image.NRGBA, error) {
+	preview := *p
+	preview.AntiAlias = 1
+	if preview.SizeX > previewWidth {
+		preview.SizeY = preview.SizeY * previewWidth / preview.SizeX
+		preview.SizeX = previewWidth
+	}
+	if err := preview.Init(); err != nil {
+		return nil, err
+	}
+	img, err := preview.Generate()
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Create(previewFile)
+	if err != nil {
+		return nil, err
+	}
+	if err = png.Encode(fp, img); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	return img, err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+
+	"github.com/russross/mandel"
+	"github.com/russross/mandel/mandeltest"
+)
+
+// selftests are small fixed renders with the SHA-256 of their pixels,
+// rendered with the default palette and an opaque black interior.
+var selftests = []struct {
+	name string
+	p    mandel.Parameters
+	hash string
+}{
+	{
+		name: "whole set",
+		p:    mandel.Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 1000, SizeX: 48, SizeY: 32, AntiAlias: 2},
+		hash: "f1c8896ae93d0ea14eb05b2eee3c239a88b5f98e549445cc1d10cff23ec3540c",
+	},
+	{
+		name: "continuous seahorse valley",
+		p:    mandel.Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 300, MaxIterations: 2000, SizeX: 48, SizeY: 32, AntiAlias: 3, Continuous: true},
+		hash: "7b7cd76d5269018e1710bef7b8a154bf3f309289d11a093910737ae43f58c07e",
+	},
+	{
+		name: "custom formula",
+		p:    mandel.Parameters{Magnification: 0.3, MaxIterations: 200, SizeX: 32, SizeY: 32, AntiAlias: 1, Formula: "z*z*z + c"},
+		hash: "18c690d1b35f3375b403dcc54d219697e05fca0fddf9f54fcd152cc6e82a5788",
+	},
+	{
+		name: "smart iterations",
+		p:    mandel.Parameters{CenterX: -0.1011, CenterY: 0.9563, Magnification: 40, MaxIterations: 3000, SizeX: 48, SizeY: 32, AntiAlias: 2, Continuous: true, SmartIterations: true},
+		hash: "8109c1d5f31579a0bde9fcb5141576b85d3ff45bf91e639440dd83adefbf0698",
+	},
+	{
+		name: "anti-aliasing fast path",
+		p:    mandel.Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 1000, SizeX: 48, SizeY: 32, AntiAlias: 3, AAFastPath: true},
+		hash: "39750f1c343ef4b8a05e920e6a1e991ca9591be463d092b79c3dd7bf46cbb091",
+	},
+}
+
+// selftest renders each self test and compares it to the expected hash,
+// exiting with an error status if any of them fail.
+func selftest() {
+	palette, err := loadPalette("")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, test := range selftests {
+		p := test.p
+		p.Palette = palette
+		p.InsideColor = color.NRGBA{0, 0, 0, 255}
+		if err := p.Init(); err != nil {
+			log.Fatalf("%s: %v", test.name, err)
+		}
+		img, err := p.Generate()
+		if err != nil {
+			log.Fatalf("%s: %v", test.name, err)
+		}
+		sum := fmt.Sprintf("%x", sha256.Sum256(img.Pix))
+		if sum == test.hash {
+			fmt.Printf("PASS %s\n", test.name)
+		} else {
+			fmt.Printf("FAIL %s: got %s, expected %s\n", test.name, sum, test.hash)
+			failed = true
+		}
+	}
+	if err := mandeltest.Verify(mandeltest.Generate, mandeltest.Tolerance{}); err != nil {
+		fmt.Printf("FAIL golden images: %v\n", err)
+		failed = true
+	} else {
+		fmt.Printf("PASS golden images\n")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// writeGolden renders the golden images of mandeltest again and saves
+// them as Go source in filename, normally mandeltest/golden.go.
+func writeGolden(filename string) {
+	fp, err := os.Create(filename)
+	if err != nil {
+		log.Fatalf("Error creating file %s: %v", filename, err)
+	}
+	if err = mandeltest.WriteGolden(fp, mandeltest.Generate); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		log.Fatalf("Error saving golden images: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/russross/mandel"
+	"github.com/russross/mandel/server"
+)
+
+// serve runs an HTTP server with slippy-map tiles under /tiles/z/x/y.png,
+// single images at /render, and a page at / that explores the tiles with
+// Leaflet, keeping rendered images in the -cache directory if one is
+// given.
+func serve(p *mandel.Parameters, listen string, cacheSize, prefetch, maxIterations int) {
+	// check the base parameters up front rather than on the first request
+	q := *p
+	if err := q.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	disk := openDiskCache()
+	tiles := server.New(p, cacheSize, prefetch, maxIterations)
+	tiles.SetDiskCache(disk)
+	render := server.NewRenderHandler(p, 4096*4096, maxIterations)
+	render.SetDiskCache(disk)
+	http.Handle("/tiles/", http.StripPrefix("/tiles", tiles))
+	http.Handle("/render", render)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, explorerPage)
+	})
+	log.Printf("serving on http://%s/", listen)
+	log.Fatal(http.ListenAndServe(listen, nil))
+}
+
+// explorerPage shows the tiles in a Leaflet map, passing its own query
+// string on to the tiles so /?i=5000&palette=fire changes the style.
+const explorerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mandelbrot explorer</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; background: #000; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var map = L.map('map', {crs: L.CRS.Simple, maxZoom: 40, minZoom: 0}).setView([-128, 128], 1);
+L.tileLayer('/tiles/{z}/{x}/{y}.png' + location.search, {noWrap: true, maxZoom: 40,
+	bounds: [[-256, 0], [0, 256]]}).addTo(map);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// saveReport logs a summary of the report of a render, and saves the
+// report as JSON in statsfile unless it is empty. Renders that do not
+// report, such as the Buddhabrot, are skipped.
+func saveReport(report *mandel.RenderReport, statsfile string) {
+	if report == nil {
+		if statsfile != "" {
+			log.Printf("no render report to save for this kind of render")
+		}
+		return
+	}
+	for _, line := range strings.Split(report.String(), "\n") {
+		log.Print(line)
+	}
+	if statsfile == "" {
+		return
+	}
+	fp, err := os.Create(statsfile)
+	if err != nil {
+		log.Fatalf("Error creating file %s: %v", statsfile, err)
+	}
+	if err = report.WriteJSON(fp); err == nil {
+		err = fp.Close()
+	} else {
+		fp.Close()
+	}
+	if err != nil {
+		log.Fatalf("Error saving render report: %v", err)
+	}
+}
+
+// savePixelMaps saves the work of every pixel of a render, from its
+// report, as grayscale maps in iterationmap and timemap. Empty names are
+// skipped.
+func savePixelMaps(report *mandel.RenderReport, iterationmap, timemap string) {
+	if iterationmap == "" && timemap == "" {
+		return
+	}
+	if report == nil || report.Pixels == nil {
+		log.Printf("no pixel statistics to map for this kind of render")
+		return
+	}
+	if iterationmap != "" {
+		if err := saveImage(iterationmap, report.Pixels.IterationImage()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if timemap != "" {
+		if err := saveImage(timemap, report.Pixels.TimeImage()); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// tiles renders the image as a grid of square tiles of the given size,
+// saving each one as soon as it is finished next to filename as
+// name-row-col.png, so images far too large to hold in memory can be
+// rendered and assembled later.
+func tiles(p *mandel.Parameters, filename string, size int) {
+	if err := p.Init(); err != nil {
+		log.Fatal(err)
+	}
+	p.Progress = progressBar(os.Stderr)
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	count := 0
+	err := p.GenerateTileGrid(size, func(rect image.Rectangle, img *image.NRGBA) error {
+		count++
+		name := fmt.Sprintf("%s-%d-%d%s", base, rect.Min.Y/size+1, rect.Min.X/size+1, ext)
+		return saveImage(name, img)
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("finished %d tiles of %s: -x %s -y %s -m %.17g -i %d", count, filename, &coordinate{&p.CenterX, &p.PreciseX}, &coordinate{&p.CenterY, &p.PreciseY}, p.Magnification, p.MaxIterations)
+}
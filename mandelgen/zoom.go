@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// zoom renders a zoom sequence from the view given by -x, -y, and -m to
+// the one given by -tox, -toy, and -tom, saving the frames next to
+// filename as name0001.png, name0002.png, and so on, ready for
+// ffmpeg -i name%04d.png, or with -animate as one animated image.
+func zoom(p *mandel.Parameters, filename string, s mandel.ZoomSequence, anim animationFlags) {
+	s.StartX, s.StartY, s.StartMagnification = p.CenterX, p.CenterY, p.Magnification
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	var a *animation
+	if anim.animate {
+		// GIF frames are quantized to the colors of the starting view
+		if err := p.Init(); err != nil {
+			log.Fatal(err)
+		}
+		var err error
+		if a, err = anim.start(p, filename, s.Frames); err != nil {
+			log.Fatal(err)
+		}
+	}
+	err := p.GenerateSequence(s, func(n int, img *image.NRGBA) error {
+		if a != nil {
+			log.Printf("frame %d of %d", n+1, s.Frames)
+			return a.addFrame(img)
+		}
+		name := fmt.Sprintf("%s%04d%s", base, n+1, ext)
+		log.Printf("frame %d of %d: %s", n+1, s.Frames, name)
+		return saveImage(name, img)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if a != nil {
+		if err := a.close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("finished %d frames of %s: -tox %.17g -toy %.17g -tom %.17g -i %d", s.Frames, filename, s.EndX, s.EndY, s.EndMagnification, p.MaxIterations)
+}
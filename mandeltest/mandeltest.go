@@ -0,0 +1,192 @@
+// Package mandeltest renders a small set of canonical images and compares
+// them with golden copies made by the library, so that new iteration
+// kernels, ports, and optimizations can check that they still produce the
+// same pixels. The golden images are kept in golden.go, which
+// WriteGolden regenerates after a deliberate change to the output.
+package mandeltest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go/format"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+// Case is a canonical render: parameters ready for Init, with the default
+// palette and an opaque black interior.
+type Case struct {
+	Name       string
+	Parameters mandel.Parameters
+}
+
+// the canonical renders, small enough that all of them take well under a
+// second, and between them covering the main render paths
+var cases = []Case{
+	{"whole set", mandel.Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 1000, SizeX: 48, SizeY: 32, AntiAlias: 2}},
+	{"continuous seahorse valley", mandel.Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 300, MaxIterations: 2000, SizeX: 48, SizeY: 32, AntiAlias: 3, Continuous: true}},
+	{"histogram", mandel.Parameters{CenterX: -0.1011, CenterY: 0.9563, Magnification: 40, MaxIterations: 3000, SizeX: 48, SizeY: 32, AntiAlias: 2, Coloring: "histogram"}},
+	{"distance", mandel.Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 1000, SizeX: 48, SizeY: 32, AntiAlias: 2, Coloring: "distance"}},
+	{"julia", mandel.Parameters{Magnification: 0.3, MaxIterations: 500, SizeX: 32, SizeY: 32, AntiAlias: 2, Continuous: true, Fractal: "julia", JuliaCX: -0.8, JuliaCY: 0.156}},
+	{"burning ship", mandel.Parameters{CenterX: -0.5, CenterY: -0.5, Magnification: 0.3, MaxIterations: 500, SizeX: 48, SizeY: 32, AntiAlias: 2, Fractal: "burningship"}},
+	{"custom formula", mandel.Parameters{Magnification: 0.3, MaxIterations: 200, SizeX: 32, SizeY: 32, AntiAlias: 1, Formula: "z*z*z + c"}},
+	{"perturbation", mandel.Parameters{
+		PreciseX:      "-0.743643887037158704752191506114774",
+		PreciseY:      "0.131825904205311970493132056385139",
+		Magnification: 1e12, MaxIterations: 5000, SizeX: 48, SizeY: 32, AntiAlias: 1, Continuous: true,
+	}},
+	{"adaptive anti-aliasing", mandel.Parameters{CenterX: -0.7453, CenterY: 0.1127, Magnification: 300, MaxIterations: 2000, SizeX: 48, SizeY: 32, AntiAlias: 3, Continuous: true, AdaptiveAA: true}},
+	{"smart iterations", mandel.Parameters{CenterX: -0.1011, CenterY: 0.9563, Magnification: 40, MaxIterations: 20000, SizeX: 48, SizeY: 32, AntiAlias: 2, Continuous: true, SmartIterations: true}},
+	{"jittered samples", mandel.Parameters{CenterX: -0.75, Magnification: 0.4, MaxIterations: 1000, SizeX: 48, SizeY: 32, AntiAlias: 3, Continuous: true, JitterAA: true, Seed: 7}},
+}
+
+// Cases returns the canonical renders, in the order Verify checks them.
+func Cases() []Case {
+	out := make([]Case, len(cases))
+	for i, c := range cases {
+		c.Parameters.Palette = mandel.DefaultPalette()
+		c.Parameters.InsideColor = color.NRGBA{0, 0, 0, 255}
+		out[i] = c
+	}
+	return out
+}
+
+// Golden decodes the golden image of c.
+func (c Case) Golden() (*image.NRGBA, error) {
+	data, ok := golden[c.Name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no golden image", c.Name)
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", c.Name, err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", c.Name, err)
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba, nil
+	}
+	nrgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(nrgba, nrgba.Rect, img, img.Bounds().Min, draw.Src)
+	return nrgba, nil
+}
+
+// Generate renders p the usual way, with Init and Generate. It is the
+// render function to pass to Verify to check the library itself.
+func Generate(p *mandel.Parameters) (*image.NRGBA, error) {
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+	return p.Generate()
+}
+
+// Tolerance says how far a render may stray from a golden image. The zero
+// Tolerance asks for identical pixels.
+type Tolerance struct {
+	// the most any channel of a pixel may be off by
+	Channel int
+
+	// how many pixels may be off by more than Channel
+	Pixels int
+}
+
+// Compare reports whether got matches want within tol, returning an error
+// that describes the worst pixel if it does not.
+func Compare(got, want *image.NRGBA, tol Tolerance) error {
+	if got.Bounds().Size() != want.Bounds().Size() {
+		return fmt.Errorf("image is %v, expected %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	size := want.Bounds().Size()
+	over, worst := 0, 0
+	var worstAt image.Point
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			g := got.Pix[got.PixOffset(got.Rect.Min.X+x, got.Rect.Min.Y+y):]
+			w := want.Pix[want.PixOffset(want.Rect.Min.X+x, want.Rect.Min.Y+y):]
+			d := 0
+			for k := 0; k < 4; k++ {
+				if diff := int(g[k]) - int(w[k]); diff > d {
+					d = diff
+				} else if -diff > d {
+					d = -diff
+				}
+			}
+			if d > tol.Channel {
+				over++
+			}
+			if d > worst {
+				worst, worstAt = d, image.Point{x, y}
+			}
+		}
+	}
+	if over > tol.Pixels {
+		return fmt.Errorf("%d pixels are off by more than %d, the worst by %d at %v", over, tol.Channel, worst, worstAt)
+	}
+	return nil
+}
+
+// Verify renders every case with render and compares it with its golden
+// image within tol. render gets a fresh copy of the case's parameters,
+// before Init. The error lists every case that failed.
+func Verify(render func(p *mandel.Parameters) (*image.NRGBA, error), tol Tolerance) error {
+	var failed []string
+	for _, c := range Cases() {
+		want, err := c.Golden()
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		p := c.Parameters
+		got, err := render(&p)
+		if err == nil {
+			err = Compare(got, want, tol)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", c.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d cases failed:\n%s", len(failed), len(cases), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// WriteGolden renders every case with render and writes Go source for
+// golden.go holding the results as the golden images.
+func WriteGolden(w io.Writer, render func(p *mandel.Parameters) (*image.NRGBA, error)) error {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by mandelgen golden. DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package mandeltest")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "// the golden image of each case, as a base64 PNG")
+	fmt.Fprintln(&b, "var golden = map[string]string{")
+	for _, c := range Cases() {
+		p := c.Parameters
+		img, err := render(&p)
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.Name, err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("%s: %v", c.Name, err)
+		}
+		fmt.Fprintf(&b, "\t%q: %q,\n", c.Name, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	fmt.Fprintln(&b, "}")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
@@ -0,0 +1,13 @@
+package mandeltest
+
+import "testing"
+
+// TestGolden renders every canonical case and requires exactly the golden
+// pixels, so a platform, compiler, or optimization that changes a single
+// threshold decision in adaptive anti-aliasing, smart iterations, or
+// perturbation fails here.
+func TestGolden(t *testing.T) {
+	if err := Verify(Generate, Tolerance{}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+package mandel
+
+import "math"
+
+// quadratic reports whether the iteration is the classic z² + c, which
+// the distance estimate, perturbation, and the tiled and masked render
+// paths depend on.
+func (p *Parameters) quadratic() bool {
+	return p.formula == nil && p.system == nil && p.Power <= 2 && (p.Fractal == "" || p.Fractal == "mandelbrot")
+}
+
+// iteratePower iterates z^power + c from z = a + bi with c = x + yi, and
+// an integer power computed by repeated complex multiplication. The
+// Mandelbrot set and its relatives start from z = c, and Julia sets from
+// the point being colored. For the burning ship, the real and imaginary
+// parts of z are replaced by their absolute values before each step, and
+// for the tricorn, z is replaced by its conjugate. The burning ship comes
+// out upside down compared to the usual pictures, since the imaginary axis
+// points up.
+func iteratePower(maxIters int, a, b, x, y float64, smooth smoother, bailout float64, power int, fractal string) float64 {
+	burning, tricorn := fractal == "burningship", fractal == "tricorn"
+	for iters := 1; iters <= maxIters; iters++ {
+		mag2 := float64(a*a) + float64(b*b)
+		if mag2 >= bailout {
+			if smooth != nil {
+				return smooth(iters, mag2, bailout)
+			}
+			return float64(iters)
+		}
+		if burning {
+			a, b = math.Abs(a), math.Abs(b)
+		} else if tricorn {
+			b = -b
+		}
+		za, zb := a, b
+		for k := 1; k < power; k++ {
+			za, zb = float64(za*a)-float64(zb*b), float64(za*b)+float64(zb*a)
+		}
+		a, b = za+x, zb+y
+	}
+	return 0.0
+}
+
+// smoothEscapePower is smoothEscape for z^power + c, where |z| grows by
+// a power of power rather than 2 with each step near escape, so the
+// fractional part is measured with log base power.
+func smoothEscapePower(iters int, mag2, bailout float64, power int) float64 {
+	nu := math.Log(math.Log(mag2)/math.Log(bailout)) / math.Log(float64(power))
+	v := float64(iters+1) - nu
+	if v < 1 {
+		v = math.Exp(v - 1)
+	}
+	return v
+}
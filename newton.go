@@ -0,0 +1,172 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// the polynomial a Newton fractal solves when NewtonCoefficients is
+// empty: z³ − 1
+var defaultNewtonCoefficients = []float64{1, 0, 0, -1}
+
+const (
+	// a sample has converged once a Newton step moves it less than this
+	newtonTolerance = 1e-9
+
+	// each iteration a sample takes to converge darkens it by this factor,
+	// down to newtonDarkest
+	newtonShade   = 0.94
+	newtonDarkest = 0.15
+)
+
+// initNewton checks the settings for a Newton fractal and finds the roots
+// of its polynomial.
+func (p *Parameters) initNewton() error {
+	coeffs := p.NewtonCoefficients
+	if len(coeffs) == 0 {
+		coeffs = defaultNewtonCoefficients
+	}
+	for len(coeffs) > 0 && coeffs[0] == 0 {
+		coeffs = coeffs[1:]
+	}
+	if len(coeffs) < 3 {
+		return fmt.Errorf("Newton polynomial must have degree 2 or higher")
+	}
+	for _, c := range coeffs {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return fmt.Errorf("Newton polynomial coefficients must be finite")
+		}
+	}
+	if p.Coloring != "" && p.Coloring != "palette" {
+		return fmt.Errorf("Newton fractals cannot use %s coloring", p.Coloring)
+	}
+	if p.Colorer != nil || p.duotone() {
+		return fmt.Errorf("Newton fractals color by root with the palette")
+	}
+	if p.Formula != "" {
+		return fmt.Errorf("Newton fractals cannot use a custom formula")
+	}
+	if p.Output != (OutputSpec{}) && p.Output != NRGBA8 {
+		return fmt.Errorf("Newton fractals only support 8-bit color output")
+	}
+
+	// the roots of the monic polynomial are the same
+	p.newtonPoly = make([]complex128, len(coeffs))
+	for i, c := range coeffs {
+		p.newtonPoly[i] = complex(c/coeffs[0], 0)
+	}
+	p.newtonRoots = polyRoots(p.newtonPoly)
+	return nil
+}
+
+// polyRoots finds the roots of a monic polynomial, given with its highest
+// power first, with the Durand-Kerner method, which refines guesses for
+// all of the roots at once.
+func polyRoots(poly []complex128) []complex128 {
+	roots := make([]complex128, len(poly)-1)
+	guess := complex(1, 0)
+	for i := range roots {
+		roots[i] = guess
+		guess *= complex(0.4, 0.9)
+	}
+	for iter := 0; iter < 1000; iter++ {
+		moved := 0.0
+		for i, r := range roots {
+			denom := complex(1, 0)
+			for j, s := range roots {
+				if j != i {
+					denom *= r - s
+				}
+			}
+			if denom == 0 {
+				continue
+			}
+			f, _ := polyEval(poly, r)
+			step := f / denom
+			roots[i] = r - step
+			moved = math.Max(moved, cmplx.Abs(step))
+		}
+		if moved < 1e-14 {
+			break
+		}
+	}
+	return roots
+}
+
+// polyEval evaluates a polynomial and its derivative at z by Horner's
+// rule.
+func polyEval(poly []complex128, z complex128) (f, df complex128) {
+	for _, c := range poly {
+		df = df*z + f
+		f = f*z + c
+	}
+	return f, df
+}
+
+// newton runs Newton's method from z until a step moves less than
+// newtonTolerance, and returns the index of the root it lands nearest,
+// with the number of iterations it took. The count is fractional with
+// continuous coloring, interpolating the size of the last step on a log
+// scale. Samples that run out of iterations, or hit a zero derivative,
+// return -1.
+func (p *Parameters) newton(z complex128, continuous bool) (root int, iters float64) {
+	last := math.Inf(1)
+	for n := 1; n <= p.MaxIterations; n++ {
+		f, df := polyEval(p.newtonPoly, z)
+		if df == 0 {
+			return -1, 0
+		}
+		step := f / df
+		z -= step
+		size := cmplx.Abs(step)
+		if size < newtonTolerance {
+			iters = float64(n)
+			if continuous && size > 0 && last > newtonTolerance && !math.IsInf(last, 1) {
+				iters -= math.Log(newtonTolerance/size) / math.Log(last/size)
+			}
+			return p.nearestRoot(z), iters
+		}
+		last = size
+	}
+	return -1, 0
+}
+
+// nearestRoot returns the index of the root closest to z.
+func (p *Parameters) nearestRoot(z complex128) int {
+	best, dist := 0, math.Inf(1)
+	for i, r := range p.newtonRoots {
+		if d := cmplx.Abs(z - r); d < dist {
+			best, dist = i, d
+		}
+	}
+	return best
+}
+
+// newtonPixel is pixelSum for Newton fractals. Each root takes a color
+// from the palette, spread evenly along it, and samples are darker the
+// more iterations they take to converge. Samples that do not converge
+// take InsideColor.
+func (p *Parameters) newtonPixel(col, row int, sum *colorSum) (inside bool) {
+	inside = true
+	for k := 0; k < p.samples; k++ {
+		xoffset, yoffset := p.pixelSample(col, row, k)
+		x, y := p.toPlane(col, row, xoffset, yoffset)
+		root, iters := p.newton(complex(x, y), p.Continuous)
+		if p.clearSample(root < 0) {
+			inside = inside && root < 0
+			sum.add(0, 0, 0, 0)
+			continue
+		}
+		if root < 0 {
+			c := p.InsideColor
+			sum.add(int(c.R), int(c.G), int(c.B), int(c.A))
+			continue
+		}
+		inside = false
+		c := samplePalette(p.palette, float64(root*len(p.palette))/float64(len(p.newtonRoots)))
+		shade := math.Max(newtonDarkest, math.Pow(newtonShade, iters-1))
+		sum.add(int(float64(c.R)*shade+0.5), int(float64(c.G)*shade+0.5), int(float64(c.B)*shade+0.5), int(c.A))
+	}
+	return inside
+}
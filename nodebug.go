@@ -0,0 +1,6 @@
+//go:build !mandeldebug
+// +build !mandeldebug
+
+package mandel
+
+func checkPaletteValue(v float64, n int) {}
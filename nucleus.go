@@ -0,0 +1,177 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+// Nucleus is the center of a hyperbolic component of the set, such as the
+// middle of a minibrot's cardioid, where the orbit of 0 is periodic.
+type Nucleus struct {
+	X, Y   *big.Float
+	Period int
+}
+
+// FindNucleus finds the nucleus nearest (x, y) of the lowest-period
+// component within about radius of it, trying periods up to maxPeriod.
+// The period comes from watching the orbits of the corners of a box around
+// the point until they first surround the origin. The nucleus is a root
+// of z_period(c) = 0, found with Newton's method in float64 from a grid of
+// starting points around (x, y), then polished to prec bits of precision.
+// A prec of 0 picks a precision suited to the radius.
+func FindNucleus(x, y, radius float64, maxPeriod int, prec uint) (*Nucleus, error) {
+	period := boxPeriod(x, y, radius, maxPeriod)
+	if period == 0 {
+		return nil, fmt.Errorf("no component found within %g of (%g, %g) up to period %d", radius, x, y, maxPeriod)
+	}
+	if prec == 0 {
+		prec = 64 + 2*uint(math.Max(0, -math.Log2(radius)))
+	}
+
+	// components of high period crowd together, so keep each Newton step
+	// short and try several starting points, keeping the closest root
+	const grid = 2
+	center := complex(x, y)
+	best, bestDist := center, math.Inf(1)
+	for i := -grid; i <= grid; i++ {
+		for j := -grid; j <= grid; j++ {
+			start := center + complex(float64(i)*radius/grid, float64(j)*radius/grid)
+			c, ok := nucleusNewton(start, period, radius/8)
+			if d := cmplx.Abs(c - center); ok && d < bestDist {
+				best, bestDist = c, d
+			}
+		}
+		if bestDist < radius/grid {
+			break
+		}
+	}
+	if bestDist > 2*radius {
+		return nil, fmt.Errorf("Newton's method found no period %d nucleus near (%g, %g)", period, x, y)
+	}
+
+	cx := new(big.Float).SetPrec(prec).SetFloat64(real(best))
+	cy := new(big.Float).SetPrec(prec).SetFloat64(imag(best))
+	nb := &bigMath{prec: prec}
+	tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -int(prec)+8)
+
+	for step := 0; step < 64; step++ {
+		// z_period and its derivative with respect to c
+		zx, zy := nb.zero(), nb.zero()
+		dx, dy := nb.zero(), nb.zero()
+		for i := 0; i < period; i++ {
+			// dz = 2·z·dz + 1
+			dx, dy = nb.add(nb.scale(nb.sub(nb.mul(zx, dx), nb.mul(zy, dy)), 2), nb.one()),
+				nb.scale(nb.add(nb.mul(zx, dy), nb.mul(zy, dx)), 2)
+			// z = z² + c
+			zx, zy = nb.add(nb.sub(nb.mul(zx, zx), nb.mul(zy, zy)), cx),
+				nb.add(nb.scale(nb.mul(zx, zy), 2), cy)
+		}
+
+		// c -= z / dz
+		denom := nb.add(nb.mul(dx, dx), nb.mul(dy, dy))
+		if denom.Sign() == 0 {
+			return nil, fmt.Errorf("Newton's method failed: zero derivative")
+		}
+		stepx := nb.quo(nb.add(nb.mul(zx, dx), nb.mul(zy, dy)), denom)
+		stepy := nb.quo(nb.sub(nb.mul(zy, dx), nb.mul(zx, dy)), denom)
+		cx, cy = nb.sub(cx, stepx), nb.sub(cy, stepy)
+
+		size := new(big.Float).Abs(stepx)
+		if ay := new(big.Float).Abs(stepy); ay.Cmp(size) > 0 {
+			size = ay
+		}
+		if size.Cmp(tolerance) <= 0 {
+			return &Nucleus{X: cx, Y: cy, Period: period}, nil
+		}
+	}
+	return nil, fmt.Errorf("Newton's method did not converge for period %d", period)
+}
+
+// nucleusNewton runs Newton's method on z_period(c) = 0 from start,
+// limiting each step to maxStep, and reports whether it converged.
+func nucleusNewton(c complex128, period int, maxStep float64) (complex128, bool) {
+	for step := 0; step < 100; step++ {
+		z, dz := complex(0, 0), complex(0, 0)
+		for i := 0; i < period; i++ {
+			dz = 2*z*dz + 1
+			z = z*z + c
+		}
+		delta := z / dz
+		size := cmplx.Abs(delta)
+		if math.IsNaN(size) || math.IsInf(size, 0) {
+			return c, false
+		}
+		if size > maxStep {
+			delta *= complex(maxStep/size, 0)
+		}
+		c -= delta
+		if size <= 1e-15*cmplx.Abs(c) {
+			return c, true
+		}
+	}
+	return c, false
+}
+
+// boxPeriod iterates the corners of a box with the given radius around
+// (x, y) and returns the first iteration at which their images surround
+// the origin, or 0 if that does not happen within maxPeriod iterations.
+func boxPeriod(x, y, radius float64, maxPeriod int) int {
+	cs := [4]complex128{
+		complex(x-radius, y-radius),
+		complex(x+radius, y-radius),
+		complex(x+radius, y+radius),
+		complex(x-radius, y+radius),
+	}
+	zs := cs
+	for n := 1; n <= maxPeriod; n++ {
+		if surroundsOrigin(zs) {
+			return n
+		}
+		escaped := true
+		for i, z := range zs {
+			zs[i] = z*z + cs[i]
+			if real(z)*real(z)+imag(z)*imag(z) < 1e20 {
+				escaped = false
+			}
+		}
+		if escaped {
+			return 0
+		}
+	}
+	return 0
+}
+
+// surroundsOrigin reports whether the origin is inside a polygon, using
+// the even-odd rule.
+func surroundsOrigin(poly [4]complex128) bool {
+	inside := false
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		if (imag(a) > 0) != (imag(b) > 0) {
+			// where the edge crosses the real axis
+			x := real(a) - imag(a)*(real(b)-real(a))/(imag(b)-imag(a))
+			if x > 0 {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// bigMath does arithmetic on big.Float values at a fixed precision.
+type bigMath struct {
+	prec uint
+}
+
+func (b *bigMath) new() *big.Float                { return new(big.Float).SetPrec(b.prec) }
+func (b *bigMath) zero() *big.Float               { return b.new() }
+func (b *bigMath) one() *big.Float                { return b.new().SetInt64(1) }
+func (b *bigMath) add(x, y *big.Float) *big.Float { return b.new().Add(x, y) }
+func (b *bigMath) sub(x, y *big.Float) *big.Float { return b.new().Sub(x, y) }
+func (b *bigMath) mul(x, y *big.Float) *big.Float { return b.new().Mul(x, y) }
+func (b *bigMath) quo(x, y *big.Float) *big.Float { return b.new().Quo(x, y) }
+func (b *bigMath) scale(x *big.Float, k int64) *big.Float {
+	return b.new().Mul(x, b.new().SetInt64(k))
+}
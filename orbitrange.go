@@ -0,0 +1,64 @@
+package mandel
+
+import "math"
+
+// mandelRange iterates z² + c like mandel, and returns the smallest and
+// largest |z| the orbit reaches before it escapes or runs out of
+// iterations, along with whether it escaped. The escaping step itself is
+// left out of the largest, so both stay within the escape radius of 2.
+func mandelRange(maxIters int, x, y float64) (minDist, maxDist float64, escaped bool) {
+	a, b := x, y
+	min2, max2 := math.Inf(1), 0.0
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		mag2 := a2 + b2
+		if mag2 >= 4 {
+			escaped = true
+			break
+		}
+		if mag2 < min2 {
+			min2 = mag2
+		}
+		if mag2 > max2 {
+			max2 = mag2
+		}
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+	}
+	if math.IsInf(min2, 1) {
+		// escaped before the first step
+		min2 = max2
+	}
+	return math.Sqrt(min2), math.Sqrt(max2), escaped
+}
+
+// rangePixel is pixelSum for orbit-range coloring. Interior points are
+// colored by their orbits too.
+func (p *Parameters) rangePixel(col, row int, sum *colorSum) (inside bool) {
+	inside = true
+	for k := 0; k < p.samples; k++ {
+		xoffset, yoffset := p.pixelSample(col, row, k)
+		x, y := p.toPlane(col, row, xoffset, yoffset)
+		minDist, maxDist, escaped := mandelRange(p.MaxIterations, x, y)
+		inside = inside && !escaped
+		if p.clearSample(!escaped) {
+			sum.add(0, 0, 0, 0)
+			continue
+		}
+		sum.add(rangeColor(minDist, maxDist))
+	}
+	return inside
+}
+
+// rangeColor colors a sample for orbit-range coloring: the closest
+// approach to the origin picks the hue, running from red at 0 around to
+// magenta at 1 and beyond, and the farthest excursion sets the
+// brightness, from a quarter at 0 to full at the escape radius.
+func rangeColor(minDist, maxDist float64) (r, g, b, a int) {
+	hue := 300 * math.Min(1, minDist)
+	value := 0.25 + 0.75*math.Min(1, maxDist/2)
+	c := hsvColor(hue, 1, value)
+	return int(c.R), int(c.G), int(c.B), int(c.A)
+}
@@ -0,0 +1,299 @@
+package mandel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"time"
+)
+
+// OutputSpec describes the channels and bit depth of the image built by
+// GenerateImage. Color output has four channels. Single-channel output is
+// either gray, holding the escape level of each pixel on the same log
+// scale as alpha-ramp coloring with the interior black; alpha, holding
+// the alpha channel of the colored image; or paletted, holding the colored
+// image quantized to the colors of QuantizePalette. The zero value means
+// NRGBA8.
+type OutputSpec struct {
+	Channels int  `json:"channels"`
+	BitDepth int  `json:"depth"`
+	Alpha    bool `json:"alpha,omitempty"`
+	Indexed  bool `json:"indexed,omitempty"`
+}
+
+// the supported output formats
+var (
+	NRGBA8   = OutputSpec{Channels: 4, BitDepth: 8}
+	NRGBA64  = OutputSpec{Channels: 4, BitDepth: 16}
+	Gray8    = OutputSpec{Channels: 1, BitDepth: 8}
+	Gray16   = OutputSpec{Channels: 1, BitDepth: 16}
+	Alpha    = OutputSpec{Channels: 1, BitDepth: 8, Alpha: true}
+	Paletted = OutputSpec{Channels: 1, BitDepth: 8, Indexed: true}
+)
+
+var outputSpecNames = map[string]OutputSpec{
+	"nrgba8":   NRGBA8,
+	"nrgba64":  NRGBA64,
+	"gray8":    Gray8,
+	"gray16":   Gray16,
+	"alpha":    Alpha,
+	"paletted": Paletted,
+}
+
+// ParseOutputSpec looks up an output format by name: nrgba8, nrgba64,
+// gray8, gray16, alpha, or paletted.
+func ParseOutputSpec(name string) (OutputSpec, error) {
+	spec, ok := outputSpecNames[name]
+	if !ok {
+		return OutputSpec{}, fmt.Errorf("unknown output format %q", name)
+	}
+	return spec, nil
+}
+
+func (s OutputSpec) valid() bool {
+	if s == (OutputSpec{}) {
+		return true
+	}
+	for _, spec := range outputSpecNames {
+		if s == spec {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateImage renders the image in the format given by Output. 8-bit
+// color output is the same as Generate. The other formats are built from
+// the raw samples, without the density overlay, contours, labels, or
+// cropping. Colorings that need more than escape values, such as orbit
+// traps, color each pixel as Generate does, but at full precision; gray
+// output shows the escape levels whatever the coloring.
+func (p *Parameters) GenerateImage() (image.Image, error) {
+	if err := p.checkInit("GenerateImage"); err != nil {
+		return nil, err
+	}
+	return p.GenerateImageContext(context.Background())
+}
+
+// GenerateImageContext is GenerateImage, but it stops early and returns
+// ctx.Err() if ctx is cancelled before the image is finished.
+func (p *Parameters) GenerateImageContext(ctx context.Context) (image.Image, error) {
+	if err := p.checkInit("GenerateImageContext"); err != nil {
+		return nil, err
+	}
+	spec := p.Output
+	if spec == (OutputSpec{}) || spec == NRGBA8 {
+		canvas, err := p.GenerateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return canvas, nil
+	}
+
+	q := *p
+	q.ctx = ctx
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+		defer q.progress.stop()
+	}
+	if p.Report != nil {
+		q.report = newReporter(p)
+	}
+	p = &q
+
+	var out *outputImage
+	if p.pixelColoring() && spec != Gray8 && spec != Gray16 {
+		// colorings that need more than escape values color whole pixels
+		out = p.newOutputImage(image.Rect(0, 0, p.SizeX, p.SizeY))
+		p.forRows(p.SizeY, func(row int) {
+			for col := 0; col < p.SizeX; col++ {
+				sum := colorSum{precise: true}
+				p.pixelSum(col, row, &sum)
+				r, g, b, a := sum.value()
+				out.set(col, row, r, g, b, a, 0)
+			}
+		})
+	} else {
+		f := p.computeIterations()
+		switch p.Coloring {
+		case "distance":
+			f = p.distanceLevels()
+		case "histogram":
+			q.histogram = p.histogramField(f)
+		}
+		out = p.newOutputImage(image.Rect(0, 0, p.SizeX, p.SizeY))
+		p.colorField(f, out)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	decorated := time.Now()
+	img := out.finish()
+	if p.report != nil {
+		p.Report(p.report.finish(p, decorated))
+	}
+	return img, nil
+}
+
+// ColorizeImage is Colorize in the format given by Output, as
+// GenerateImage builds it. Colorings that need more than escape values,
+// such as orbit traps and distance coloring, cannot color a field.
+func (f *Field) ColorizeImage(p *Parameters) (image.Image, error) {
+	if err := p.checkInit("ColorizeImage"); err != nil {
+		return nil, err
+	}
+	if p.pixelColoring() || p.Coloring == "distance" {
+		return nil, fmt.Errorf("the coloring needs more than escape values, so it cannot color a field")
+	}
+	if p.Output == (OutputSpec{}) || p.Output == NRGBA8 {
+		return f.Colorize(p), nil
+	}
+	if p.Coloring == "histogram" && p.histogram == nil {
+		q := *p
+		q.histogram = p.histogramField(f)
+		p = &q
+	}
+	out := p.newOutputImage(image.Rect(0, 0, f.Width/f.AntiAlias, f.Height/f.AntiAlias))
+	p.colorField(f, out)
+	return out.finish(), nil
+}
+
+// outputImage is an image being built in the format given by Output.
+type outputImage struct {
+	p    *Parameters
+	rect image.Rectangle
+	img  image.Image
+
+	// set stores a pixel from its color, with channels in [0, 1] and not
+	// premultiplied, and its escape level
+	set func(col, row int, r, g, b, a, level float64)
+
+	// paletted output keeps the colors unrounded until they are quantized
+	colors []float64
+}
+
+// newOutputImage starts an image covering rect in the format given by
+// Output, which must not be NRGBA8.
+func (p *Parameters) newOutputImage(rect image.Rectangle) *outputImage {
+	out := &outputImage{p: p, rect: rect}
+	switch p.Output {
+	case NRGBA64:
+		canvas := image.NewNRGBA64(rect)
+		out.img = canvas
+		out.set = func(col, row int, r, g, b, a, level float64) {
+			canvas.SetNRGBA64(col, row, color.NRGBA64{p.channel16(r), p.channel16(g), p.channel16(b), uint16(a*65535 + 0.5)})
+		}
+	case Gray8:
+		canvas := image.NewGray(rect)
+		out.img = canvas
+		out.set = func(col, row int, r, g, b, a, level float64) {
+			canvas.SetGray(col, row, color.Gray{uint8(level*255 + 0.5)})
+		}
+	case Gray16:
+		canvas := image.NewGray16(rect)
+		out.img = canvas
+		out.set = func(col, row int, r, g, b, a, level float64) {
+			canvas.SetGray16(col, row, color.Gray16{uint16(level*65535 + 0.5)})
+		}
+	case Alpha:
+		canvas := image.NewAlpha(rect)
+		out.img = canvas
+		out.set = func(col, row int, r, g, b, a, level float64) {
+			canvas.SetAlpha(col, row, color.Alpha{uint8(a*255 + 0.5)})
+		}
+	case Paletted:
+		out.colors = make([]float64, 4*rect.Dx()*rect.Dy())
+		out.set = func(col, row int, r, g, b, a, level float64) {
+			i := 4 * (row*rect.Dx() + col)
+			out.colors[i], out.colors[i+1], out.colors[i+2], out.colors[i+3] = p.gamma(r), p.gamma(g), p.gamma(b), a
+		}
+	}
+	return out
+}
+
+// finish returns the finished image, quantizing paletted output.
+func (o *outputImage) finish() image.Image {
+	if o.p.Output == Paletted {
+		return o.p.quantize(o.colors, o.rect, o.p.quantizeColors())
+	}
+	return o.img
+}
+
+// colorField colors the escape values of f into out, averaging the colors
+// of the samples of each pixel weighted by alpha, as colorSum does, but
+// at full precision.
+func (p *Parameters) colorField(f *Field, out *outputImage) {
+	aa := f.AntiAlias
+	p.forRows(f.Height/aa, func(row int) {
+		for col := 0; col < f.Width/aa; col++ {
+			var r, g, b, a, level float64
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					v := f.At(i, j)
+					rs, gs, bs, as := p.sampleColor(v)
+					r, g, b, a = r+rs*as, g+gs*as, b+bs*as, a+as
+					if v != 0 {
+						level += p.level(v - p.depthShift)
+					}
+				}
+			}
+			n := float64(aa * aa)
+			if a > 0 {
+				r, g, b = r/a/255, g/a/255, b/a/255
+			}
+			out.set(col, row, r, g, b, a/n/255, level/n)
+		}
+	})
+}
+
+// Generate64 renders the image with 16 bits per channel. Subpixel colors
+// are averaged at full precision and only then quantized, so continuous
+// gradients do not band the way 8-bit output can. Like the other formats
+// of GenerateImage, it leaves out contours, labels, and cropping.
+func (p *Parameters) Generate64() (*image.NRGBA64, error) {
+	if err := p.checkInit("Generate64"); err != nil {
+		return nil, err
+	}
+	q := *p
+	q.Output = NRGBA64
+	img, err := q.GenerateImage()
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.NRGBA64), nil
+}
+
+// channel16 applies the output gamma to a color channel in [0, 1] and
+// scales it to 16 bits.
+func (p *Parameters) channel16(v float64) uint16 {
+	return uint16(math.Max(0, math.Min(1, p.gamma(v)))*65535 + 0.5)
+}
+
+// gamma applies the output gamma to a color channel in [0, 1].
+func (p *Parameters) gamma(v float64) float64 {
+	if p.OutputGamma != 0 && p.OutputGamma != 1 {
+		v = math.Pow(v, 1/p.OutputGamma)
+	}
+	return v
+}
+
+// GeneratePNGBytes renders the image in the format given by Output and
+// returns it encoded as a PNG.
+func (p *Parameters) GeneratePNGBytes() ([]byte, error) {
+	if err := p.checkInit("GeneratePNGBytes"); err != nil {
+		return nil, err
+	}
+	img, err := p.GenerateImage()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,155 @@
+package mandel
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// InterpolatePalettes blends palette a into palette b, where t = 0 gives a
+// and t = 1 gives b, so an animation can cross-fade between palettes. When
+// the lengths differ, both palettes are resampled around their cycle to a
+// length between the two, so the result at t = 0 and t = 1 is exact.
+func InterpolatePalettes(a, b []color.NRGBA, t float64) []color.NRGBA {
+	if len(a) == 0 || t >= 1 {
+		return append([]color.NRGBA(nil), b...)
+	}
+	if len(b) == 0 || t <= 0 {
+		return append([]color.NRGBA(nil), a...)
+	}
+
+	n := int(math.Floor(float64(len(a)) + float64(len(b)-len(a))*t + 0.5))
+	out := make([]color.NRGBA, n)
+	for i := range out {
+		u := float64(i) / float64(n)
+		ca := samplePalette(a, u*float64(len(a)))
+		cb := samplePalette(b, u*float64(len(b)))
+		mix := func(x, y uint8) uint8 {
+			return uint8(float64(x)*(1-t) + float64(y)*t + 0.5)
+		}
+		out[i] = color.NRGBA{mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B), mix(ca.A, cb.A)}
+	}
+	return out
+}
+
+// samplePalette interpolates linearly between the entries on either side
+// of a fractional position, wrapping around the end of the palette.
+func samplePalette(palette []color.NRGBA, pos float64) color.NRGBA {
+	i := paletteIndex(pos, len(palette))
+	weight := pos - math.Floor(pos)
+	c1, c2 := palette[i], palette[(i+1)%len(palette)]
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-weight) + float64(y)*weight + 0.5)
+	}
+	return color.NRGBA{mix(c1.R, c2.R), mix(c1.G, c2.G), mix(c1.B, c2.B), mix(c1.A, c2.A)}
+}
+
+// the built-in palettes, as color stops spaced evenly around a cycle
+var namedPalettes = map[string][]color.NRGBA{
+	"fire": {
+		{0, 0, 0, 255}, {128, 0, 0, 255}, {255, 64, 0, 255}, {255, 200, 0, 255},
+		{255, 255, 200, 255}, {255, 200, 0, 255}, {255, 64, 0, 255}, {128, 0, 0, 255},
+	},
+	"ice": {
+		{0, 0, 32, 255}, {0, 64, 128, 255}, {64, 160, 255, 255},
+		{220, 240, 255, 255}, {64, 160, 255, 255}, {0, 64, 128, 255},
+	},
+	"ultra": {
+		{0, 7, 100, 255}, {32, 107, 203, 255}, {237, 255, 255, 255},
+		{255, 170, 0, 255}, {0, 2, 0, 255},
+	},
+	"grayscale": {
+		{0, 0, 0, 255}, {255, 255, 255, 255},
+	},
+	"rainbow": {
+		{255, 0, 0, 255}, {255, 255, 0, 255}, {0, 255, 0, 255},
+		{0, 255, 255, 255}, {0, 0, 255, 255}, {255, 0, 255, 255},
+	},
+}
+
+// colors in each built-in palette
+const namedPaletteSize = 64
+
+// NamedPalette returns one of the built-in palettes: "fire", "ice",
+// "ultra", "grayscale", or "rainbow".
+func NamedPalette(name string) ([]color.NRGBA, error) {
+	stops, ok := namedPalettes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown palette %q", name)
+	}
+	return Gradient(stops, namedPaletteSize, "rgb")
+}
+
+// Gradient builds a palette of size colors from a few color stops spaced
+// evenly around a cycle, so the last stop blends back into the first.
+// The interpolation between stops is "rgb" for straight lines in RGB,
+// "spline" for a monotone cubic spline through each channel, which eases
+// through the stops without overshooting them, or "hsv" or "lab" for
+// straight lines in those color spaces.
+func Gradient(stops []color.NRGBA, size int, interpolation string) ([]color.NRGBA, error) {
+	if len(stops) == 0 {
+		return nil, fmt.Errorf("gradient needs at least one color")
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("gradient size must be at least 1")
+	}
+	var mix func(i int, weight float64) color.NRGBA
+	switch interpolation {
+	case "rgb", "":
+		mix = func(i int, weight float64) color.NRGBA {
+			return samplePalette(stops, float64(i)+weight)
+		}
+	case "spline":
+		mix = func(i int, weight float64) color.NRGBA {
+			return splineColor(stops, i, weight)
+		}
+	case "hsv":
+		mix = func(i int, weight float64) color.NRGBA {
+			return mixHSV(stops[i], stops[(i+1)%len(stops)], weight)
+		}
+	case "lab":
+		mix = func(i int, weight float64) color.NRGBA {
+			return mixLab(stops[i], stops[(i+1)%len(stops)], weight)
+		}
+	default:
+		return nil, fmt.Errorf("unknown gradient interpolation %q", interpolation)
+	}
+
+	palette := make([]color.NRGBA, size)
+	for i := range palette {
+		pos := float64(i*len(stops)) / float64(size)
+		palette[i] = mix(int(pos), pos-math.Floor(pos))
+	}
+	return palette, nil
+}
+
+// splineColor evaluates a cyclic monotone cubic spline through the stops
+// at the given weight between stop i and the next. The tangent at each
+// stop is the harmonic mean of the slopes on either side, or flat where
+// the channel turns around, which keeps the curve inside the stops.
+func splineColor(stops []color.NRGBA, i int, weight float64) color.NRGBA {
+	n := len(stops)
+	channel := func(get func(c color.NRGBA) uint8) uint8 {
+		y := func(k int) float64 {
+			return float64(get(stops[(k+n)%n]))
+		}
+		tangent := func(k int) float64 {
+			d0, d1 := y(k)-y(k-1), y(k+1)-y(k)
+			if d0*d1 <= 0 {
+				return 0
+			}
+			return 2 * d0 * d1 / (d0 + d1)
+		}
+		t := weight
+		t2, t3 := t*t, t*t*t
+		v := (2*t3-3*t2+1)*y(i) + (t3-2*t2+t)*tangent(i) +
+			(-2*t3+3*t2)*y(i+1) + (t3-t2)*tangent(i+1)
+		return clamp8(int(math.Floor(v + 0.5)))
+	}
+	return color.NRGBA{
+		channel(func(c color.NRGBA) uint8 { return c.R }),
+		channel(func(c color.NRGBA) uint8 { return c.G }),
+		channel(func(c color.NRGBA) uint8 { return c.B }),
+		channel(func(c color.NRGBA) uint8 { return c.A }),
+	}
+}
@@ -0,0 +1,212 @@
+package palette
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// colors sampled from a GIMP gradient
+const ggrSize = 256
+
+// a segment of a GIMP gradient, with positions in [0, 1] and endpoint
+// colors as red, green, blue, and alpha in [0, 1]
+type ggrSegment struct {
+	left, middle, right float64
+	from, to            [4]float64
+	blend, coloring     int
+}
+
+// ReadGGR reads a GIMP gradient and samples it at 256 evenly spaced
+// points. Each segment blends between its endpoint colors with the
+// segment's curve: linear, curved, sine, spherical, or step, in RGB or
+// in HSV the short or long way around the hue circle.
+func ReadGGR(r io.Reader) ([]color.NRGBA, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "GIMP Gradient" {
+		return nil, fmt.Errorf("not a GIMP gradient")
+	}
+	line := 1
+	next := func() (string, bool) {
+		for scanner.Scan() {
+			line++
+			if text := strings.TrimSpace(scanner.Text()); text != "" {
+				return text, true
+			}
+		}
+		return "", false
+	}
+	text, ok := next()
+	if ok && strings.HasPrefix(text, "Name:") {
+		text, ok = next()
+	}
+	if !ok {
+		return nil, fmt.Errorf("GIMP gradient has no segments")
+	}
+	count, err := strconv.Atoi(text)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("line %d: invalid segment count %q", line, text)
+	}
+
+	segments := make([]ggrSegment, count)
+	for i := range segments {
+		if text, ok = next(); !ok {
+			return nil, fmt.Errorf("GIMP gradient has %d segments instead of %d", i, count)
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 13 {
+			return nil, fmt.Errorf("line %d: expected at least 13 values in a segment", line)
+		}
+		var v [13]float64
+		for j := range v {
+			if v[j], err = strconv.ParseFloat(fields[j], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid number %q", line, fields[j])
+			}
+		}
+		s := ggrSegment{left: v[0], middle: v[1], right: v[2], blend: int(v[11]), coloring: int(v[12])}
+		copy(s.from[:], v[3:7])
+		copy(s.to[:], v[7:11])
+		if s.blend < 0 || s.blend > 5 || s.coloring < 0 || s.coloring > 2 {
+			return nil, fmt.Errorf("line %d: unknown blending type", line)
+		}
+		segments[i] = s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	palette := make([]color.NRGBA, ggrSize)
+	for i := range palette {
+		pos := (float64(i) + 0.5) / ggrSize
+		s := segments[len(segments)-1]
+		for _, seg := range segments {
+			if pos <= seg.right {
+				s = seg
+				break
+			}
+		}
+		palette[i] = s.at(pos)
+	}
+	return palette, nil
+}
+
+// at finds the color at a position within the segment.
+func (s ggrSegment) at(pos float64) color.NRGBA {
+	const epsilon = 1e-10
+	width := s.right - s.left
+	var x, middle float64
+	if width < epsilon {
+		x, middle = 0.5, 0.5
+	} else {
+		x, middle = (pos-s.left)/width, (s.middle-s.left)/width
+	}
+
+	var f float64
+	switch s.blend {
+	case 0:
+		f = linearFactor(x, middle)
+	case 1:
+		f = math.Pow(x, math.Log(0.5)/math.Log(math.Max(middle, epsilon)))
+	case 2:
+		f = (math.Sin(-math.Pi/2+math.Pi*linearFactor(x, middle)) + 1) / 2
+	case 3:
+		f = linearFactor(x, middle) - 1
+		f = math.Sqrt(1 - f*f)
+	case 4:
+		f = linearFactor(x, middle)
+		f = 1 - math.Sqrt(1-f*f)
+	case 5:
+		if x >= middle {
+			f = 1
+		}
+	}
+
+	var c [4]float64
+	for i := range c {
+		c[i] = s.from[i] + (s.to[i]-s.from[i])*f
+	}
+	if s.coloring != 0 {
+		h0, s0, v0 := toHSV(s.from)
+		h1, s1, v1 := toHSV(s.to)
+		dh := h1 - h0
+		if s.coloring == 1 && dh < 0 {
+			// counterclockwise
+			dh++
+		} else if s.coloring == 2 && dh > 0 {
+			// clockwise
+			dh--
+		}
+		h := h0 + dh*f
+		h -= math.Floor(h)
+		c[0], c[1], c[2] = fromHSV(h, s0+(s1-s0)*f, v0+(v1-v0)*f)
+	}
+	return color.NRGBA{channel(c[0]), channel(c[1]), channel(c[2]), channel(c[3])}
+}
+
+// linearFactor maps a position within a segment to a blend factor that
+// is 0.5 at the midpoint and linear on either side of it.
+func linearFactor(x, middle float64) float64 {
+	const epsilon = 1e-10
+	if x <= middle {
+		if middle < epsilon {
+			return 0
+		}
+		return 0.5 * x / middle
+	}
+	if 1-middle < epsilon {
+		return 1
+	}
+	return 0.5 + 0.5*(x-middle)/(1-middle)
+}
+
+// toHSV converts red, green, and blue in [0, 1] to hue, saturation, and
+// value, all in [0, 1].
+func toHSV(c [4]float64) (h, s, v float64) {
+	r, g, b := c[0], c[1], c[2]
+	v = math.Max(r, math.Max(g, b))
+	delta := v - math.Min(r, math.Min(g, b))
+	if v <= 0 || delta <= 0 {
+		return 0, 0, v
+	}
+	s = delta / v
+	switch v {
+	case r:
+		h = (g - b) / delta
+	case g:
+		h = 2 + (b-r)/delta
+	default:
+		h = 4 + (r-g)/delta
+	}
+	h /= 6
+	if h < 0 {
+		h++
+	}
+	return h, s, v
+}
+
+// fromHSV converts hue, saturation, and value in [0, 1] to red, green,
+// and blue.
+func fromHSV(h, s, v float64) (r, g, b float64) {
+	h *= 6
+	i := math.Floor(h)
+	f := h - i
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+	switch int(i) % 6 {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
@@ -0,0 +1,156 @@
+// Package palette reads palettes saved in the formats used by other
+// fractal and graphics programs, so they can be used for coloring.
+package palette
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Load reads a palette file, choosing the format by its extension:
+// .json for a JSON array of [r, g, b] or [r, g, b, a] colors, .ggr for a
+// GIMP gradient, .gpl for a GIMP palette, .map for a Fractint map, or
+// .ugr for an UltraFractal gradient. A file with any other extension is
+// read as JSON.
+func Load(filename string) ([]color.NRGBA, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	switch format {
+	case "ggr", "gpl", "map", "ugr":
+	default:
+		format = "json"
+	}
+	return Decode(fp, format)
+}
+
+// Decode reads a palette in the named format: "json", "ggr", "gpl",
+// "map", or "ugr".
+func Decode(r io.Reader, format string) ([]color.NRGBA, error) {
+	var palette []color.NRGBA
+	var err error
+	switch format {
+	case "json":
+		palette, err = ReadJSON(r)
+	case "ggr":
+		palette, err = ReadGGR(r)
+	case "gpl":
+		palette, err = ReadGPL(r)
+	case "map":
+		palette, err = ReadMap(r)
+	case "ugr":
+		palette, err = ReadUGR(r)
+	default:
+		return nil, fmt.Errorf("unknown palette format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("palette has no colors")
+	}
+	return palette, nil
+}
+
+// ReadJSON reads a JSON array of colors, each one an array of red,
+// green, blue, and optional alpha values.
+func ReadJSON(r io.Reader) ([]color.NRGBA, error) {
+	var colors [][]uint8
+	if err := json.NewDecoder(r).Decode(&colors); err != nil {
+		return nil, fmt.Errorf("error parsing palette JSON data: %v", err)
+	}
+	var palette []color.NRGBA
+	for _, c := range colors {
+		switch len(c) {
+		case 3:
+			palette = append(palette, color.NRGBA{c[0], c[1], c[2], 255})
+		case 4:
+			palette = append(palette, color.NRGBA{c[0], c[1], c[2], c[3]})
+		default:
+			return nil, fmt.Errorf("each color must have 3 or 4 elements: red, green, blue, and optional alpha: found %v", c)
+		}
+	}
+	return palette, nil
+}
+
+// ReadGPL reads a GIMP palette: a "GIMP Palette" header line, optional
+// Name and Columns lines, and one color per line as red, green, and blue
+// values followed by an optional name. Lines starting with # are
+// comments.
+func ReadGPL(r io.Reader) ([]color.NRGBA, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "GIMP Palette" {
+		return nil, fmt.Errorf("not a GIMP palette")
+	}
+	var palette []color.NRGBA
+	for line := 2; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") ||
+			strings.HasPrefix(text, "Name:") || strings.HasPrefix(text, "Columns:") {
+			continue
+		}
+		c, err := parseRGB(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		palette = append(palette, c)
+	}
+	return palette, scanner.Err()
+}
+
+// ReadMap reads a Fractint map: one color per line as red, green, and
+// blue values, with anything after them on the line ignored.
+func ReadMap(r io.Reader) ([]color.NRGBA, error) {
+	scanner := bufio.NewScanner(r)
+	var palette []color.NRGBA
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		c, err := parseRGB(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		palette = append(palette, c)
+	}
+	return palette, scanner.Err()
+}
+
+// parseRGB reads the red, green, and blue values at the start of a line.
+func parseRGB(text string) (color.NRGBA, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 {
+		return color.NRGBA{}, fmt.Errorf("expected red, green, and blue values: found %q", text)
+	}
+	var rgb [3]uint8
+	for i := range rgb {
+		n, err := strconv.ParseUint(fields[i], 10, 8)
+		if err != nil {
+			return color.NRGBA{}, fmt.Errorf("invalid color value %q", fields[i])
+		}
+		rgb[i] = uint8(n)
+	}
+	return color.NRGBA{rgb[0], rgb[1], rgb[2], 255}, nil
+}
+
+// channel converts a color channel from [0, 1] to a byte.
+func channel(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
@@ -0,0 +1,88 @@
+package palette
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the positions around an UltraFractal gradient
+const ugrSize = 400
+
+// ReadUGR reads the first gradient in an UltraFractal gradient file and
+// blends linearly between its control points around a cycle of 400
+// colors. Control points are given as index=N color=BGR pairs in the
+// gradient: section, where the color is a decimal integer with red in
+// the low byte; the opacity: section is ignored.
+func ReadUGR(r io.Reader) ([]color.NRGBA, error) {
+	type point struct {
+		index int
+		color color.NRGBA
+	}
+	var points []point
+
+	scanner := bufio.NewScanner(r)
+	section, index := "", -1
+	for line := 1; scanner.Scan(); line++ {
+		for _, field := range strings.Fields(scanner.Text()) {
+			switch {
+			case field == "}":
+				if len(points) > 0 {
+					section = "done"
+				} else {
+					section = ""
+				}
+			case strings.HasSuffix(field, ":"):
+				section = strings.TrimSuffix(field, ":")
+			case section == "gradient" && strings.HasPrefix(field, "index="):
+				n, err := strconv.Atoi(strings.TrimPrefix(field, "index="))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid index %q", line, field)
+				}
+				index = ((n % ugrSize) + ugrSize) % ugrSize
+			case section == "gradient" && strings.HasPrefix(field, "color="):
+				n, err := strconv.ParseUint(strings.TrimPrefix(field, "color="), 10, 32)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("line %d: invalid color %q", line, field)
+				}
+				points = append(points, point{index, color.NRGBA{uint8(n), uint8(n >> 8), uint8(n >> 16), 255}})
+				index = -1
+			}
+		}
+		if section == "done" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("UltraFractal gradient has no colors")
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].index < points[j].index })
+
+	// walk around the cycle, blending from each point to the next
+	palette := make([]color.NRGBA, ugrSize)
+	for i := range points {
+		from, to := points[i], points[(i+1)%len(points)]
+		span := to.index - from.index
+		if i == len(points)-1 {
+			span += ugrSize
+		}
+		for j := 0; j < span; j++ {
+			t := float64(j) / float64(span)
+			mix := func(x, y uint8) uint8 {
+				return uint8(float64(x)*(1-t) + float64(y)*t + 0.5)
+			}
+			palette[(from.index+j)%ugrSize] = color.NRGBA{
+				mix(from.color.R, to.color.R), mix(from.color.G, to.color.G),
+				mix(from.color.B, to.color.B), 255,
+			}
+		}
+	}
+	return palette, nil
+}
@@ -0,0 +1,67 @@
+package mandel
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultParameters returns the same defaults as mandelgen: the whole set
+// at 1024×768 with 1000 iterations, no anti-aliasing, the default palette,
+// and an opaque black interior. Init has not been called on them.
+func DefaultParameters() *Parameters {
+	return &Parameters{
+		CenterX:       -0.75,
+		Magnification: 0.4,
+		MaxIterations: 1000,
+		SizeX:         1024,
+		SizeY:         768,
+		AntiAlias:     1,
+		Palette:       DefaultPalette(),
+		InsideColor:   color.NRGBA{0, 0, 0, 255},
+	}
+}
+
+// LoadParameters reads parameters saved as JSON by Save and calls Init on
+// them. Fields missing from the JSON get their values from
+// DefaultParameters, and an empty palette is replaced by the default one.
+// A Detail preset still fills in the iterations and anti-aliasing when
+// they are missing.
+func LoadParameters(r io.Reader) (*Parameters, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading parameters: %v", err)
+	}
+	p := DefaultParameters()
+	p.Palette = nil
+	if err := json.Unmarshal(raw, p); err != nil {
+		return nil, fmt.Errorf("error parsing parameters: %v", err)
+	}
+	if p.Detail != "" {
+		var given map[string]json.RawMessage
+		json.Unmarshal(raw, &given)
+		if _, ok := given["i"]; !ok {
+			p.MaxIterations = 0
+		}
+		if _, ok := given["a"]; !ok {
+			p.AntiAlias = 0
+		}
+	}
+	if len(p.Palette) == 0 {
+		p.Palette = DefaultPalette()
+	}
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Save writes the parameters as JSON that LoadParameters can read back.
+// Everything but the Progress callback is saved.
+func (p *Parameters) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(p)
+}
@@ -0,0 +1,50 @@
+package mandel
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+// TestParametersRoundTrip saves initialized parameters, loads them back,
+// and checks that saving them again writes the same JSON and that they
+// render the same image.
+func TestParametersRoundTrip(t *testing.T) {
+	tests := []Parameters{
+		{CenterX: -0.7453, CenterY: 0.1127, Magnification: 100, MaxIterations: 500, SizeX: 48, SizeY: 32, AntiAlias: 2, Continuous: true, Palette: DefaultPalette()},
+		{CenterX: -0.75, Magnification: 0.4, SizeX: 40, SizeY: 30, Detail: "draft", Palette: DefaultPalette()},
+		{CenterX: -0.1011, CenterY: 0.9563, Magnification: 40, SizeX: 36, SizeY: 36, AntiAlias: 1, Continuous: true, Palette: []color.NRGBA{{255, 0, 0, 255}, {0, 0, 255, 255}}, InsideColor: color.NRGBA{10, 20, 30, 255}, Coloring: "histogram", Bailout: 4},
+	}
+	for i := range tests {
+		p := &tests[i]
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		var saved bytes.Buffer
+		if err := p.Save(&saved); err != nil {
+			t.Fatal(err)
+		}
+		q, err := LoadParameters(bytes.NewReader(saved.Bytes()))
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		var again bytes.Buffer
+		if err := q.Save(&again); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(saved.Bytes(), again.Bytes()) {
+			t.Errorf("case %d: saved\n%s\nloaded and saved again\n%s", i, saved.Bytes(), again.Bytes())
+		}
+		want, err := p.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := q.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("case %d: loaded parameters render a different image", i)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package mandel
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/big"
+)
+
+const (
+	// magnification past which neighboring samples are too close together
+	// for float64 coordinates, so the field is computed by perturbation
+	perturbMagnification = 1e10
+
+	// an orbit whose value falls below this fraction of the reference
+	// orbit's squared magnitude has lost too much precision to trust
+	glitchTolerance = 1e-6
+
+	// references tried for glitched samples before giving up on them
+	maxReferences = 16
+
+	// the series approximation is trusted while its cubic term stays
+	// below this fraction of its linear term for the farthest sample
+	seriesTolerance = 1e-12
+)
+
+// confidence values recorded for each sample in GenerateWithGlitchMap
+const (
+	confidenceDirect     = 255 // iterated against the main reference
+	confidenceReferenced = 192 // fixed with a later reference
+	confidenceGlitched   = 0   // never fixed; iterated in plain float64
+)
+
+// perturbed reports whether the field is computed by perturbation around
+// a high-precision reference orbit instead of directly in float64.
+func (p *Parameters) perturbed() bool {
+	return math.Abs(p.Magnification) >= perturbMagnification &&
+		p.quadratic() && p.norm == nil && !p.CurvatureColor && !p.CompensatedSum
+}
+
+// precision is the number of mantissa bits for reference orbits.
+func (p *Parameters) precision() uint {
+	if p.Precision > 0 {
+		return p.Precision
+	}
+
+	// enough for the center plus a full float64 offset at this depth
+	return uint(math.Ceil(math.Log2(math.Abs(p.Magnification)))) + 128
+}
+
+// perturbField computes the escape field by perturbation. One reference
+// orbit at the center of the image is computed with big.Float, and each
+// sample iterates only its small difference from that orbit in float64,
+// starting where a series approximation around the reference stops being
+// accurate. Samples that fail the glitch test |Z+z| < 10⁻³·|Z| are
+// iterated again against a new reference chosen among them, up to
+// maxReferences times.
+func (p *Parameters) perturbField(continuous bool) *Field {
+	bailout := p.bailout(continuous)
+	smooth := p.smoother(continuous, 2)
+	iterate := func(ref [][2]float64, dca, dcb float64, s *series) (float64, bool) {
+		return perturb(ref, p.MaxIterations, dca, dcb, s, smooth, bailout)
+	}
+	direct := func(x, y float64) float64 {
+		return p.escape(p.MaxIterations, x, y, continuous)
+	}
+	return p.perturbSamples(bailout, iterate, direct)
+}
+
+// perturbDistanceField is DistanceEstimate by perturbation, tracking the
+// derivative of the full orbit alongside the difference from the
+// reference.
+func (p *Parameters) perturbDistanceField() *Field {
+	bailout := float64(1 << 20)
+	iterate := func(ref [][2]float64, dca, dcb float64, s *series) (float64, bool) {
+		return perturbDistance(ref, p.MaxIterations, dca, dcb, s, bailout)
+	}
+	direct := func(x, y float64) float64 {
+		return distance(p.MaxIterations, x, y)
+	}
+	return p.perturbSamples(bailout, iterate, direct)
+}
+
+// perturbSamples runs iterate on every sample against the reference
+// orbits of perturbField, and direct in plain float64 on the samples that
+// stay glitched.
+func (p *Parameters) perturbSamples(bailout float64, iterate func(ref [][2]float64, dca, dcb float64, s *series) (float64, bool), direct func(x, y float64) float64) *Field {
+	f := newField(p)
+	status := make([]uint8, len(f.Values))
+	offsets := make([][2]float64, len(f.Values))
+	radius := 0.0
+	for j := 0; j < f.Height; j++ {
+		for i := 0; i < f.Width; i++ {
+			dx, dy := p.sampleOffset(i, j)
+			offsets[j*f.Width+i] = [2]float64{dx, dy}
+			radius = math.Max(radius, math.Hypot(dx, dy))
+		}
+	}
+
+	ref := p.referenceOrbit(0, 0, bailout)
+	s := newSeries(ref, radius)
+	var glitched []int
+	glitchedRows := make([][]int, f.Height)
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			k := j*f.Width + i
+			v, ok := iterate(ref, offsets[k][0], offsets[k][1], s)
+			f.Values[k] = v
+			if !ok {
+				glitchedRows[j] = append(glitchedRows[j], k)
+			}
+		}
+	})
+	for _, row := range glitchedRows {
+		glitched = append(glitched, row...)
+	}
+
+	// re-reference the glitched samples, a row's worth at a time
+	for round := 0; round < maxReferences && len(glitched) > 0 && !p.cancelled(); round++ {
+		center := offsets[glitched[len(glitched)/2]]
+		ref = p.referenceOrbit(center[0], center[1], bailout)
+		chunks := (len(glitched) + f.Width - 1) / f.Width
+		still := make([][]int, chunks)
+		p.forRows(chunks, func(n int) {
+			end := (n + 1) * f.Width
+			if end > len(glitched) {
+				end = len(glitched)
+			}
+			for _, k := range glitched[n*f.Width : end] {
+				dx, dy := offsets[k][0]-center[0], offsets[k][1]-center[1]
+				v, ok := iterate(ref, dx, dy, nil)
+				f.Values[k] = v
+				status[k] = 1
+				if !ok {
+					still[n] = append(still[n], k)
+				}
+			}
+		})
+		glitched = glitched[:0]
+		for _, chunk := range still {
+			glitched = append(glitched, chunk...)
+		}
+	}
+
+	// whatever is left gets the best float64 can do
+	for _, k := range glitched {
+		x, y := p.CenterX+offsets[k][0], p.CenterY+offsets[k][1]
+		f.Values[k] = direct(x, y)
+		status[k] = 2
+	}
+
+	if p.confidence != nil {
+		p.fillConfidence(f, status)
+	}
+	return f
+}
+
+// initPreciseCenter parses PreciseX and PreciseY at the precision of the
+// reference orbit and rounds them into CenterX and CenterY. Either may be
+// blank to use the float64 field instead, and both are ignored when the
+// view is given by bounds.
+func (p *Parameters) initPreciseCenter() error {
+	p.preciseX, p.preciseY = nil, nil
+	if (p.PreciseX == "" && p.PreciseY == "") || p.boundsSet() {
+		return nil
+	}
+	prec := p.precision()
+	parse := func(name, s string, v float64) (*big.Float, error) {
+		if s == "" {
+			return new(big.Float).SetPrec(prec).SetFloat64(v), nil
+		}
+		f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid precise center %s %q", name, s)
+		}
+		return f, nil
+	}
+	x, err := parse("x", p.PreciseX, p.CenterX)
+	if err != nil {
+		return err
+	}
+	y, err := parse("y", p.PreciseY, p.CenterY)
+	if err != nil {
+		return err
+	}
+	p.preciseX, p.preciseY = x, y
+	p.CenterX, _ = x.Float64()
+	p.CenterY, _ = y.Float64()
+	return nil
+}
+
+// referenceOrbit computes the orbit of the point at offset (dx, dy) from
+// the center of the image with big.Float, rounding each step to float64.
+// The orbit runs until it escapes or reaches MaxIterations, and entry
+// n-1 holds z_n, where z_1 = c.
+func (p *Parameters) referenceOrbit(dx, dy, bailout float64) [][2]float64 {
+	prec := p.precision()
+	newFloat := func(v float64) *big.Float {
+		return new(big.Float).SetPrec(prec).SetFloat64(v)
+	}
+	cx, cy := newFloat(p.CenterX), newFloat(p.CenterY)
+	if p.preciseX != nil {
+		cx.Set(p.preciseX)
+		cy.Set(p.preciseY)
+	}
+	cx.Add(cx, newFloat(dx))
+	cy.Add(cy, newFloat(dy))
+
+	a, b := newFloat(0).Set(cx), newFloat(0).Set(cy)
+	a2, b2, ab := newFloat(0), newFloat(0), newFloat(0)
+	orbit := make([][2]float64, 0, p.MaxIterations)
+	for iters := 1; iters <= p.MaxIterations; iters++ {
+		x, _ := a.Float64()
+		y, _ := b.Float64()
+		orbit = append(orbit, [2]float64{x, y})
+		if float64(x*x)+float64(y*y) >= bailout {
+			break
+		}
+		a2.Mul(a, a)
+		b2.Mul(b, b)
+		ab.Mul(a, b)
+		a.Sub(a2, b2)
+		a.Add(a, cx)
+		b.Add(ab, ab)
+		b.Add(b, cy)
+	}
+	return orbit
+}
+
+// series approximates the difference z between an orbit and the
+// reference orbit as A·dc + B·dc² + C·dc³ in the difference dc between
+// their points, which lets every sample skip the iterations where the
+// approximation holds. The coefficients are those of z_start, where
+// z_1 = dc.
+type series struct {
+	start   int
+	a, b, c [2]float64
+}
+
+// newSeries iterates the series coefficients along the reference orbit,
+// with A ← 2·Z·A + 1, B ← 2·Z·B + A², and C ← 2·Z·C + 2·A·B, for as long as
+// the cubic term stays negligible for samples within radius of the
+// reference. It returns nil if no iterations can be skipped.
+func newSeries(ref [][2]float64, radius float64) *series {
+	s := &series{start: 1, a: [2]float64{1, 0}}
+	mul := func(u, v [2]float64) [2]float64 {
+		return [2]float64{float64(u[0]*v[0]) - float64(u[1]*v[1]), float64(u[0]*v[1]) + float64(u[1]*v[0])}
+	}
+	// stop short of the end of the reference, which the samples must
+	// still check for escape against
+	for n := 1; n < len(ref)-1; n++ {
+		z2 := [2]float64{2 * ref[n-1][0], 2 * ref[n-1][1]}
+		za, zb, zc := mul(z2, s.a), mul(z2, s.b), mul(z2, s.c)
+		a2, ab := mul(s.a, s.a), mul(s.a, s.b)
+		next := series{
+			start: n + 1,
+			a:     [2]float64{za[0] + 1, za[1]},
+			b:     [2]float64{zb[0] + a2[0], zb[1] + a2[1]},
+			c:     [2]float64{zc[0] + 2*ab[0], zc[1] + 2*ab[1]},
+		}
+		cubic := math.Hypot(next.c[0], next.c[1]) * radius * radius
+		if !(cubic <= seriesTolerance*math.Hypot(next.a[0], next.a[1])) {
+			break
+		}
+		*s = next
+	}
+	if s.start == 1 {
+		return nil
+	}
+	return s
+}
+
+// at evaluates the series for the difference dc = dca + dcb·i.
+func (s *series) at(dca, dcb float64) (a, b float64) {
+	// Horner's rule: ((C·dc + B)·dc + A)·dc
+	a, b = s.c[0], s.c[1]
+	for _, k := range [][2]float64{s.b, s.a} {
+		a, b = float64(a*dca)-float64(b*dcb)+k[0], float64(a*dcb)+float64(b*dca)+k[1]
+	}
+	return float64(a*dca) - float64(b*dcb), float64(a*dcb) + float64(b*dca)
+}
+
+// derivative evaluates the derivative A + 2·B·dc + 3·C·dc² of the series
+// with respect to dc, which is also dz/dc of the orbit.
+func (s *series) derivative(dca, dcb float64) (a, b float64) {
+	a, b = 3*s.c[0], 3*s.c[1]
+	for _, k := range [][2]float64{{2 * s.b[0], 2 * s.b[1]}, s.a} {
+		a, b = float64(a*dca)-float64(b*dcb)+k[0], float64(a*dcb)+float64(b*dca)+k[1]
+	}
+	return a, b
+}
+
+// perturb iterates the difference z between an orbit and the reference
+// orbit Z, starting from the difference dc between their points, with
+// z ← 2·Z·z + z² + dc. A series, if given, supplies z for the iterations
+// it skips. It returns the escape value of the orbit, and false if the
+// orbit glitched or outran the reference.
+func perturb(ref [][2]float64, maxIters int, dca, dcb float64, s *series, smooth smoother, bailout float64) (float64, bool) {
+	a, b := dca, dcb
+	start := 1
+	if s != nil {
+		a, b = s.at(dca, dcb)
+		start = s.start
+	}
+	for iters := start; iters <= maxIters; iters++ {
+		if iters > len(ref) {
+			return 0.0, false
+		}
+		x, y := ref[iters-1][0], ref[iters-1][1]
+		za, zb := x+a, y+b
+		mag2 := float64(za*za) + float64(zb*zb)
+		if mag2 >= bailout {
+			if smooth != nil {
+				return smooth(iters, mag2, bailout), true
+			}
+			return float64(iters), true
+		}
+		if mag2 < glitchTolerance*(float64(x*x)+float64(y*y)) {
+			return 0.0, false
+		}
+		na := 2*(float64(x*a)-float64(y*b)) + float64(a*a) - float64(b*b) + dca
+		nb := 2*(float64(x*b)+float64(y*a)+float64(a*b)) + dcb
+		a, b = na, nb
+	}
+	return 0.0, true
+}
+
+// perturbDistance is perturb for the distance estimate. Alongside z it
+// tracks the derivative of the full orbit Z+z with respect to c, with
+// dz ← 2·(Z+z)·dz + 1, and it returns the distance estimate of distance,
+// or 0 if the orbit does not escape.
+func perturbDistance(ref [][2]float64, maxIters int, dca, dcb float64, s *series, bailout float64) (float64, bool) {
+	a, b := dca, dcb
+	da, db := 1.0, 0.0
+	start := 1
+	if s != nil {
+		a, b = s.at(dca, dcb)
+		da, db = s.derivative(dca, dcb)
+		start = s.start
+	}
+	for iters := start; iters <= maxIters; iters++ {
+		if iters > len(ref) {
+			return 0.0, false
+		}
+		x, y := ref[iters-1][0], ref[iters-1][1]
+		za, zb := x+a, y+b
+		mag2 := float64(za*za) + float64(zb*zb)
+		if mag2 >= bailout {
+			mag := math.Sqrt(mag2)
+			return mag * math.Log(mag) / math.Hypot(da, db), true
+		}
+		if mag2 < glitchTolerance*(float64(x*x)+float64(y*y)) {
+			return 0.0, false
+		}
+		da, db = 2*(float64(za*da)-float64(zb*db))+1, 2*(float64(za*db)+float64(zb*da))
+		na := 2*(float64(x*a)-float64(y*b)) + float64(a*a) - float64(b*b) + dca
+		nb := 2*(float64(x*b)+float64(y*a)+float64(a*b)) + dcb
+		a, b = na, nb
+	}
+	return 0.0, true
+}
+
+// fillConfidence averages the confidence of each pixel's samples into
+// the confidence map.
+func (p *Parameters) fillConfidence(f *Field, status []uint8) {
+	levels := [3]int{confidenceDirect, confidenceReferenced, confidenceGlitched}
+	aa := f.AntiAlias
+	for row := 0; row < f.Height/aa; row++ {
+		for col := 0; col < f.Width/aa; col++ {
+			sum := 0
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					sum += levels[status[j*f.Width+i]]
+				}
+			}
+			p.confidence.SetGray(col, row, color.Gray{uint8(sum / (aa * aa))})
+		}
+	}
+}
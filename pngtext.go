@@ -0,0 +1,96 @@
+package mandel
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+)
+
+// keyword of the iTXt chunk that holds the parameters in a PNG file
+const pngParametersKeyword = "mandel.parameters"
+
+// length of the PNG signature and the IHDR chunk that must follow it
+const pngHeaderLength = 8 + 12 + 13
+
+// EncodePNG writes img as a PNG with the parameters saved in an iTXt
+// chunk, in the same JSON as Save, so the image documents how it was made
+// and ReadPNGParameters can load them back to render it again.
+func (p *Parameters) EncodePNG(w io.Writer, img image.Image) error {
+	chunk, err := p.parametersChunk()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if _, err := w.Write(data[:pngHeaderLength]); err != nil {
+		return err
+	}
+	if err := writePNGChunk(w, "iTXt", chunk); err != nil {
+		return err
+	}
+	_, err = w.Write(data[pngHeaderLength:])
+	return err
+}
+
+// ReadPNGParameters loads the parameters saved in a PNG file by EncodePNG
+// or GenerateTo, and calls Init on them as LoadParameters does.
+func ReadPNGParameters(r io.Reader) (*Parameters, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading PNG: %v", err)
+	}
+	if len(data) < 8 || string(data[:8]) != "\x89PNG\r\n\x1a\n" {
+		return nil, fmt.Errorf("not a PNG file")
+	}
+	for rest := data[8:]; len(rest) >= 12; {
+		length := int(binary.BigEndian.Uint32(rest[:4]))
+		if length > len(rest)-12 {
+			break
+		}
+		kind, chunk := string(rest[4:8]), rest[8:8+length]
+		rest = rest[12+length:]
+		if kind == "IEND" {
+			break
+		}
+		if kind != "iTXt" || !bytes.HasPrefix(chunk, []byte(pngParametersKeyword+"\x00")) {
+			continue
+		}
+
+		// compression flag and method, then the language tag and the
+		// translated keyword, each ending in a zero byte
+		fields := bytes.SplitN(chunk[len(pngParametersKeyword)+3:], []byte{0}, 3)
+		if len(fields) != 3 || chunk[len(pngParametersKeyword)+1] != 1 {
+			return nil, fmt.Errorf("invalid parameters chunk in PNG")
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(fields[2]))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing parameters: %v", err)
+		}
+		return LoadParameters(zr)
+	}
+	return nil, fmt.Errorf("no parameters found in PNG")
+}
+
+// parametersChunk builds the iTXt chunk data for the parameters, with the
+// JSON compressed.
+func (p *Parameters) parametersChunk() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(pngParametersKeyword)
+	buf.Write([]byte{0, 1, 0, 0, 0})
+	zw := zlib.NewWriter(&buf)
+	if err := p.Save(zw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package mandel
+
+import (
+	"context"
+	"image"
+)
+
+// Pool is a fixed set of worker goroutines shared by many renders, so a
+// busy caller such as a tile server does not start new goroutines for
+// every image and has a fixed bound on rendering concurrency.
+type Pool struct {
+	jobs    chan func()
+	workers int
+}
+
+// NewPool starts a pool with the given number of workers.
+func NewPool(workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	pool := &Pool{jobs: make(chan func()), workers: workers}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range pool.jobs {
+				job()
+			}
+		}()
+	}
+	return pool
+}
+
+// Close stops the workers once they finish the rows already handed out.
+// The pool must not be used after Close.
+func (pool *Pool) Close() {
+	close(pool.jobs)
+}
+
+// Render renders an image exactly as Generate does, with the rows handed
+// to the pool's workers instead of goroutines of its own.
+func (pool *Pool) Render(p *Parameters) (*image.NRGBA, error) {
+	if err := p.checkInit("Render"); err != nil {
+		return nil, err
+	}
+	return pool.RenderContext(context.Background(), p)
+}
+
+// RenderContext is Render, but it stops early and returns ctx.Err() if
+// ctx is cancelled before the image is finished.
+func (pool *Pool) RenderContext(ctx context.Context, p *Parameters) (*image.NRGBA, error) {
+	if err := p.checkInit("RenderContext"); err != nil {
+		return nil, err
+	}
+	q := *p
+	q.pool = pool
+	return q.GenerateContext(ctx)
+}
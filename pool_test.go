@@ -0,0 +1,68 @@
+package mandel
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"testing"
+)
+
+// TestPoolRender checks that Pool.Render gives the pixels Generate does,
+// including for the settings that take their own render paths, and that
+// it reports and stops on cancellation as Generate does.
+func TestPoolRender(t *testing.T) {
+	pool := NewPool(2)
+	defer pool.Close()
+
+	base := Parameters{CenterX: -0.75, CenterY: 0.1, Magnification: 0.6, MaxIterations: 300, SizeX: 40, SizeY: 30, AntiAlias: 2, Continuous: true, Palette: DefaultPalette()}
+	tests := []struct {
+		name string
+		set  func(p *Parameters)
+	}{
+		{"plain", func(p *Parameters) {}},
+		{"supersample", func(p *Parameters) { p.Supersample = 2 }},
+		{"layers", func(p *Parameters) {
+			p.Layers = []Layer{
+				{Palette: DefaultPalette()},
+				{Palette: []color.NRGBA{{255, 255, 255, 128}}, MinIterations: 10, Blend: "screen"},
+			}
+		}},
+		{"symmetry", func(p *Parameters) { p.CenterY = 0; p.Symmetry = true }},
+		{"histogram", func(p *Parameters) { p.Coloring = "histogram" }},
+		{"adaptive", func(p *Parameters) { p.AdaptiveAA = true }},
+	}
+	for _, test := range tests {
+		p := base
+		test.set(&p)
+		var reported bool
+		p.Report = func(r *RenderReport) { reported = true }
+		if err := p.Init(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		want, err := p.Generate()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		reported = false
+		got, err := pool.Render(&p)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("%s: Pool.Render and Generate give different pixels", test.name)
+		}
+		if !reported {
+			t.Errorf("%s: Pool.Render did not call Report", test.name)
+		}
+	}
+
+	p := base
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.RenderContext(ctx, &p); err != context.Canceled {
+		t.Errorf("a cancelled render returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,68 @@
+package mandel
+
+import "sync/atomic"
+
+// progress counts completed rows across every pass of a render and
+// reports them to a Progress callback from its own goroutine, so a slow
+// callback never holds up the workers. Reports are coalesced: the
+// callback sees the latest counts, not every row.
+type progress struct {
+	fn          func(rowsDone, rowsTotal int)
+	done, total int64
+	fixed       bool // total was announced up front, so add ignores passes
+	wake        chan struct{}
+	finished    chan struct{}
+}
+
+func newProgress(fn func(rowsDone, rowsTotal int)) *progress {
+	pr := &progress{
+		fn:       fn,
+		wake:     make(chan struct{}, 1),
+		finished: make(chan struct{}),
+	}
+	go func() {
+		for range pr.wake {
+			// done first, since total only grows and must not fall behind
+			done := atomic.LoadInt64(&pr.done)
+			pr.fn(int(done), int(atomic.LoadInt64(&pr.total)))
+		}
+		close(pr.finished)
+	}()
+	return pr
+}
+
+// expect announces every row of a render that is done in several passes,
+// such as the bands of GenerateTo, before any of them start.
+func (pr *progress) expect(rows int) {
+	if pr != nil {
+		atomic.StoreInt64(&pr.total, int64(rows))
+		pr.fixed = true
+	}
+}
+
+// add announces a pass over more rows. A nil progress ignores it.
+func (pr *progress) add(rows int) {
+	if pr != nil && !pr.fixed {
+		atomic.AddInt64(&pr.total, int64(rows))
+	}
+}
+
+// rowDone counts a finished row. A nil progress ignores it.
+func (pr *progress) rowDone() {
+	if pr == nil {
+		return
+	}
+	atomic.AddInt64(&pr.done, 1)
+	select {
+	case pr.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop delivers any pending report and waits for the reporter to exit.
+func (pr *progress) stop() {
+	if pr != nil {
+		close(pr.wake)
+		<-pr.finished
+	}
+}
@@ -0,0 +1,67 @@
+package mandel
+
+import (
+	"context"
+	"image"
+)
+
+// the fractions of the full size that GenerateProgressive previews
+// the image at, coarsest first
+var progressiveScales = []int{8, 4, 2}
+
+// GenerateProgressive renders the image like GenerateContext, but first
+// renders quick previews at 1/8, 1/4, and 1/2 of the width and height,
+// so a viewer can show something right away while a slow render
+// finishes. Previews leave out anti-aliasing, multiple passes, and the
+// density overlay. Each preview is scaled back up to the full size and
+// handed to pass along with its scale, and the finished image is handed
+// over with a scale of 1 before it is returned. Previews too small to
+// show anything are skipped. The previews add about a third to the work
+// of a render without anti-aliasing, and less with it.
+func (p *Parameters) GenerateProgressive(ctx context.Context, pass func(img *image.NRGBA, scale int)) (*image.NRGBA, error) {
+	if err := p.checkInit("GenerateProgressive"); err != nil {
+		return nil, err
+	}
+	for _, scale := range progressiveScales {
+		q := *p
+		q.SizeX = (p.SizeX + scale - 1) / scale
+		q.SizeY = (p.SizeY + scale - 1) / scale
+		if q.SizeX < 2 || q.SizeY < 2 {
+			continue
+		}
+		q.AntiAlias, q.Passes, q.DensityOverlay = 1, 0, 0
+		q.Progress = nil
+
+		// keep the iterations chosen for the full image
+		q.autoIters = 0
+		if err := q.Init(); err != nil {
+			return nil, err
+		}
+		preview, err := q.GenerateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pass(upscale(preview, scale, p.SizeX, p.SizeY), scale)
+	}
+
+	canvas, err := p.GenerateContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pass(canvas, 1)
+	return canvas, nil
+}
+
+// upscale enlarges img by scale, repeating each pixel in a square, and
+// trims it to width by height.
+func upscale(img *image.NRGBA, scale, width, height int) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		src := img.Pix[img.PixOffset(0, y/scale):]
+		dst := out.Pix[out.PixOffset(0, y):]
+		for x := 0; x < width; x++ {
+			copy(dst[4*x:4*x+4], src[4*(x/scale):])
+		}
+	}
+	return out
+}
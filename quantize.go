@@ -0,0 +1,169 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"math"
+)
+
+// the 8×8 Bayer matrix of ordered dithering, with thresholds from 0 to 63
+var bayer8 = [8][8]float64{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// initQuantize checks the settings for paletted output.
+func (p *Parameters) initQuantize() error {
+	switch p.Dither {
+	case "", "floyd-steinberg", "ordered", "none":
+	default:
+		return fmt.Errorf("unknown dithering %q", p.Dither)
+	}
+	switch p.QuantizePalette {
+	case "", "render":
+		if p.Output.Indexed && (len(p.Palette) == 0 || p.duotone()) {
+			return fmt.Errorf("paletted output needs a palette to quantize to")
+		}
+	case "websafe", "plan9":
+	default:
+		return fmt.Errorf("unknown quantization palette %q", p.QuantizePalette)
+	}
+	return nil
+}
+
+// QuantizeColors returns the colors that paletted output is quantized to,
+// as chosen by QuantizePalette. The render palette is InsideColor followed
+// by up to 255 colors spread evenly over Palette. With Transparent set,
+// the last color is transparent instead, so GIF output keeps the
+// transparency.
+func (p *Parameters) QuantizeColors() (color.Palette, error) {
+	if err := p.checkInit("QuantizeColors"); err != nil {
+		return nil, err
+	}
+	return p.quantizeColors(), nil
+}
+
+// quantizeColors is QuantizeColors without the check.
+func (p *Parameters) quantizeColors() color.Palette {
+	var pal color.Palette
+	switch p.QuantizePalette {
+	case "websafe":
+		pal = palette.WebSafe
+	case "plan9":
+		pal = palette.Plan9
+	default:
+		pal = color.Palette{p.InsideColor}
+		n := len(p.Palette)
+		if n > 255 {
+			n = 255
+		}
+		for i := 0; i < n; i++ {
+			pal = append(pal, p.Palette[i*len(p.Palette)/n])
+		}
+	}
+	if p.Transparent == "" {
+		return pal
+	}
+	if len(pal) == 256 {
+		pal = pal[:255]
+	}
+	return append(append(color.Palette{}, pal...), color.NRGBA{})
+}
+
+// QuantizeImage maps a finished image onto the colors of QuantizeColors,
+// dithering it as Dither asks, for formats such as GIF that only hold a
+// palette.
+func (p *Parameters) QuantizeImage(img *image.NRGBA) (*image.Paletted, error) {
+	if err := p.checkInit("QuantizeImage"); err != nil {
+		return nil, err
+	}
+	if (p.QuantizePalette == "" || p.QuantizePalette == "render") && (len(p.Palette) == 0 || p.duotone()) {
+		return nil, fmt.Errorf("quantizing to the render palette needs a palette")
+	}
+	rect := img.Bounds()
+	colors := make([]float64, 0, 4*rect.Dx()*rect.Dy())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)]
+		for _, v := range row {
+			colors = append(colors, float64(v)/255)
+		}
+	}
+	return p.quantize(colors, rect, p.quantizeColors()), nil
+}
+
+// quantize maps colors with channels in [0, 1], four to a pixel and a row
+// at a time from the top, onto the colors of pal, dithering them as
+// Dither asks. Floyd–Steinberg diffusion works from the unrounded colors,
+// so smooth gradients dither evenly rather than in steps.
+func (p *Parameters) quantize(colors []float64, rect image.Rectangle, pal color.Palette) *image.Paletted {
+	img := image.NewPaletted(rect, pal)
+	entries := make([][4]float64, len(pal))
+	for i, c := range pal {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		entries[i] = [4]float64{float64(n.R) / 255, float64(n.G) / 255, float64(n.B) / 255, float64(n.A) / 255}
+	}
+
+	// ordered dithering nudges each pixel by up to about the spacing of
+	// the palette's colors, which is one step of a cube of them
+	spread := 1 / math.Cbrt(float64(len(pal)))
+
+	width := rect.Dx()
+	next := make([][4]float64, width+2)
+	below := make([][4]float64, width+2)
+	for row := 0; row < rect.Dy(); row++ {
+		next, below = below, next
+		for i := range below {
+			below[i] = [4]float64{}
+		}
+		for col := 0; col < width; col++ {
+			var c [4]float64
+			copy(c[:], colors[4*(row*width+col):])
+			switch p.Dither {
+			case "", "floyd-steinberg":
+				for k := range c {
+					c[k] += next[col+1][k]
+				}
+			case "ordered":
+				nudge := ((bayer8[row%8][col%8]+0.5)/64 - 0.5) * spread
+				for k := 0; k < 3; k++ {
+					c[k] += nudge
+				}
+			}
+			index := nearestColor(entries, c)
+			img.Pix[row*img.Stride+col] = uint8(index)
+			if p.Dither == "" || p.Dither == "floyd-steinberg" {
+				for k := range c {
+					e := c[k] - entries[index][k]
+					next[col+2][k] += e * 7 / 16
+					below[col][k] += e * 3 / 16
+					below[col+1][k] += e * 5 / 16
+					below[col+2][k] += e * 1 / 16
+				}
+			}
+		}
+	}
+	return img
+}
+
+// nearestColor returns the index of the entry closest to c.
+func nearestColor(entries [][4]float64, c [4]float64) int {
+	best, bestDist := 0, math.Inf(1)
+	for i, e := range entries {
+		d := 0.0
+		for k := range c {
+			d += (c[k] - e[k]) * (c[k] - e[k])
+		}
+		if d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
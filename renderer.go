@@ -0,0 +1,67 @@
+package mandel
+
+import "image"
+
+// Renderer renders an image a few pixels at a time on the goroutine that
+// calls Step, for hosts with a single thread, such as a browser running
+// the library compiled to WebAssembly, where a render has to hand control
+// back to the event loop often to keep the page responsive. Pixels are
+// rendered from the top left a row at a time, like GenerateRegion renders
+// them, so options that look at the whole image are left out, as are
+// contours, labels, and cropping.
+type Renderer struct {
+	p      Parameters
+	canvas *image.NRGBA
+
+	// pixels rendered so far, counting across each row from the top
+	done int
+}
+
+// NewRenderer starts an incremental render of p. Pixels that have not been
+// rendered yet are UnrenderedColor. p is copied, so later changes to it do
+// not affect the render.
+func (p *Parameters) NewRenderer() (*Renderer, error) {
+	if err := p.checkInit("NewRenderer"); err != nil {
+		return nil, err
+	}
+	r := &Renderer{p: *p}
+	r.canvas = r.p.newRegionCanvas(image.Rect(0, 0, p.SizeX, p.SizeY))
+	return r, nil
+}
+
+// Step renders up to n more pixels and returns the smallest rectangle
+// that holds them, so a caller can paint just the part of the image that
+// changed. It returns an empty rectangle once the render is done.
+func (r *Renderer) Step(n int) image.Rectangle {
+	width, total := r.p.SizeX, r.p.SizeX*r.p.SizeY
+	var changed image.Rectangle
+	for n > 0 && r.done < total {
+		row, start := r.done/width, r.done%width
+		end := start + n
+		if end > width {
+			end = width
+		}
+		r.p.calcSpan(r.canvas, start, end, row)
+		changed = changed.Union(image.Rect(start, row, end, row+1))
+		n -= end - start
+		r.done += end - start
+	}
+	return changed
+}
+
+// Done reports whether every pixel has been rendered.
+func (r *Renderer) Done() bool {
+	return r.done == r.p.SizeX*r.p.SizeY
+}
+
+// Pixels reports how many pixels have been rendered, out of how many in
+// all.
+func (r *Renderer) Pixels() (done, total int) {
+	return r.done, r.p.SizeX * r.p.SizeY
+}
+
+// Image returns the image as rendered so far. It is the same image
+// throughout the render, filled in by each Step.
+func (r *Renderer) Image() *image.NRGBA {
+	return r.canvas
+}
@@ -0,0 +1,274 @@
+package mandel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RenderReport describes the work that went into a render and how long
+// it took, for comparing settings and machines. It measures the render
+// itself, shortcuts and all.
+type RenderReport struct {
+	Width         int `json:"width"`
+	Height        int `json:"height"`
+	AntiAlias     int `json:"antialias"`
+	SampleCount   int `json:"samplecount,omitempty"` // set when it is not AntiAlias²
+	MaxIterations int `json:"maxiterations"`
+	Workers       int `json:"workers"`
+
+	// wall-clock time of the whole render, and of the part of it spent
+	// on shading, overlays, contours, labels, and cropping
+	Time         time.Duration `json:"time"`
+	DecorateTime time.Duration `json:"decoratetime"`
+
+	// every pass the render made over the rows of the image or of its
+	// sample grid, in order
+	Passes []PassReport `json:"passes"`
+
+	// samples iterated, the ones that never escaped, and the iterations
+	// the escaping ones ran, counted from their escape values. Samples
+	// that never escape are left out of the iterations, since the
+	// cardioid and periodicity checks stop most of them well before
+	// MaxIterations. Colorings that follow whole orbits, such as orbit
+	// traps, and renders by perturbation are not counted.
+	Samples             int64   `json:"samples"`
+	InsideSamples       int64   `json:"insidesamples"`
+	Iterations          int64   `json:"iterations"`
+	IterationsPerSecond float64 `json:"iterationspersecond"`
+
+	// work skipped by shortcuts: pixels colored from fewer samples than
+	// the anti-aliasing grid by AAFastPath, AdaptiveAA, or DEMaskedAA,
+	// pixels filled in by InteriorTiles, and rows copied by Symmetry
+	FastPixels   int64 `json:"fastpixels"`
+	TiledPixels  int64 `json:"tiledpixels"`
+	MirroredRows int64 `json:"mirroredrows"`
+
+	// memory the process had obtained from the system by the end of the
+	// render, which bounds the most it used at once
+	PeakMemory uint64 `json:"peakmemory"`
+
+	// the work of every pixel, and its summary, with ReportPixels
+	Pixels       *Stats        `json:"-"`
+	PixelSummary *StatsSummary `json:"pixels,omitempty"`
+}
+
+// PassReport times one pass of a render over a set of rows.
+type PassReport struct {
+	Rows     int             `json:"rows"`
+	Time     time.Duration   `json:"time"`
+	RowTimes []time.Duration `json:"rowtimes"`
+}
+
+// WriteJSON writes the report as JSON, with times in nanoseconds.
+func (r *RenderReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(r)
+}
+
+// String summarizes the report in a few lines of text.
+func (r *RenderReport) String() string {
+	var b strings.Builder
+	grid := fmt.Sprintf("%d×%d", r.AntiAlias, r.AntiAlias)
+	if r.SampleCount > 0 {
+		grid = fmt.Sprint(r.SampleCount)
+	}
+	fmt.Fprintf(&b, "rendered %dx%d with %s samples per pixel on %s in %v, %v of it decorating\n",
+		r.Width, r.Height, grid, plural(r.Workers, "worker"), r.Time.Round(time.Millisecond), r.DecorateTime.Round(time.Millisecond))
+	rows, slowest, slowestPass, slowestRow := 0, time.Duration(0), 0, 0
+	var total time.Duration
+	for i, pass := range r.Passes {
+		rows += pass.Rows
+		for row, t := range pass.RowTimes {
+			total += t
+			if t > slowest {
+				slowest, slowestPass, slowestRow = t, i, row
+			}
+		}
+	}
+	if rows > 0 {
+		fmt.Fprintf(&b, "%s over %d rows, %v per row on average, slowest row %d of pass %d in %v\n",
+			plural(len(r.Passes), "pass"), rows, (total / time.Duration(rows)).Round(time.Microsecond), slowestRow, slowestPass+1, slowest.Round(time.Microsecond))
+	}
+	if r.Samples > 0 {
+		fmt.Fprintf(&b, "%d samples, %.1f%% inside, %d iterations to escape at %.3g per second\n",
+			r.Samples, 100*float64(r.InsideSamples)/float64(r.Samples), r.Iterations, r.IterationsPerSecond)
+	}
+	if r.FastPixels > 0 || r.TiledPixels > 0 || r.MirroredRows > 0 {
+		fmt.Fprintf(&b, "shortcuts: %d pixels with fewer samples, %d tiled pixels, %d mirrored rows\n",
+			r.FastPixels, r.TiledPixels, r.MirroredRows)
+	}
+	if s := r.PixelSummary; s != nil {
+		fmt.Fprintf(&b, "pixels: %.1f iterations per escaping sample and %v per pixel on average, %.1f%% of the time inside the set\n",
+			s.MeanIterations, s.MeanTime, s.InsideShare*100)
+	}
+	fmt.Fprintf(&b, "%.1f MiB obtained from the system", float64(r.PeakMemory)/(1<<20))
+	return b.String()
+}
+
+// plural counts n of a noun that takes -s or -es in the plural.
+func plural(n int, noun string) string {
+	switch {
+	case n == 1:
+		return fmt.Sprintf("1 %s", noun)
+	case strings.HasSuffix(noun, "s"):
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// reporter gathers a RenderReport while a render runs. Its methods do
+// nothing on a nil reporter, so renders that are not reported pay only
+// for the check.
+type reporter struct {
+	// counted atomically, and first so they stay 64-bit aligned
+	samples, inside, iterations int64
+	fast, tiled, mirrored       int64
+
+	start  time.Time
+	mu     sync.Mutex
+	passes []*PassReport
+	pixels *pixelCounter
+}
+
+func newReporter(p *Parameters) *reporter {
+	r := &reporter{start: time.Now()}
+	if p.ReportPixels {
+		r.pixels = newPixelCounter(p)
+	}
+	return r
+}
+
+// pass starts timing a pass over rows rows.
+func (r *reporter) pass(rows int) *PassReport {
+	if r == nil {
+		return nil
+	}
+	pass := &PassReport{Rows: rows, RowTimes: make([]time.Duration, rows)}
+	r.mu.Lock()
+	r.passes = append(r.passes, pass)
+	r.mu.Unlock()
+	return pass
+}
+
+// escaped counts samples with escape values vs, iterated up to maxIters.
+func (r *reporter) escaped(maxIters int, vs ...float64) {
+	if r == nil {
+		return
+	}
+	var inside, iters int64
+	for _, v := range vs {
+		if v == 0 {
+			inside++
+			continue
+		}
+		iters += escapeIterations(maxIters, v)
+	}
+	atomic.AddInt64(&r.samples, int64(len(vs)))
+	atomic.AddInt64(&r.inside, inside)
+	atomic.AddInt64(&r.iterations, iters)
+}
+
+// escapeIterations is the number of iterations a sample with escape value
+// v ran, iterated up to maxIters.
+func escapeIterations(maxIters int, v float64) int64 {
+	return int64(math.Min(math.Ceil(v), float64(maxIters)))
+}
+
+// clock returns the time, for timing samples when the work of each pixel
+// is counted, or the zero time when it is not.
+func (r *reporter) clock() time.Time {
+	if r == nil || r.pixels == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// sampled counts samples at the points (xs[k], ys[k]), with escape values
+// vs, toward the pixels they fall in, if the work of each pixel is being
+// counted. They were iterated together up to maxIters from start, and
+// share the time equally, since batched samples run in lockstep.
+func (r *reporter) sampled(p *Parameters, start time.Time, maxIters int, xs, ys, vs []float64) {
+	if r == nil || r.pixels == nil || len(vs) == 0 {
+		return
+	}
+	c := r.pixels
+	share := int64(time.Since(start)) / int64(len(vs))
+	for k, v := range vs {
+		fx, fy := p.toPixel(xs[k], ys[k])
+		i := c.index(fx, fy)
+		atomic.AddInt64(&c.samples[i], 1)
+		atomic.AddInt64(&c.nanos[i], share)
+		if v == 0 {
+			atomic.AddInt64(&c.inside[i], 1)
+		} else {
+			atomic.AddInt64(&c.iterations[i], escapeIterations(maxIters, v))
+		}
+	}
+}
+
+// fastPixel, tiledPixels, and mirroredRows count the work skipped by
+// shortcuts.
+func (r *reporter) fastPixel() {
+	if r != nil {
+		atomic.AddInt64(&r.fast, 1)
+	}
+}
+
+func (r *reporter) tiledPixels(n int) {
+	if r != nil {
+		atomic.AddInt64(&r.tiled, int64(n))
+	}
+}
+
+func (r *reporter) mirroredRows(n int) {
+	if r != nil {
+		atomic.AddInt64(&r.mirrored, int64(n))
+	}
+}
+
+// finish fills in the report for p once the render is done, given when
+// its decoration started.
+func (r *reporter) finish(p *Parameters, decorated time.Time) *RenderReport {
+	now := time.Now()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	report := &RenderReport{
+		Width:         p.SizeX,
+		Height:        p.SizeY,
+		AntiAlias:     p.AntiAlias,
+		MaxIterations: p.MaxIterations,
+		Workers:       p.workers(),
+		Time:          now.Sub(r.start),
+		DecorateTime:  now.Sub(decorated),
+		Samples:       r.samples,
+		InsideSamples: r.inside,
+		Iterations:    r.iterations,
+		FastPixels:    r.fast,
+		TiledPixels:   r.tiled,
+		MirroredRows:  r.mirrored,
+		PeakMemory:    mem.Sys,
+	}
+	if p.samples != p.AntiAlias*p.AntiAlias {
+		report.SampleCount = p.samples
+	}
+	for _, pass := range r.passes {
+		report.Passes = append(report.Passes, *pass)
+	}
+	if r.pixels != nil {
+		report.Pixels = r.pixels.stats()
+		summary := report.Pixels.Summary()
+		report.PixelSummary = &summary
+	}
+	if report.Time > 0 {
+		report.IterationsPerSecond = float64(report.Iterations) / report.Time.Seconds()
+	}
+	return report
+}
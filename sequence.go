@@ -0,0 +1,116 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ZoomSequence describes an animation that moves from one view to
+// another over a number of frames.
+type ZoomSequence struct {
+	StartX, StartY, StartMagnification float64
+	EndX, EndY, EndMagnification       float64
+	Frames                             int
+
+	// "exponential" (default) zooms by the same factor every frame, which
+	// looks like a steady zoom; "linear" changes the magnification by the
+	// same amount every frame
+	Easing string
+
+	// when set, the palette cross-fades from the base palette to this one
+	// over the sequence
+	EndPalette []color.NRGBA
+
+	// rotate the palette this many times over the sequence, by stepping
+	// PaletteOffset from its starting value; negative turns it backward
+	PaletteCycles float64
+}
+
+// GenerateSequence renders the frames of a zoom sequence in order, using
+// p for everything but the view, and passes each frame to the frame
+// callback as it finishes so the caller can encode it without holding
+// the whole sequence in memory. Each frame's parameters are checked with
+// Init, and an error from Init or the callback ends the sequence.
+//
+// With exponential easing, the center moves in step with the width of the
+// view rather than with the frame number, so the end point drifts steadily
+// toward the middle of the image instead of racing there before the zoom
+// gets going.
+func (p *Parameters) GenerateSequence(s ZoomSequence, frame func(n int, img *image.NRGBA) error) error {
+	if s.Frames < 1 {
+		return fmt.Errorf("a zoom sequence needs at least one frame")
+	}
+	switch s.Easing {
+	case "", "exponential", "linear":
+	default:
+		return fmt.Errorf("unknown easing %q", s.Easing)
+	}
+
+	for n := 0; n < s.Frames; n++ {
+		t := 0.0
+		if s.Frames > 1 {
+			t = float64(n) / float64(s.Frames-1)
+		}
+
+		q := *p
+		w := t
+		if s.Easing == "linear" {
+			q.Magnification = s.StartMagnification + (s.EndMagnification-s.StartMagnification)*t
+		} else {
+			q.Magnification = s.StartMagnification * math.Pow(s.EndMagnification/s.StartMagnification, t)
+			if s.StartMagnification != s.EndMagnification {
+				w = (1/q.Magnification - 1/s.StartMagnification) / (1/s.EndMagnification - 1/s.StartMagnification)
+			}
+		}
+		if n == s.Frames-1 && s.Frames > 1 {
+			// land exactly on the end view
+			q.Magnification, w = s.EndMagnification, 1
+		}
+		q.CenterX = s.StartX + (s.EndX-s.StartX)*w
+		q.CenterY = s.StartY + (s.EndY-s.StartY)*w
+		q.PreciseX, q.PreciseY = "", ""
+		if s.EndPalette != nil {
+			q.Palette = InterpolatePalettes(p.Palette, s.EndPalette, t)
+		}
+		q.PaletteOffset = p.PaletteOffset + s.PaletteCycles*t
+
+		if err := q.Init(); err != nil {
+			return fmt.Errorf("frame %d: %v", n, err)
+		}
+		img, err := q.Generate()
+		if err != nil {
+			return err
+		}
+		if err := frame(n, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GeneratePaletteCycle renders an animation that turns the palette
+// cycles times over the given number of frames by stepping PaletteOffset,
+// passing each frame to the frame callback as it finishes. The escape
+// values are computed once and recolored for each frame, as with
+// Field.Colorize, so it costs little more than a single image. The frames
+// stop one step short of a whole number of turns, so the animation loops
+// without repeating a frame.
+func (p *Parameters) GeneratePaletteCycle(frames int, cycles float64, frame func(n int, img *image.NRGBA) error) error {
+	if err := p.checkInit("GeneratePaletteCycle"); err != nil {
+		return err
+	}
+	if frames < 1 {
+		return fmt.Errorf("a palette cycle needs at least one frame")
+	}
+	f := p.computeIterations()
+	for n := 0; n < frames; n++ {
+		q := *p
+		q.PaletteOffset = wrapPaletteOffset(p.PaletteOffset + cycles*float64(n)/float64(frames))
+		if err := frame(n, f.Colorize(&q)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
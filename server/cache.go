@@ -0,0 +1,54 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache is a least-recently-used set of encoded tiles.
+type cache struct {
+	sync.Mutex
+	size  int
+	order *list.List
+	tiles map[tile]*list.Element
+}
+
+type entry struct {
+	t    tile
+	data []byte
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:  size,
+		order: list.New(),
+		tiles: make(map[tile]*list.Element),
+	}
+}
+
+func (c *cache) get(t tile) ([]byte, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elt, ok := c.tiles[t]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elt)
+	return elt.Value.(*entry).data, true
+}
+
+func (c *cache) put(t tile, data []byte) {
+	c.Lock()
+	defer c.Unlock()
+	if elt, ok := c.tiles[t]; ok {
+		elt.Value.(*entry).data = data
+		c.order.MoveToFront(elt)
+		return
+	}
+	c.tiles[t] = c.order.PushFront(&entry{t, data})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.tiles, oldest.Value.(*entry).t)
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+
+	"github.com/russross/mandel"
+)
+
+// the most anti-aliasing a request may ask for, since every step multiplies
+// the work for each pixel
+const maxAntiAlias = 8
+
+// RenderHandler renders a single image for every request, taking the view
+// from query values with the same names as the JSON fields: x, y, m, i,
+// px, py, a, and c. Values that are missing come from the base parameters.
+// With progressive=true, the response is a multipart/x-mixed-replace
+// stream of PNGs, starting with quick previews and ending with the full
+// image, which browsers show in place as each one arrives.
+type RenderHandler struct {
+	base          mandel.Parameters
+	maxPixels     int
+	maxIterations int
+	disk          *mandel.DiskCache
+}
+
+// NewRenderHandler creates a handler that renders with the palette and
+// coloring settings of base. Requests for more than maxPixels pixels or
+// more than maxIterations iterations are refused.
+func NewRenderHandler(base *mandel.Parameters, maxPixels, maxIterations int) *RenderHandler {
+	return &RenderHandler{
+		base:          *base,
+		maxPixels:     maxPixels,
+		maxIterations: maxIterations,
+	}
+}
+
+// SetDiskCache keeps the images the handler renders in c, so repeated
+// requests are served without rendering again. Progressive renders are
+// not cached. Call it before serving any requests.
+func (h *RenderHandler) SetDiskCache(c *mandel.DiskCache) {
+	h.disk = c
+}
+
+func (h *RenderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p, err := h.parse(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	progressive := false
+	if s := r.URL.Query().Get("progressive"); s != "" {
+		if progressive, err = strconv.ParseBool(s); err != nil {
+			http.Error(w, fmt.Sprintf("invalid value %q for progressive: must be true or false", s), http.StatusBadRequest)
+			return
+		}
+	}
+	if progressive {
+		h.serveProgressive(w, r, p)
+		return
+	}
+
+	key := p.CacheKey("render png")
+	data, ok := h.disk.Get(key)
+	if !ok {
+		// stop rendering if the client goes away
+		canvas, err := p.GenerateContext(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, canvas); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = buf.Bytes()
+		h.disk.Put(key, data)
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// serveProgressive streams each pass of a progressive render as a part of
+// a multipart/x-mixed-replace response. Once the first part is sent, the
+// status can no longer change, so a render that fails just ends the
+// stream.
+func (h *RenderHandler) serveProgressive(w http.ResponseWriter, r *http.Request, p *mandel.Parameters) {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	flusher, _ := w.(http.Flusher)
+	failed := false
+	_, err := p.GenerateProgressive(r.Context(), func(img *image.NRGBA, scale int) {
+		if failed {
+			return
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			failed = true
+			return
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":   {"image/png"},
+			"Content-Length": {strconv.Itoa(buf.Len())},
+		})
+		if err == nil {
+			_, err = part.Write(buf.Bytes())
+		}
+		if err != nil {
+			failed = true
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if err == nil && !failed {
+		mw.Close()
+	}
+}
+
+// parse builds the parameters for a request and checks them against the
+// limits.
+func (h *RenderHandler) parse(query url.Values) (*mandel.Parameters, error) {
+	p := h.base
+	floats := []struct {
+		name string
+		v    *float64
+	}{
+		{"x", &p.CenterX},
+		{"y", &p.CenterY},
+		{"m", &p.Magnification},
+	}
+	for _, f := range floats {
+		if s := query.Get(f.name); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for %s: must be a number", s, f.name)
+			}
+			*f.v = v
+		}
+	}
+	ints := []struct {
+		name string
+		v    *int
+	}{
+		{"i", &p.MaxIterations},
+		{"px", &p.SizeX},
+		{"py", &p.SizeY},
+		{"a", &p.AntiAlias},
+	}
+	for _, f := range ints {
+		if s := query.Get(f.name); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for %s: must be an integer", s, f.name)
+			}
+			*f.v = v
+		}
+	}
+	if query.Get("x") != "" || query.Get("y") != "" {
+		p.PreciseX, p.PreciseY = "", ""
+	}
+	if s := query.Get("c"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for c: must be true or false", s)
+		}
+		p.Continuous = v
+	}
+
+	if p.SizeX > 0 && p.SizeY > 0 && p.SizeX > h.maxPixels/p.SizeY {
+		return nil, fmt.Errorf("image of %d×%d is too large: the limit is %d pixels", p.SizeX, p.SizeY, h.maxPixels)
+	}
+	if p.AntiAlias > maxAntiAlias {
+		return nil, fmt.Errorf("anti-aliasing of %d is too high: the limit is %d", p.AntiAlias, maxAntiAlias)
+	}
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+
+	// after Init, which may choose the iterations
+	if p.MaxIterations > h.maxIterations {
+		return nil, fmt.Errorf("%d iterations is too many: the limit is %d", p.MaxIterations, h.maxIterations)
+	}
+	return &p, nil
+}
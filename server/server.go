@@ -0,0 +1,239 @@
+// Package server serves the Mandelbrot set over HTTP as slippy-map tiles.
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/russross/mandel"
+)
+
+const (
+	TileSize = 256
+
+	// the whole map at zoom level 0 is a single square this wide,
+	// centered on the set
+	worldSize    = 4.0
+	worldCenterX = -0.75
+	worldCenterY = 0.0
+	maxZoom      = 40
+)
+
+type tile struct {
+	z, x, y int
+	style   style
+}
+
+// style holds the settings a tile request can override in its query
+// string: i for the maximum iterations, c for continuous coloring, and
+// palette for one of the built-in palettes. Settings a request leaves
+// out take their values from the base parameters, so equivalent requests
+// share cache entries.
+type style struct {
+	iterations int
+	continuous bool
+	palette    string
+}
+
+// TileServer renders tiles on demand at /z/x/y.png, keeping recent tiles
+// in memory. After serving a tile it renders the neighboring tiles in the
+// background so panning finds them already cached.
+type TileServer struct {
+	base          mandel.Parameters
+	maxIterations int
+	cache         *cache
+	disk          *mandel.DiskCache
+	queue         chan tile
+}
+
+// New creates a tile server that renders with the palette, iteration, and
+// coloring settings of base, which requests can override in their query
+// strings. Requests for more than maxIterations iterations are refused.
+// Up to cacheSize tiles are kept in memory, and prefetch goroutines
+// render neighboring tiles in the background. Each prefetch goroutine
+// renders on a single CPU, so prefetch never uses more than prefetch CPUs
+// no matter how busy the server is.
+func New(base *mandel.Parameters, cacheSize, prefetch, maxIterations int) *TileServer {
+	s := &TileServer{
+		base:          *base,
+		maxIterations: maxIterations,
+		cache:         newCache(cacheSize),
+		queue:         make(chan tile, 64),
+	}
+	s.base.SizeX, s.base.SizeY = TileSize, TileSize
+	for i := 0; i < prefetch; i++ {
+		go s.prefetcher()
+	}
+	return s
+}
+
+// SetDiskCache keeps the tiles the server renders in c as well, so they
+// outlast the memory cache and the server itself. Call it before serving
+// any requests.
+func (s *TileServer) SetDiskCache(c *mandel.DiskCache) {
+	s.disk = c
+}
+
+func (s *TileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t, err := parseTile(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if t.style, err = s.parseStyle(r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, ok := s.cache.get(t)
+	if !ok {
+		if data, err = s.render(t, false); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.cache.put(t, data)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+
+	// queue up the neighbors, dropping them if the prefetchers are behind
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			n := tile{t.z, t.x + dx, t.y + dy, t.style}
+			if n == t || !n.valid() {
+				continue
+			}
+			select {
+			case s.queue <- n:
+			default:
+			}
+		}
+	}
+}
+
+func (s *TileServer) prefetcher() {
+	for t := range s.queue {
+		if _, ok := s.cache.get(t); ok {
+			continue
+		}
+		if data, err := s.render(t, true); err == nil {
+			s.cache.put(t, data)
+		}
+	}
+}
+
+// render draws a tile and encodes it as a PNG. Background renders stay on
+// the calling goroutine instead of fanning out across every CPU.
+func (s *TileServer) render(t tile, background bool) ([]byte, error) {
+	p := s.base
+	size := worldSize / float64(uint64(1)<<uint(t.z))
+	minX := worldCenterX - worldSize/2 + float64(t.x)*size
+	maxY := worldCenterY + worldSize/2 - float64(t.y)*size
+	p.FitBounds(minX, maxY-size, minX+size, maxY)
+	p.MaxIterations, p.Continuous = t.style.iterations, t.style.continuous
+	if t.style.palette != "" {
+		palette, err := mandel.NamedPalette(t.style.palette)
+		if err != nil {
+			return nil, err
+		}
+		p.Palette = palette
+	}
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+	key := p.CacheKey("tile png")
+	if data, ok := s.disk.Get(key); ok {
+		return data, nil
+	}
+
+	var img *image.NRGBA
+	if background {
+		img = image.NewNRGBA(image.Rect(0, 0, p.SizeX, p.SizeY))
+		for row := 0; row < p.SizeY; row++ {
+			for col := 0; col < p.SizeX; col++ {
+				c, err := p.CalcPixel(col, row)
+				if err != nil {
+					return nil, err
+				}
+				img.Set(col, row, c)
+			}
+		}
+	} else {
+		var err error
+		if img, err = p.Generate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	// a tile that cannot be cached is still worth serving
+	s.disk.Put(key, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+func (t tile) valid() bool {
+	n := 1 << uint(t.z)
+	return t.z >= 0 && t.z <= maxZoom && t.x >= 0 && t.x < n && t.y >= 0 && t.y < n
+}
+
+// parseTile reads a tile address of the form /z/x/y.png.
+func parseTile(path string) (tile, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ".png") {
+		return tile{}, fmt.Errorf("tile paths must have the form /z/x/y.png")
+	}
+	parts[2] = strings.TrimSuffix(parts[2], ".png")
+	var n [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return tile{}, fmt.Errorf("invalid tile coordinate %q", part)
+		}
+		n[i] = v
+	}
+	t := tile{z: n[0], x: n[1], y: n[2]}
+	if !t.valid() {
+		return tile{}, fmt.Errorf("tile %d/%d/%d is out of range", t.z, t.x, t.y)
+	}
+	return t, nil
+}
+
+// parseStyle reads the tile settings from a query string and checks them
+// against the limits.
+func (s *TileServer) parseStyle(query url.Values) (style, error) {
+	st := style{iterations: s.base.MaxIterations, continuous: s.base.Continuous}
+	if v := query.Get("i"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return style{}, fmt.Errorf("invalid value %q for i: must be a positive integer", v)
+		}
+		st.iterations = n
+	}
+	if st.iterations > s.maxIterations {
+		return style{}, fmt.Errorf("%d iterations is too many: the limit is %d", st.iterations, s.maxIterations)
+	}
+	if v := query.Get("c"); v != "" {
+		c, err := strconv.ParseBool(v)
+		if err != nil {
+			return style{}, fmt.Errorf("invalid value %q for c: must be true or false", v)
+		}
+		st.continuous = c
+	}
+	if v := query.Get("palette"); v != "" {
+		if _, err := mandel.NamedPalette(v); err != nil {
+			return style{}, err
+		}
+		st.palette = v
+	}
+	return st, nil
+}
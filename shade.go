@@ -0,0 +1,181 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+const (
+	// share of the light that reaches a surface however it faces
+	shadeAmbient = 0.3
+
+	// strength and tightness of the Blinn-Phong highlight
+	shadeSpecular  = 0.4
+	shadeShininess = 24
+)
+
+// initShading checks the relief lighting settings.
+func (p *Parameters) initShading() error {
+	switch p.Shading {
+	case "", "none", "lambert", "phong":
+	default:
+		return fmt.Errorf("unknown shading %q", p.Shading)
+	}
+	if !(p.LightElevation >= 0 && p.LightElevation <= 90) {
+		return fmt.Errorf("light elevation must be between 0 and 90 degrees")
+	}
+	return nil
+}
+
+// shaded reports whether a shading stage lights the finished render.
+func (p *Parameters) shaded() bool {
+	return p.Shading == "lambert" || p.Shading == "phong"
+}
+
+// surfaceNormals returns, for every sample of the image, the direction
+// in the image that the potential of the set falls away fastest, as a
+// unit vector with x to the right and y up, or zero for interior samples.
+// It follows z/dz for z² + c, and the gradient of the continuous escape
+// value otherwise.
+func (p *Parameters) surfaceNormals() (nx, ny *Field) {
+	nx, ny = newField(p), newField(p)
+	if !p.quadratic() || p.perturbed() || p.ExpMap {
+		q := *p
+		q.Smoothing = ""
+		mu := q.computeField(true)
+		p.forRows(nx.Height, func(j int) {
+			for i := 0; i < nx.Width; i++ {
+				if mu.At(i, j) == 0 {
+					continue
+				}
+				// mu falls away from the set
+				gx, gy := p.gradient(mu, i, j, p.sampleImagePoint)
+				dx, dy := -gx, -gy
+				if h := math.Hypot(dx, dy); h > 0 {
+					nx.Values[j*nx.Width+i], ny.Values[j*ny.Width+i] = dx/h, dy/h
+				}
+			}
+		})
+		return nx, ny
+	}
+
+	p.forRows(nx.Height, func(j int) {
+		for i := 0; i < nx.Width; i++ {
+			x, y := p.samplePoint(i, j)
+			dx, dy, escaped := slopeDirection(p.MaxIterations, x, y)
+			if !escaped {
+				continue
+			}
+			dx, dy = p.unviewOffset(dx, dy)
+			if p.Magnification < 0 {
+				dx = -dx
+			}
+			if h := math.Hypot(dx, dy); h > 0 {
+				nx.Values[j*nx.Width+i], ny.Values[j*ny.Width+i] = dx/h, dy/h
+			}
+		}
+	})
+	return nx, ny
+}
+
+// slopeDirection iterates z² + c while tracking dz/dc like derivative,
+// and returns the direction of z/dz where the orbit escaped, which points
+// away from the set on the plane, or false if it does not escape. It
+// skips the interior with the same tests as mandel.
+func slopeDirection(maxIters int, x, y float64) (dx, dy float64, escaped bool) {
+	// points in the main cardioid or the period-2 bulb never escape
+	xq := x - 0.25
+	y2 := float64(y * y)
+	q := float64(xq*xq) + y2
+	if float64(q*(q+xq)) <= float64(0.25*y2) || float64((x+1)*(x+1))+y2 <= 0.0625 {
+		return 0, 0, false
+	}
+
+	bailout := float64(1 << 20)
+	a, b := x, y
+	ra, rb := a, b
+	next, interval := periodStart, periodStart
+	da, db := 1.0, 0.0
+	for iters := 1; iters <= maxIters; iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if a2+b2 >= bailout {
+			// z/dz = z·conj(dz)/|dz|², and only the direction matters
+			return float64(a*da) + float64(b*db), float64(b*da) - float64(a*db), true
+		}
+		da, db = 2*(float64(a*da)-float64(b*db))+1, 2*(float64(a*db)+float64(b*da))
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+		if math.Abs(a-ra) < periodEpsilon && math.Abs(b-rb) < periodEpsilon {
+			return 0, 0, false
+		}
+		if iters == next {
+			ra, rb = a, b
+			interval *= 2
+			next += interval
+		}
+	}
+	return 0, 0, false
+}
+
+// shadeRelief lights the outside of the set in a finished render as if
+// it were a surface sloping down at 45° away from the set, with a light
+// LightAngle degrees counterclockwise from the right of the image and
+// LightElevation degrees above it. Lambert shading scales each pixel by
+// how squarely the light falls on it, over a floor of ambient light, and
+// Phong shading adds a Blinn-Phong highlight. Each pixel takes the
+// average lighting of its samples, and interior samples are left unlit.
+func (p *Parameters) shadeRelief(canvas *image.NRGBA) {
+	// the render has already reported all of its progress
+	q := *p
+	q.progress = nil
+	p = &q
+
+	nx, ny := p.surfaceNormals()
+	azimuth := p.LightAngle * math.Pi / 180
+	elevation := p.LightElevation
+	if elevation == 0 {
+		elevation = 45
+	}
+	elevation *= math.Pi / 180
+	light := [3]float64{math.Cos(elevation) * math.Cos(azimuth), math.Cos(elevation) * math.Sin(azimuth), math.Sin(elevation)}
+
+	// the highlight is brightest on slopes facing halfway between the
+	// light and a viewer looking straight down
+	half := [3]float64{light[0], light[1], light[2] + 1}
+	hl := math.Sqrt(half[0]*half[0] + half[1]*half[1] + half[2]*half[2])
+	for k := range half {
+		half[k] /= hl
+	}
+
+	aa := nx.AntiAlias
+	p.forRows(p.SizeY, func(row int) {
+		for col := 0; col < p.SizeX; col++ {
+			diffuse, specular := 0.0, 0.0
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					x, y := nx.At(i, j), ny.At(i, j)
+					if x == 0 && y == 0 {
+						diffuse++
+						continue
+					}
+					n := [3]float64{x / math.Sqrt2, y / math.Sqrt2, 1 / math.Sqrt2}
+					lambert := math.Max(0, n[0]*light[0]+n[1]*light[1]+n[2]*light[2])
+					diffuse += shadeAmbient + (1-shadeAmbient)*lambert
+					if p.Shading == "phong" {
+						specular += shadeSpecular * math.Pow(math.Max(0, n[0]*half[0]+n[1]*half[1]+n[2]*half[2]), shadeShininess)
+					}
+				}
+			}
+			samples := float64(aa * aa)
+			diffuse, specular = diffuse/samples, specular/samples
+			pix := canvas.Pix[canvas.PixOffset(col, row):]
+			for k := 0; k < 3; k++ {
+				v := float64(pix[k])*diffuse + 255*specular
+				pix[k] = uint8(math.Max(0, math.Min(v, 255)) + 0.5)
+			}
+		}
+	})
+}
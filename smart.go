@@ -0,0 +1,83 @@
+package mandel
+
+import "math"
+
+// smartField computes the escape field in two passes. The first pass uses
+// a small fraction of MaxIterations, which settles every sample far from
+// the set. For the samples that have not escaped, the distance in samples
+// to the nearest escaped sample is a cheap estimate of how far they are
+// from the boundary. The second pass iterates those samples again with a
+// limit inversely proportional to that distance, so the full budget is
+// spent only next to the boundary, where interior and slow-escaping
+// exterior points are hard to tell apart.
+func (p *Parameters) smartField() *Field {
+	low := p.MaxIterations / 16
+	if low < 64 {
+		low = 64
+	}
+	if low >= p.MaxIterations || p.perturbed() {
+		return p.computeField(p.Continuous)
+	}
+
+	q := *p
+	q.MaxIterations = low
+	f := q.computeField(p.Continuous)
+	dist := escapeDistance(f)
+
+	p.forRows(f.Height, func(j int) {
+		for i := 0; i < f.Width; i++ {
+			k := j*f.Width + i
+			if f.Values[k] != 0 {
+				continue
+			}
+			limit := int(float64(p.MaxIterations) / math.Max(1, dist[k]))
+			if limit <= low {
+				continue
+			}
+			x, y := p.samplePoint(i, j)
+			f.Values[k] = p.escape(limit, x, y, p.Continuous)
+		}
+	})
+	return f
+}
+
+// escapeDistance finds the approximate distance, in samples, from every
+// sample to the nearest escaped sample using a two-pass chamfer transform.
+// Escaped samples are at distance 0.
+func escapeDistance(f *Field) []float64 {
+	const straight, diagonal = 1.0, math.Sqrt2
+	w, h := f.Width, f.Height
+	dist := make([]float64, w*h)
+	for k, v := range f.Values {
+		if v == 0 {
+			dist[k] = math.Inf(1)
+		}
+	}
+
+	relax := func(k, i, j int, d float64) {
+		if i >= 0 && i < w && j >= 0 && j < h && dist[j*w+i]+d < dist[k] {
+			dist[k] = dist[j*w+i] + d
+		}
+	}
+
+	// forward pass from the top left, then backward from the bottom right
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			k := j*w + i
+			relax(k, i-1, j, straight)
+			relax(k, i-1, j-1, diagonal)
+			relax(k, i, j-1, straight)
+			relax(k, i+1, j-1, diagonal)
+		}
+	}
+	for j := h - 1; j >= 0; j-- {
+		for i := w - 1; i >= 0; i-- {
+			k := j*w + i
+			relax(k, i+1, j, straight)
+			relax(k, i+1, j+1, diagonal)
+			relax(k, i, j+1, straight)
+			relax(k, i-1, j+1, diagonal)
+		}
+	}
+	return dist
+}
@@ -0,0 +1,58 @@
+package mandel
+
+import "math"
+
+// smoother turns the iteration on which an orbit escaped and its squared
+// magnitude there into a continuous escape value. A nil smoother means
+// discrete escape counts.
+type smoother func(iters int, mag2, bailout float64) float64
+
+// smoother picks the smoothing formula for an iteration that grows by
+// the given power near escape, or nil in discrete mode.
+func (p *Parameters) smoother(continuous bool, power int) smoother {
+	if !continuous {
+		return nil
+	}
+	if p.Smoothing == "linear" {
+		return func(iters int, mag2, bailout float64) float64 {
+			return linearEscape(iters, mag2, bailout, power)
+		}
+	}
+	if power == 2 {
+		return smoothEscape
+	}
+	return func(iters int, mag2, bailout float64) float64 {
+		return smoothEscapePower(iters, mag2, bailout, power)
+	}
+}
+
+// linearEscape interpolates linearly in |z| instead of taking the log-log
+// correction. An orbit that escapes with |z| at the escape radius R gets
+// iters+1, as with smoothEscape, and one that reaches R^power gets iters,
+// the farthest an orbit from inside the radius can get in one step.
+func linearEscape(iters int, mag2, bailout float64, power int) float64 {
+	r := math.Sqrt(bailout)
+	t := (math.Sqrt(mag2) - r) / (math.Pow(r, float64(power)) - r)
+	return float64(iters+1) - math.Max(0, math.Min(1, t))
+}
+
+// SmoothIterations returns the continuous escape value of the point
+// (x, y) with the iteration, bailout, and smoothing settings of p, whether
+// or not Continuous is set. Interior points return exactly 0, and escaping
+// points return a value above 0 that normally falls between the iteration
+// on which the orbit escaped and the one after. Points that are already
+// far past the escape radius after the first step come out below 1,
+// easing toward 0 as they start farther out. Bailout shapes
+// other than a circle have no continuous value and return discrete
+// counts, and CurvatureColor is ignored.
+func (p *Parameters) SmoothIterations(x, y float64) (float64, error) {
+	if err := p.checkInit("SmoothIterations"); err != nil {
+		return 0, err
+	}
+	if p.CurvatureColor {
+		q := *p
+		q.CurvatureColor = false
+		p = &q
+	}
+	return p.escape(p.MaxIterations, x, y, true), nil
+}
@@ -0,0 +1,114 @@
+package mandel
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSmoothEscapeSweep sweeps the magnitude at escape from just above the
+// bailout to the farthest one step can reach, for each smoothing formula,
+// and checks that escape values fall in (iters, iters+1], shrink steadily
+// as the magnitude grows, and meet the values of the next iteration at
+// the ends, so there is no kink anywhere from 1 upward.
+func TestSmoothEscapeSweep(t *testing.T) {
+	tests := []struct {
+		name    string
+		smooth  smoother
+		power   int
+		bailout float64
+	}{
+		{"log-log, radius 2", smoothEscape, 2, 4},
+		{"log-log, radius 16", smoothEscape, 2, 256},
+		{"log-log, power 3", func(i int, m, b float64) float64 { return smoothEscapePower(i, m, b, 3) }, 3, 4},
+		{"linear, radius 2", func(i int, m, b float64) float64 { return linearEscape(i, m, b, 2) }, 2, 4},
+		{"linear, power 4", func(i int, m, b float64) float64 { return linearEscape(i, m, b, 4) }, 4, 256},
+	}
+	const steps = 1000
+	for _, test := range tests {
+		far := math.Pow(test.bailout, float64(test.power))
+		for iters := 1; iters <= 4; iters++ {
+			prev := math.Inf(1)
+			for k := 0; k <= steps; k++ {
+				// step evenly in log |z|², starting just above the bailout
+				mag2 := test.bailout * math.Pow(far/test.bailout, float64(k)/steps)
+				if k == 0 {
+					mag2 = math.Nextafter(test.bailout, math.Inf(1))
+				}
+				v := test.smooth(iters, mag2, test.bailout)
+				if !(v >= float64(iters)-1e-9 && v <= float64(iters+1)+1e-9) {
+					t.Fatalf("%s: iteration %d, |z|² %g: escape value %g outside [%d, %d]", test.name, iters, mag2, v, iters, iters+1)
+				}
+				if v > prev+1e-12 {
+					t.Fatalf("%s: iteration %d, |z|² %g: escape value %g rose from %g", test.name, iters, mag2, v, prev)
+				}
+				prev = v
+			}
+			if top := test.smooth(iters, test.bailout, test.bailout); math.Abs(top-float64(iters+1)) > 1e-9 {
+				t.Errorf("%s: iteration %d at the bailout gives %g, want %d", test.name, iters, top, iters+1)
+			}
+			if bottom := test.smooth(iters, far, test.bailout); math.Abs(bottom-float64(iters)) > 1e-9 {
+				t.Errorf("%s: iteration %d at the far end gives %g, want %d", test.name, iters, bottom, iters)
+			}
+		}
+	}
+}
+
+// TestSmoothEscapeFast checks that points escaping far past the bailout on
+// the first iteration are eased toward 0 without reaching it, and that
+// continuous coloring handles their values below 1.
+func TestSmoothEscapeFast(t *testing.T) {
+	prev := 1.0
+	for _, mag2 := range []float64{16, 1e3, 1e6, 1e12, 1e24, 1e100, 1e300} {
+		v := smoothEscape(0, mag2, 4)
+		if !(v > 0 && v <= prev) {
+			t.Fatalf("|z|² %g: escape value %g, want in (0, %g]", mag2, v, prev)
+		}
+		prev = v
+	}
+
+	p := &Parameters{Magnification: 1, MaxIterations: 100, SizeX: 8, SizeY: 8, AntiAlias: 1, Continuous: true, Palette: DefaultPalette()}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	last, first := p.palette[len(p.palette)-1], p.palette[0]
+	r, g, b, _ := p.valueColor(0.5, false)
+	mid := func(u, v uint8) float64 { return (float64(u) + float64(v)) / 2 }
+	if r != mid(last.R, first.R) || g != mid(last.G, first.G) || b != mid(last.B, first.B) {
+		t.Errorf("escape value 0.5 gives %g %g %g, want halfway from the last palette entry to the first", r, g, b)
+	}
+}
+
+// TestSmoothBailout checks that a small continuous bailout changes escape
+// values only by a constant from what a huge one gives, so the smoothing
+// stays seamless, and that values along a line crossing many bands have
+// no jumps.
+func TestSmoothBailout(t *testing.T) {
+	const points = 2000
+	for _, bailout := range []float64{100, 256} {
+		var offset float64
+		for k := 0; k < points; k++ {
+			x, y := -2+2.5*float64(k)/points, 0.7
+			v, ref := mandel(10000, x, y, smoothEscape, bailout), mandel(10000, x, y, smoothEscape, 1<<40)
+			if v < 1 || ref < 1 {
+				continue
+			}
+			d := v - ref
+			if offset == 0 {
+				offset = d
+			}
+			if tol := 4 / bailout; math.Abs(d-offset) > tol {
+				t.Errorf("bailout %g at (%g, %g): escape value %g is %g off the huge bailout's %g, want within %g of %g", bailout, x, y, v, d, ref, tol, offset)
+			}
+		}
+
+		prev := math.NaN()
+		for k := 0; k <= points; k++ {
+			x, y := -3+6*float64(k)/points, 1.5
+			v := mandel(100, x, y, smoothEscape, bailout)
+			if math.Abs(v-prev) > 0.05 {
+				t.Errorf("bailout %g: escape value jumps from %g to %g at (%g, %g)", bailout, prev, v, x, y)
+			}
+			prev = v
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package mandel
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats records the work that went into each pixel of a render, row by
+// row, to show which regions dominate its cost and whether MaxIterations
+// is high enough. A report gathers them with ReportPixels. Each sample
+// counts toward the pixel its point falls in, so the samples of a pixel
+// are the ones the render actually iterated: fewer than the anti-aliasing
+// grid where a shortcut took over, and none for pixels that InteriorTiles
+// filled in or Symmetry mirrored. Colorings that follow whole orbits, such
+// as orbit traps, and renders by perturbation are not counted, as in
+// RenderReport.
+type Stats struct {
+	Width, Height int
+	MaxIterations int
+
+	// the samples iterated for each pixel, and how many of them never
+	// escaped
+	Samples []int
+	Inside  []int
+
+	// the average iterations each pixel's escaping samples ran, counted
+	// from their escape values as in RenderReport, or 0 if none escaped
+	Iterations []float64
+
+	// how long each pixel's samples took to iterate
+	Time []time.Duration
+}
+
+// StatsSummary condenses Stats into numbers for a whole image.
+type StatsSummary struct {
+	Pixels        int `json:"pixels"`
+	MaxIterations int `json:"maxiterations"`
+
+	// pixels with every sample inside, with some samples inside, and
+	// with no samples iterated at all
+	InsidePixels  int `json:"insidepixels"`
+	EdgePixels    int `json:"edgepixels"`
+	SkippedPixels int `json:"skippedpixels"`
+
+	// iterations per escaping sample over the pixels that have any, and
+	// percentiles over the pixels with no samples inside; an escaping
+	// percentile close to MaxIterations suggests that more iterations
+	// would find more detail
+	MeanIterations      float64 `json:"meaniterations"`
+	EscapeIterations50  float64 `json:"escapeiterations50"`
+	EscapeIterations90  float64 `json:"escapeiterations90"`
+	EscapeIterations99  float64 `json:"escapeiterations99"`
+	EscapeIterationsMax float64 `json:"escapeiterationsmax"`
+
+	// total time summed over pixels, and the share of it spent on pixels
+	// with samples inside
+	TotalTime   time.Duration `json:"totaltime"`
+	MeanTime    time.Duration `json:"meantime"`
+	SlowestTime time.Duration `json:"slowesttime"`
+	SlowestX    int           `json:"slowestx"`
+	SlowestY    int           `json:"slowesty"`
+	InsideShare float64       `json:"insideshare"`
+}
+
+// pixelCounter counts the work of each pixel while a render runs, with
+// every count updated atomically.
+type pixelCounter struct {
+	width, height, maxIters     int
+	samples, inside, iterations []int64
+	nanos                       []int64
+}
+
+func newPixelCounter(p *Parameters) *pixelCounter {
+	n := p.SizeX * p.SizeY
+	return &pixelCounter{
+		width:      p.SizeX,
+		height:     p.SizeY,
+		maxIters:   p.MaxIterations,
+		samples:    make([]int64, n),
+		inside:     make([]int64, n),
+		iterations: make([]int64, n),
+		nanos:      make([]int64, n),
+	}
+}
+
+// index is the pixel that image coordinates fx, fy fall in. Samples on the
+// outer edge of the image, or just past it, count toward the edge pixels.
+func (c *pixelCounter) index(fx, fy float64) int {
+	col, row := 0, 0
+	if fx > 0 {
+		col = int(math.Min(fx, float64(c.width-1)))
+	}
+	if fy > 0 {
+		row = int(math.Min(fy, float64(c.height-1)))
+	}
+	return row*c.width + col
+}
+
+// stats returns the counts as Stats once the render is done.
+func (c *pixelCounter) stats() *Stats {
+	n := c.width * c.height
+	s := &Stats{
+		Width:         c.width,
+		Height:        c.height,
+		MaxIterations: c.maxIters,
+		Samples:       make([]int, n),
+		Inside:        make([]int, n),
+		Iterations:    make([]float64, n),
+		Time:          make([]time.Duration, n),
+	}
+	for i := 0; i < n; i++ {
+		s.Samples[i], s.Inside[i] = int(c.samples[i]), int(c.inside[i])
+		if escaped := c.samples[i] - c.inside[i]; escaped > 0 {
+			s.Iterations[i] = float64(c.iterations[i]) / float64(escaped)
+		}
+		s.Time[i] = time.Duration(c.nanos[i])
+	}
+	return s
+}
+
+// Summary condenses the statistics into totals and percentiles.
+func (s *Stats) Summary() StatsSummary {
+	summary := StatsSummary{Pixels: len(s.Iterations), MaxIterations: s.MaxIterations}
+	var escaped []float64
+	var insideTime time.Duration
+	total, counted := 0.0, 0
+	for i, iters := range s.Iterations {
+		if s.Samples[i] > s.Inside[i] {
+			total += iters
+			counted++
+		}
+		switch {
+		case s.Samples[i] == 0:
+			summary.SkippedPixels++
+		case s.Inside[i] == 0:
+			escaped = append(escaped, iters)
+		case s.Inside[i] == s.Samples[i]:
+			summary.InsidePixels++
+			insideTime += s.Time[i]
+		default:
+			summary.EdgePixels++
+			insideTime += s.Time[i]
+		}
+		summary.TotalTime += s.Time[i]
+		if s.Time[i] > summary.SlowestTime {
+			summary.SlowestTime = s.Time[i]
+			summary.SlowestX, summary.SlowestY = i%s.Width, i/s.Width
+		}
+	}
+	if summary.Pixels == 0 {
+		return summary
+	}
+	if counted > 0 {
+		summary.MeanIterations = total / float64(counted)
+	}
+	summary.MeanTime = summary.TotalTime / time.Duration(summary.Pixels)
+	if summary.TotalTime > 0 {
+		summary.InsideShare = float64(insideTime) / float64(summary.TotalTime)
+	}
+	if len(escaped) > 0 {
+		sort.Float64s(escaped)
+		percentile := func(pct int) float64 {
+			return escaped[(len(escaped)-1)*pct/100]
+		}
+		summary.EscapeIterations50 = percentile(50)
+		summary.EscapeIterations90 = percentile(90)
+		summary.EscapeIterations99 = percentile(99)
+		summary.EscapeIterationsMax = escaped[len(escaped)-1]
+	}
+	return summary
+}
+
+// WriteJSON writes the summary of the statistics as JSON, with times in
+// nanoseconds.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s.Summary())
+}
+
+// IterationImage draws the iterations of each pixel as a heat map on a
+// log scale, from black for pixels that escape at once, or were never
+// iterated, to white for pixels whose samples reach MaxIterations or never
+// escape.
+func (s *Stats) IterationImage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, s.Width, s.Height))
+	scale := math.Log1p(float64(s.MaxIterations))
+	for i, iters := range s.Iterations {
+		if s.Samples[i] > 0 && s.Inside[i] == s.Samples[i] {
+			img.Pix[i] = 255
+			continue
+		}
+		img.Pix[i] = grayLevel(math.Log1p(iters) / scale)
+	}
+	return img
+}
+
+// TimeImage draws the time each pixel took on a linear scale, from black
+// for none to white for the slowest 1% of pixels, so that a few pixels
+// stalled by the scheduler or the garbage collector do not wash out the
+// rest.
+func (s *Stats) TimeImage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, s.Width, s.Height))
+	if len(s.Time) == 0 {
+		return img
+	}
+	times := append([]time.Duration(nil), s.Time...)
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	slow := times[(len(times)-1)*99/100]
+	if slow == 0 {
+		return img
+	}
+	for i, t := range s.Time {
+		img.Pix[i] = grayLevel(float64(t) / float64(slow))
+	}
+	return img
+}
+
+// grayLevel converts a level from [0, 1] to a gray byte.
+func grayLevel(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(v, 1))*255 + 0.5)
+}
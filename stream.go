@@ -0,0 +1,249 @@
+package mandel
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"io"
+)
+
+// rows rendered at a time by GenerateTo when BandRows is 0
+const defaultBandRows = 64
+
+// GenerateTo renders the image a band of rows at a time and streams it to
+// w, so memory use grows with the width of the image and BandRows but not
+// with its height. The only format is "png", with the parameters saved
+// as EncodePNG saves them. Bands are rendered like
+// GenerateRegion, so options that look at the whole image are left out,
+// as are contours, labels, and cropping.
+func (p *Parameters) GenerateTo(w io.Writer, format string) error {
+	if err := p.checkInit("GenerateTo"); err != nil {
+		return err
+	}
+	if format != "png" {
+		return fmt.Errorf("unsupported streaming format %q", format)
+	}
+
+	text, err := p.parametersChunk()
+	if err != nil {
+		return err
+	}
+	enc, err := newPNGStream(w, p.SizeX, p.SizeY, text)
+	if err != nil {
+		return err
+	}
+	err = p.forBands(func(canvas *image.NRGBA) error {
+		for row := canvas.Rect.Min.Y; row < canvas.Rect.Max.Y; row++ {
+			i := canvas.PixOffset(0, row)
+			if err := enc.writeRow(canvas.Pix[i : i+4*p.SizeX]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return enc.close()
+}
+
+// GenerateRows renders the image a band of rows at a time, like
+// GenerateTo, and hands the finished rows to row in order from the top,
+// so a caller can feed an encoder or a network connection without the
+// whole image in memory. The pixels slice is reused for the next row, so
+// row must copy anything it keeps. An error from row ends the render.
+func (p *Parameters) GenerateRows(row func(y int, pixels []color.NRGBA) error) error {
+	if err := p.checkInit("GenerateRows"); err != nil {
+		return err
+	}
+	pixels := make([]color.NRGBA, p.SizeX)
+	return p.forBands(func(canvas *image.NRGBA) error {
+		for y := canvas.Rect.Min.Y; y < canvas.Rect.Max.Y; y++ {
+			pix := canvas.Pix[canvas.PixOffset(0, y):]
+			for x := range pixels {
+				pixels[x] = color.NRGBA{pix[4*x], pix[4*x+1], pix[4*x+2], pix[4*x+3]}
+			}
+			if err := row(y, pixels); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// forBands renders the image BandRows rows at a time with generateRegion,
+// passing each band to fn in order from the top.
+func (p *Parameters) forBands(fn func(canvas *image.NRGBA) error) error {
+	band := p.BandRows
+	if band <= 0 {
+		band = defaultBandRows
+	}
+	q := *p
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+		q.progress.expect(p.SizeY)
+		defer q.progress.stop()
+	}
+	p = &q
+	for top := 0; top < p.SizeY; top += band {
+		bottom := top + band
+		if bottom > p.SizeY {
+			bottom = p.SizeY
+		}
+		if err := fn(p.generateRegion(image.Rect(0, top, p.SizeX, bottom))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateTileGrid renders the image as a grid of size by size tiles,
+// row by row from the top left, and hands each one to tile along with its
+// bounds in the full image, so only one tile is held in memory at a time.
+// The tiles along the right and bottom edges are cut short by the edges
+// of the image. Tiles are rendered like GenerateRegion, so they line up
+// exactly, and options that look at the whole image are left out, as are
+// contours, labels, and cropping. An error from tile ends the grid.
+func (p *Parameters) GenerateTileGrid(size int, tile func(rect image.Rectangle, img *image.NRGBA) error) error {
+	if err := p.checkInit("GenerateTileGrid"); err != nil {
+		return err
+	}
+	if size < 1 {
+		return fmt.Errorf("tile size must be at least 1")
+	}
+	tilesX := (p.SizeX + size - 1) / size
+	q := *p
+	if p.Progress != nil {
+		q.progress = newProgress(p.Progress)
+		q.progress.expect(p.SizeY * tilesX)
+		defer q.progress.stop()
+	}
+	p = &q
+	bounds := image.Rect(0, 0, p.SizeX, p.SizeY)
+	for top := 0; top < p.SizeY; top += size {
+		for left := 0; left < p.SizeX; left += size {
+			rect := image.Rect(left, top, left+size, top+size).Intersect(bounds)
+			if err := tile(rect, p.generateRegion(rect)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pngStream writes an 8-bit NRGBA PNG one row at a time. The compressed
+// rows go out in IDAT chunks of up to 64 KiB as the buffer fills.
+type pngStream struct {
+	w         io.Writer
+	buf       *bufio.Writer
+	z         *zlib.Writer
+	prev, out []byte
+}
+
+func newPNGStream(w io.Writer, width, height int, text []byte) (*pngStream, error) {
+	if _, err := io.WriteString(w, "\x89PNG\r\n\x1a\n"); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], uint32(width))
+	binary.BigEndian.PutUint32(header[4:], uint32(height))
+	header[8] = 8 // bits per channel
+	header[9] = 6 // truecolor with alpha, not premultiplied
+	if err := writePNGChunk(w, "IHDR", header); err != nil {
+		return nil, err
+	}
+	if err := writePNGChunk(w, "iTXt", text); err != nil {
+		return nil, err
+	}
+	s := &pngStream{
+		w:    w,
+		prev: make([]byte, 4*width),
+		out:  make([]byte, 1+4*width),
+	}
+	s.buf = bufio.NewWriterSize(idatWriter{w}, 1<<16)
+	s.z = zlib.NewWriter(s.buf)
+	return s, nil
+}
+
+// writeRow compresses one row of NRGBA pixels with the Paeth filter,
+// which works well on the smooth gradients of continuous coloring.
+func (s *pngStream) writeRow(row []byte) error {
+	s.out[0] = 4
+	for i := range row {
+		var a, c int
+		if i >= 4 {
+			a, c = int(row[i-4]), int(s.prev[i-4])
+		}
+		s.out[1+i] = row[i] - paeth(a, int(s.prev[i]), c)
+	}
+	copy(s.prev, row)
+	_, err := s.z.Write(s.out)
+	return err
+}
+
+func (s *pngStream) close() error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return writePNGChunk(s.w, "IEND", nil)
+}
+
+// flush finishes the compressed rows and writes out the last of them.
+func (s *pngStream) flush() error {
+	if err := s.z.Close(); err != nil {
+		return err
+	}
+	return s.buf.Flush()
+}
+
+// paeth predicts a byte from its left, upper, and upper-left neighbors.
+func paeth(a, b, c int) uint8 {
+	pa, pb, pc := abs(b-c), abs(a-c), abs(a+b-2*c)
+	if pa <= pb && pa <= pc {
+		return uint8(a)
+	}
+	if pb <= pc {
+		return uint8(b)
+	}
+	return uint8(c)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// idatWriter wraps everything written to it in an IDAT chunk.
+type idatWriter struct {
+	w io.Writer
+}
+
+func (iw idatWriter) Write(data []byte) (int, error) {
+	if err := writePNGChunk(iw.w, "IDAT", data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func writePNGChunk(w io.Writer, kind string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
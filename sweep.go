@@ -0,0 +1,259 @@
+package mandel
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/cmplx"
+)
+
+// GenerateIterationSweep renders the image as it would look with
+// MaxIterations set to each of 1, 2, ..., maxN in turn, passing each frame
+// to emit. Each sample keeps its orbit between frames, so a frame only
+// takes its samples that are still inside the set one iteration further,
+// and the whole sweep costs about what a render with maxN iterations
+// does. Iterations that do not keep an orbit, such as CurvatureColor and
+// CompensatedSum, are run again from the start for each frame. Views
+// deep enough for perturbation are an error.
+func (p *Parameters) GenerateIterationSweep(maxN int, emit func(n int, img *image.NRGBA)) error {
+	if err := p.checkInit("GenerateIterationSweep"); err != nil {
+		return err
+	}
+	if p.perturbed() {
+		return fmt.Errorf("an iteration sweep cannot render views deep enough for perturbation")
+	}
+	q := *p
+	q.MaxIterations = 1
+	step := q.sweepStep()
+	if step == nil {
+		f := q.computeField(q.Continuous)
+		for n := 1; n <= maxN; n++ {
+			if n > 1 {
+				q.refineField(f, n)
+				q.MaxIterations = n
+			}
+			emit(n, f.Colorize(&q))
+		}
+		return nil
+	}
+
+	f := newField(&q)
+	orbits := make([]sweepOrbit, len(f.Values))
+	for n := 1; n <= maxN; n++ {
+		q.MaxIterations = n
+		q.forRows(f.Height, func(j int) {
+			for i := 0; i < f.Width; i++ {
+				k := j*f.Width + i
+				o := &orbits[k]
+				if o.done {
+					continue
+				}
+				if o.iters == 0 {
+					x, y := q.samplePoint(i, j)
+					o.start(x, y)
+				}
+				f.Values[k] = step(o, n)
+			}
+		})
+		emit(n, f.Colorize(&q))
+	}
+	return nil
+}
+
+// sweepOrbit is where the orbit of one sample stands between the frames
+// of an iteration sweep: its point c, the current z, and the iteration
+// that checks z next, along with the saved point of the periodicity check
+// that mandel makes, or the state of a StatefulSystem. It is done once it
+// has escaped or is known never to.
+type sweepOrbit struct {
+	x, y           float64
+	a, b           float64
+	ra, rb         float64
+	iters          int
+	next, interval int
+	done           bool
+}
+
+// start sets up the orbit of the point (x, y) to check its first z, which
+// each iteration sets to suit itself.
+func (o *sweepOrbit) start(x, y float64) {
+	o.x, o.y = x, y
+	o.iters = 1
+	o.next, o.interval = periodStart, periodStart
+}
+
+// sweepStep returns the iteration of an iteration sweep, which takes an
+// orbit on until it escapes, returning its escape value, or until it has
+// been checked on limit iterations, returning 0. It is nil for iterations
+// that cannot pick up where they stopped.
+func (p *Parameters) sweepStep() func(o *sweepOrbit, limit int) float64 {
+	if p.Fractal == "newton" {
+		return nil
+	}
+	if p.system != nil {
+		return func(o *sweepOrbit, limit int) float64 {
+			return o.system(p.system, limit)
+		}
+	}
+	if p.formula != nil {
+		smooth, bailout := p.smoother(p.Continuous, 2), p.bailout(p.Continuous)
+		return func(o *sweepOrbit, limit int) float64 {
+			return o.formula(p.formula, limit, smooth, bailout)
+		}
+	}
+	if p.quadratic() && (p.norm != nil || p.CompensatedSum || p.CurvatureColor) {
+		return nil
+	}
+	if !p.quadratic() || p.ExactInterior {
+		power := p.Power
+		if power == 0 {
+			power = 2
+		}
+		smooth, bailout := p.smoother(p.Continuous, power), p.bailout(p.Continuous)
+		julia := p.Fractal == "julia"
+		return func(o *sweepOrbit, limit int) float64 {
+			cx, cy := o.x, o.y
+			if julia {
+				cx, cy = p.JuliaCX, p.JuliaCY
+			}
+			return o.power(limit, cx, cy, smooth, bailout, power, p.Fractal)
+		}
+	}
+	smooth, bailout := p.smoother(p.Continuous, 2), p.bailout(p.Continuous)
+	return func(o *sweepOrbit, limit int) float64 {
+		return o.mandel(limit, smooth, bailout)
+	}
+}
+
+// mandel is mandel, picking up the orbit where it stopped.
+func (o *sweepOrbit) mandel(limit int, smooth smoother, bailout float64) float64 {
+	x, y := o.x, o.y
+	if o.iters == 1 {
+		// points in the main cardioid or the period-2 bulb never escape
+		xq := x - 0.25
+		y2 := float64(y * y)
+		q := float64(xq*xq) + y2
+		if float64(q*(q+xq)) <= float64(0.25*y2) || float64((x+1)*(x+1))+y2 <= 0.0625 {
+			o.done = true
+			return 0.0
+		}
+		o.a, o.b = x, y
+		o.ra, o.rb = x, y
+	}
+	a, b := o.a, o.b
+	for ; o.iters <= limit; o.iters++ {
+		a2 := float64(a * a)
+		b2 := float64(b * b)
+		if a2+b2 >= bailout {
+			o.done = true
+			if smooth != nil {
+				return smooth(o.iters, a2+b2, bailout)
+			}
+			return float64(o.iters)
+		}
+		ab := float64(a * b)
+		a = a2 - b2 + x
+		b = ab + ab + y
+		if math.Abs(a-o.ra) < periodEpsilon && math.Abs(b-o.rb) < periodEpsilon {
+			o.done = true
+			return 0.0
+		}
+		if o.iters == o.next {
+			o.ra, o.rb = a, b
+			o.interval *= 2
+			o.next += o.interval
+		}
+	}
+	o.a, o.b = a, b
+	return 0.0
+}
+
+// power is iteratePower from z = c, with c = x + yi, picking up the orbit
+// where it stopped.
+func (o *sweepOrbit) power(limit int, x, y float64, smooth smoother, bailout float64, power int, fractal string) float64 {
+	if o.iters == 1 {
+		o.a, o.b = o.x, o.y
+	}
+	burning, tricorn := fractal == "burningship", fractal == "tricorn"
+	a, b := o.a, o.b
+	for ; o.iters <= limit; o.iters++ {
+		mag2 := float64(a*a) + float64(b*b)
+		if mag2 >= bailout {
+			o.done = true
+			if smooth != nil {
+				return smooth(o.iters, mag2, bailout)
+			}
+			return float64(o.iters)
+		}
+		if burning {
+			a, b = math.Abs(a), math.Abs(b)
+		} else if tricorn {
+			b = -b
+		}
+		za, zb := a, b
+		for k := 1; k < power; k++ {
+			za, zb = float64(za*a)-float64(zb*b), float64(za*b)+float64(zb*a)
+		}
+		a, b = za+x, zb+y
+	}
+	o.a, o.b = a, b
+	return 0.0
+}
+
+// formula is iterateFormula, picking up the orbit where it stopped.
+func (o *sweepOrbit) formula(f cfunc, limit int, smooth smoother, bailout float64) float64 {
+	c := complex(o.x, o.y)
+	if o.iters == 1 {
+		z := f(0, c)
+		o.a, o.b = real(z), imag(z)
+	}
+	z := complex(o.a, o.b)
+	for ; o.iters <= limit; o.iters++ {
+		mag2 := real(z)*real(z) + imag(z)*imag(z)
+		if math.IsNaN(mag2) || math.IsInf(mag2, 1) {
+			// overflow means the orbit escaped
+			o.done = true
+			return float64(o.iters)
+		}
+		if mag2 >= bailout {
+			o.done = true
+			if smooth != nil {
+				return smooth(o.iters, mag2, bailout)
+			}
+			return float64(o.iters)
+		}
+		z = f(z, c)
+	}
+	o.a, o.b = real(z), imag(z)
+	return 0.0
+}
+
+// system is iterateSystem, picking up the orbit where it stopped.
+func (o *sweepOrbit) system(s System, limit int) float64 {
+	c := complex(o.x, o.y)
+	stateful, _ := s.(StatefulSystem)
+	if o.iters == 1 {
+		z := s.Start(c)
+		o.a, o.b = real(z), imag(z)
+		if stateful != nil {
+			state := stateful.StartState(c)
+			o.ra, o.rb = real(state), imag(state)
+		}
+	}
+	z, state := complex(o.a, o.b), complex(o.ra, o.rb)
+	for ; o.iters <= limit; o.iters++ {
+		if cmplx.IsNaN(z) || cmplx.IsInf(z) || s.Escaped(z) {
+			// overflow means the orbit escaped
+			o.done = true
+			return float64(o.iters)
+		}
+		if stateful != nil {
+			z, state = stateful.StepState(z, state, c)
+		} else {
+			z = s.Step(z, c)
+		}
+	}
+	o.a, o.b = real(z), imag(z)
+	o.ra, o.rb = real(state), imag(state)
+	return 0.0
+}
@@ -0,0 +1,143 @@
+package mandel
+
+import "image"
+
+// mirrorable reports whether Symmetry is set and the settings color c and
+// its conjugate alike, so rows of a view centered on the real axis can be
+// copied from the rows they mirror. Views that are rotated, skewed, or
+// jittered are never symmetric, and leaving them out means the imaginary
+// parts of a row's samples are the same in every column.
+func (p *Parameters) mirrorable() bool {
+	if !p.Symmetry || p.viewSet || p.ExpMap || p.JitterAA || !p.quadratic() || p.norm != nil || p.CurvatureColor {
+		return false
+	}
+	switch p.Coloring {
+	case "", "palette", "histogram", "distance", "alpha-ramp", "orbit-range", "tia":
+		return true
+	case "orbittrap":
+		return p.TrapY == 0 && p.TrapAngle == 0
+	}
+	return false
+}
+
+// mirrorPartners lists the rows above row that may mirror it about the
+// real axis: the row as far above the middle of the image as row is
+// below it, and, since views centered on a center pixel put the axis on
+// row rows/2, the row as far above that one.
+func mirrorPartners(row, rows int) [2]int {
+	return [2]int{rows - 1 - row, 2*(rows/2) - row}
+}
+
+// mirrors reports whether the points of one row are those of another
+// reflected in the real axis: the same real parts and the opposite
+// imaginary parts, exactly.
+func mirrors(xs, ys, mxs, mys []float64) bool {
+	for k := range xs {
+		if xs[k] != mxs[k] || ys[k] != -mys[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// mirrorPixelRows finds, for each row of the image, the row above it that
+// it mirrors, or -1 for rows that must be rendered. Every sample of the
+// first pixel is checked, along with the center and corners that
+// AAFastPath looks at, so rounding never copies a row that would have
+// come out differently.
+func (p *Parameters) mirrorPixelRows() []int {
+	points := func(row int, flip bool) (xs, ys []float64) {
+		add := func(xoffset, yoffset float64) {
+			x, y := p.toPlane(0, row, xoffset, yoffset)
+			xs, ys = append(xs, x), append(ys, y)
+		}
+		for j := range p.subpixOffsets {
+			for i := range p.subpixOffsets {
+				jj := j
+				if flip {
+					jj = len(p.subpixOffsets) - 1 - j
+				}
+				add(p.subpixel(0, row, i, jj))
+			}
+		}
+		sign := 1.0
+		if flip {
+			sign = -1
+		}
+		add(0, 0)
+		for _, corner := range [4][2]float64{{-0.5, -0.5}, {0.5, -0.5}, {-0.5, 0.5}, {0.5, 0.5}} {
+			add(corner[0], sign*corner[1])
+		}
+		return xs, ys
+	}
+	return p.mirrorRows(p.SizeY, points)
+}
+
+// mirrorSampleRows is mirrorPixelRows for the rows of the sample grid of
+// a field.
+func (p *Parameters) mirrorSampleRows() []int {
+	aa := p.AntiAlias
+	mirror := p.mirrorRows(p.SizeY, func(row int, flip bool) (xs, ys []float64) {
+		for s := 0; s < aa; s++ {
+			j := row*aa + s
+			if flip {
+				// the sample rows of a pixel run the other way in its mirror
+				j = row*aa + aa - 1 - s
+			}
+			for i := 0; i < aa; i++ {
+				x, y := p.samplePoint(i, j)
+				xs, ys = append(xs, x), append(ys, y)
+			}
+		}
+		return xs, ys
+	})
+	samples := make([]int, p.SizeY*aa)
+	for j := range samples {
+		samples[j] = -1
+		if m := mirror[j/aa]; m >= 0 {
+			samples[j] = m*aa + aa - 1 - j%aa
+		}
+	}
+	return samples
+}
+
+// mirrorRows finds the mirror of each of rows rows, given the points of
+// a row, either in order or with the samples within each pixel flipped
+// top to bottom to line up with those of its mirror. Only rows below the
+// middle mirror others, so every row that is copied is copied from a row
+// that is rendered.
+func (p *Parameters) mirrorRows(rows int, points func(row int, flip bool) (xs, ys []float64)) []int {
+	mirror := make([]int, rows)
+	p.forRows(rows, func(row int) {
+		mirror[row] = -1
+		xs, ys := points(row, false)
+		for _, m := range mirrorPartners(row, rows) {
+			if m >= 0 && m < row {
+				if mxs, mys := points(m, true); mirrors(xs, ys, mxs, mys) {
+					mirror[row] = m
+					return
+				}
+			}
+		}
+	})
+	return mirror
+}
+
+// generateMirrored renders the rows of the image that mirror no other row
+// like generatePixels, and copies the rest from their mirrors.
+func (p *Parameters) generateMirrored() *image.NRGBA {
+	mirror := p.mirrorPixelRows()
+	canvas := p.newRegionCanvas(image.Rect(0, 0, p.SizeX, p.SizeY))
+	p.forRows(p.SizeY, func(row int) {
+		if mirror[row] < 0 {
+			p.calcSpan(canvas, 0, p.SizeX, row)
+		}
+	})
+	for row, m := range mirror {
+		if m >= 0 {
+			copy(canvas.Pix[row*canvas.Stride:(row+1)*canvas.Stride], canvas.Pix[m*canvas.Stride:])
+			p.report.mirroredRows(1)
+		}
+	}
+	return canvas
+}
@@ -0,0 +1,266 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// System is an iteration that Generate runs from each pixel in place of
+// z² + c, so a fractal can be written in Go without a Formula. The
+// sample's point is c, and its escape value is the first iteration whose
+// z has Escaped, counting Start(c) as the first; orbits that overflow to
+// infinity or NaN count as escaped too. Escape values are whole iteration
+// counts, since the system decides when an orbit escapes, so Continuous
+// and Bailout cannot be used with one. A System's methods are called from
+// many goroutines at once.
+// Renders with a System are only cached if it is also a CacheKeyer, as
+// the systems here are.
+type System interface {
+	// the first point of the orbit of c
+	Start(c complex128) complex128
+
+	// the point that follows z in the orbit of c
+	Step(z, c complex128) complex128
+
+	// the orbit has escaped once it reaches z
+	Escaped(z complex128) bool
+}
+
+// StatefulSystem is a System whose orbit carries a value besides z from
+// one step to the next, such as the point before z that the phoenix
+// fractal adds in. Generate starts the orbit of c at Start(c) with state
+// StartState(c), and takes it on with StepState in place of Step.
+type StatefulSystem interface {
+	System
+
+	// the state that goes with the first point of the orbit of c
+	StartState(c complex128) complex128
+
+	// the point that follows z, with state s, in the orbit of c, and the
+	// state that goes with it
+	StepState(z, s, c complex128) (complex128, complex128)
+}
+
+// escapedRadius2 is Escaped for an escape radius of 2.
+func escapedRadius2(z complex128) bool {
+	return real(z)*real(z)+imag(z)*imag(z) >= 4
+}
+
+// MandelbrotSystem is z² + c. Generate runs it with the same fast code as
+// when System is nil.
+type MandelbrotSystem struct{}
+
+// Start returns c.
+func (MandelbrotSystem) Start(c complex128) complex128 { return c }
+
+// Step returns z² + c.
+func (MandelbrotSystem) Step(z, c complex128) complex128 { return z*z + c }
+
+// Escaped reports whether |z| ≥ 2.
+func (MandelbrotSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns "".
+func (MandelbrotSystem) CacheKey() string { return "" }
+
+// BurningShipSystem is (|Re z| + |Im z|·i)² + c, which appears upside
+// down, as the "burningship" Fractal does.
+type BurningShipSystem struct{}
+
+// Start returns c.
+func (BurningShipSystem) Start(c complex128) complex128 { return c }
+
+// Step returns (|Re z| + |Im z|·i)² + c.
+func (BurningShipSystem) Step(z, c complex128) complex128 {
+	z = complex(math.Abs(real(z)), math.Abs(imag(z)))
+	return z*z + c
+}
+
+// Escaped reports whether |z| ≥ 2.
+func (BurningShipSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns "".
+func (BurningShipSystem) CacheKey() string { return "" }
+
+// TricornSystem is conj(z)² + c, also called the mandelbar.
+type TricornSystem struct{}
+
+// Start returns c.
+func (TricornSystem) Start(c complex128) complex128 { return c }
+
+// Step returns conj(z)² + c.
+func (TricornSystem) Step(z, c complex128) complex128 {
+	z = cmplx.Conj(z)
+	return z*z + c
+}
+
+// Escaped reports whether |z| ≥ 2.
+func (TricornSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns "".
+func (TricornSystem) CacheKey() string { return "" }
+
+// MultibrotSystem is z^Power + c for a Power of 2 or more.
+type MultibrotSystem struct {
+	Power int
+}
+
+// Start returns c.
+func (MultibrotSystem) Start(c complex128) complex128 { return c }
+
+// Step returns z^Power + c.
+func (s MultibrotSystem) Step(z, c complex128) complex128 {
+	w := z
+	for k := 1; k < s.Power; k++ {
+		w *= z
+	}
+	return w + c
+}
+
+// Escaped reports whether |z| ≥ 2.
+func (MultibrotSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns the power.
+func (s MultibrotSystem) CacheKey() string { return fmt.Sprint(s.Power) }
+
+// JuliaSystem is the Julia set of z² + C, where each pixel is a starting
+// z rather than c.
+type JuliaSystem struct {
+	C complex128
+}
+
+// Start returns the pixel, c.
+func (JuliaSystem) Start(c complex128) complex128 { return c }
+
+// Step returns z² + C.
+func (s JuliaSystem) Step(z, c complex128) complex128 { return z*z + s.C }
+
+// Escaped reports whether |z| ≥ 2.
+func (JuliaSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns C.
+func (s JuliaSystem) CacheKey() string { return fmt.Sprint(s.C) }
+
+// LambdaSystem is the logistic map c·z·(1 − z), iterated from its
+// critical point ½. Its set looks like two Mandelbrot sets joined at the
+// origin.
+type LambdaSystem struct{}
+
+// Start returns c/4, the step after ½.
+func (LambdaSystem) Start(c complex128) complex128 { return c / 4 }
+
+// Step returns c·z·(1 − z).
+func (LambdaSystem) Step(z, c complex128) complex128 { return c * z * (1 - z) }
+
+// Escaped reports whether |z| ≥ 100.
+func (LambdaSystem) Escaped(z complex128) bool {
+	return real(z)*real(z)+imag(z)*imag(z) >= 1e4
+}
+
+// CacheKey returns "".
+func (LambdaSystem) CacheKey() string { return "" }
+
+// MagnetSystem is the first magnet model of statistical physics,
+// ((z² + c − 1) / (2z + c − 2))², iterated from 0. Orbits that settle on
+// the fixed point 1 do not escape, and are colored as inside.
+type MagnetSystem struct{}
+
+// Start returns the step after 0.
+func (s MagnetSystem) Start(c complex128) complex128 { return s.Step(0, c) }
+
+// Step returns ((z² + c − 1) / (2z + c − 2))².
+func (MagnetSystem) Step(z, c complex128) complex128 {
+	w := (z*z + c - 1) / (2*z + c - 2)
+	return w * w
+}
+
+// Escaped reports whether |z| ≥ 100.
+func (MagnetSystem) Escaped(z complex128) bool {
+	return real(z)*real(z)+imag(z)*imag(z) >= 1e4
+}
+
+// CacheKey returns "".
+func (MagnetSystem) CacheKey() string { return "" }
+
+// PhoenixSystem is the Julia set of z² + P + Q·y, where y is the point
+// before z, starting from 0, and each pixel is a starting z. P = 0.56667
+// and Q = −0.5 give Ushiki's phoenix, on its side.
+type PhoenixSystem struct {
+	P, Q complex128
+}
+
+// Start returns the pixel, c.
+func (PhoenixSystem) Start(c complex128) complex128 { return c }
+
+// Step returns z² + P, the step from a z with 0 before it.
+func (s PhoenixSystem) Step(z, c complex128) complex128 { return z*z + s.P }
+
+// StartState returns 0, the point before the first.
+func (PhoenixSystem) StartState(c complex128) complex128 { return 0 }
+
+// StepState returns z² + P + Q·y, and z as the point before it.
+func (s PhoenixSystem) StepState(z, y, c complex128) (complex128, complex128) {
+	return z*z + s.P + s.Q*y, z
+}
+
+// Escaped reports whether |z| ≥ 2.
+func (PhoenixSystem) Escaped(z complex128) bool { return escapedRadius2(z) }
+
+// CacheKey returns P and Q.
+func (s PhoenixSystem) CacheKey() string { return fmt.Sprint(s.P, s.Q) }
+
+// initSystem checks that System can be used with the other settings, and
+// sets the system the render iterates, which is left nil for z² + c so
+// that it keeps the fast paths.
+func (p *Parameters) initSystem() error {
+	p.system = nil
+	if p.System == nil {
+		return nil
+	}
+	if p.Formula != "" {
+		return fmt.Errorf("a System cannot be used with a custom formula")
+	}
+	if p.Power > 2 || (p.Fractal != "" && p.Fractal != "mandelbrot") {
+		return fmt.Errorf("a System cannot be used with the %q fractal or a power", p.Fractal)
+	}
+	if p.Coloring == "orbittrap" || p.Coloring == "orbit-range" {
+		return fmt.Errorf("a System cannot be used with %s coloring", p.Coloring)
+	}
+	if _, ok := p.System.(MandelbrotSystem); ok {
+		return nil
+	}
+	if p.Bailout != 0 {
+		return fmt.Errorf("a System decides when orbits escape, so it cannot be used with an escape radius")
+	}
+	if p.Continuous {
+		return fmt.Errorf("a System gives whole iteration counts, so it cannot be used with continuous coloring")
+	}
+	p.system = p.System
+	return nil
+}
+
+// iterateSystem is mandel for a System.
+func iterateSystem(s System, maxIters int, x, y float64) float64 {
+	c := complex(x, y)
+	z := s.Start(c)
+	stateful, _ := s.(StatefulSystem)
+	var state complex128
+	if stateful != nil {
+		state = stateful.StartState(c)
+	}
+	for iters := 1; iters <= maxIters; iters++ {
+		if cmplx.IsNaN(z) || cmplx.IsInf(z) {
+			// overflow means the orbit escaped
+			return float64(iters)
+		}
+		if s.Escaped(z) {
+			return float64(iters)
+		}
+		if stateful != nil {
+			z, state = stateful.StepState(z, state, c)
+		} else {
+			z = s.Step(z, c)
+		}
+	}
+	return 0.0
+}
@@ -0,0 +1,46 @@
+package mandel
+
+import (
+	"bufio"
+	"io"
+)
+
+// characters for escaping points, from fastest to slowest escape; the
+// interior is drawn with textInside
+const (
+	textRamp   = " .:-=+*%"
+	textInside = '#'
+)
+
+// GenerateText writes the image as SizeY lines of SizeX characters, one
+// per pixel, using the center sample of each pixel. Points inside the set
+// are drawn as '#', and escaping points as characters that get denser as
+// the escape count grows, on the same log scale as alpha-ramp coloring.
+func (p *Parameters) GenerateText(w io.Writer) error {
+	if err := p.checkInit("GenerateText"); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	line := make([]byte, p.SizeX+1)
+	line[p.SizeX] = '\n'
+	for row := 0; row < p.SizeY; row++ {
+		for col := 0; col < p.SizeX; col++ {
+			x, y := p.toPlane(col, row, 0, 0)
+			v := p.escape(p.MaxIterations, x, y, p.Continuous)
+			if v == 0 {
+				line[col] = textInside
+				continue
+			}
+			i := int(p.level(v-p.depthShift) * float64(len(textRamp)))
+			if i >= len(textRamp) {
+				i = len(textRamp) - 1
+			}
+			line[col] = textRamp[i]
+		}
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,256 @@
+package mandel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// WriteLayeredTIFF writes a multi-page TIFF for compositing, with one page
+// per layer, all at the size of img:
+//
+//	color      img as 8-bit RGBA
+//	iterations escape values on a log scale normalized to the field's
+//	           largest, as 16-bit gray, with the interior black
+//	distance   distance estimate from finite differences of the field, in
+//	           pixels on a log scale normalized to the largest, as 16-bit
+//	           gray, with the interior black
+//	mask       fraction of each pixel's samples inside the set, as 8-bit
+//	           gray
+//
+// The distance layer is only meaningful for a continuous field.
+func (f *Field) WriteLayeredTIFF(w io.Writer, img *image.NRGBA) error {
+	aa := f.AntiAlias
+	width, height := f.Width/aa, f.Height/aa
+	if img.Rect.Dx() != width || img.Rect.Dy() != height {
+		return fmt.Errorf("image is %dx%d but the field is for %dx%d", img.Rect.Dx(), img.Rect.Dy(), width, height)
+	}
+
+	// per-pixel averages of the escape values, distances, and mask
+	n := float64(aa * aa)
+	iters := make([]float64, width*height)
+	dist := make([]float64, width*height)
+	mask := make([]byte, width*height)
+	maxIters, maxDist := 0.0, 0.0
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			var it, d, inside float64
+			for j := row * aa; j < (row+1)*aa; j++ {
+				for i := col * aa; i < (col+1)*aa; i++ {
+					v := f.At(i, j)
+					if v == 0 {
+						inside++
+						continue
+					}
+					it += math.Log1p(v)
+					d += math.Log1p(f.sampleDistance(i, j) / float64(aa))
+				}
+			}
+			k := row*width + col
+			iters[k], dist[k] = it/n, d/n
+			mask[k] = uint8(255*inside/n + 0.5)
+			maxIters, maxDist = math.Max(maxIters, iters[k]), math.Max(maxDist, dist[k])
+		}
+	}
+
+	rgba := make([]byte, 0, width*height*4)
+	for row := 0; row < height; row++ {
+		i := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+row)
+		rgba = append(rgba, img.Pix[i:i+width*4]...)
+	}
+	pages := []tiffPage{
+		{name: "color", samples: 4, bits: 8, data: rgba},
+		{name: "iterations", samples: 1, bits: 16, data: tiffGray16(iters, maxIters)},
+		{name: "distance", samples: 1, bits: 16, data: tiffGray16(dist, maxDist)},
+		{name: "mask", samples: 1, bits: 8, data: mask},
+	}
+	return writeTIFF(w, width, height, pages)
+}
+
+// EncodeTIFF writes img as an uncompressed single-page TIFF, keeping the
+// channels and bit depth of the formats GenerateImage returns: NRGBA and
+// NRGBA64 as RGBA with unassociated alpha, and Gray, Gray16, and Alpha as
+// gray. Other images are converted to 8-bit NRGBA.
+func EncodeTIFF(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	pg := tiffPage{name: "mandelbrot"}
+	var pix []byte
+	var stride int
+	switch m := img.(type) {
+	case *image.NRGBA64:
+		pg.samples, pg.bits, pix, stride = 4, 16, m.Pix, m.Stride
+	case *image.Gray:
+		pg.samples, pg.bits, pix, stride = 1, 8, m.Pix, m.Stride
+	case *image.Gray16:
+		pg.samples, pg.bits, pix, stride = 1, 16, m.Pix, m.Stride
+	case *image.Alpha:
+		pg.samples, pg.bits, pix, stride = 1, 8, m.Pix, m.Stride
+	default:
+		n, ok := img.(*image.NRGBA)
+		if !ok {
+			n = image.NewNRGBA(b)
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					n.Set(x, y, img.At(x, y))
+				}
+			}
+		}
+		pg.samples, pg.bits, pix, stride = 4, 8, n.Pix, n.Stride
+	}
+
+	// copy the rows out without padding, swapping 16-bit samples from the
+	// big-endian order of the image package
+	rowBytes := b.Dx() * pg.samples * pg.bits / 8
+	pg.data = make([]byte, 0, rowBytes*b.Dy())
+	for row := 0; row < b.Dy(); row++ {
+		pg.data = append(pg.data, pix[row*stride:row*stride+rowBytes]...)
+	}
+	if pg.bits == 16 {
+		for i := 0; i < len(pg.data); i += 2 {
+			pg.data[i], pg.data[i+1] = pg.data[i+1], pg.data[i]
+		}
+	}
+	return writeTIFF(w, b.Dx(), b.Dy(), []tiffPage{pg})
+}
+
+// sampleDistance estimates the distance in samples from an escaped sample
+// to the set, from the gradient of the escape values, differencing only
+// with escaped neighbors.
+func (f *Field) sampleDistance(i, j int) float64 {
+	slope := func(di, dj int) float64 {
+		v := f.At(i, j)
+		var sum, count float64
+		for _, s := range []int{-1, 1} {
+			ni, nj := i+s*di, j+s*dj
+			if ni < 0 || nj < 0 || ni >= f.Width || nj >= f.Height || f.At(ni, nj) == 0 {
+				continue
+			}
+			sum += float64(s) * (f.At(ni, nj) - v)
+			count++
+		}
+		if count == 0 {
+			return 0
+		}
+		return sum / count
+	}
+	grad := math.Hypot(slope(1, 0), slope(0, 1))
+	if grad == 0 {
+		return 0
+	}
+	return 1 / (math.Ln2 * grad)
+}
+
+// tiffGray16 scales values in [0, max] to 16-bit little-endian samples.
+func tiffGray16(values []float64, max float64) []byte {
+	data := make([]byte, len(values)*2)
+	for i, v := range values {
+		if max > 0 {
+			v /= max
+		}
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(math.Max(0, math.Min(1, v))*65535+0.5))
+	}
+	return data
+}
+
+// tiffPage is one uncompressed, single-strip page of a TIFF file.
+type tiffPage struct {
+	name    string
+	samples int
+	bits    int
+	data    []byte
+}
+
+// TIFF tag numbers and field types
+const (
+	tiffNewSubfileType  = 254
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffPhotometric     = 262
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffPlanarConfig    = 284
+	tiffPageName        = 285
+	tiffExtraSamples    = 338
+
+	tiffASCII = 2
+	tiffShort = 3
+	tiffLong  = 4
+)
+
+// writeTIFF writes a little-endian TIFF with each page's data followed by
+// its directory.
+func writeTIFF(w io.Writer, width, height int, pages []tiffPage) error {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("II")
+	binary.Write(&buf, le, uint16(42))
+	binary.Write(&buf, le, uint32(0)) // patched with the first IFD offset
+	next := 4                         // where to patch in the next IFD offset
+
+	for _, pg := range pages {
+		dataOffset := buf.Len()
+		buf.Write(pg.data)
+
+		// values too big for a tag entry go after the data
+		bitsOffset := buf.Len()
+		for i := 0; i < pg.samples; i++ {
+			binary.Write(&buf, le, uint16(pg.bits))
+		}
+		nameOffset := buf.Len()
+		buf.WriteString(pg.name)
+		buf.WriteByte(0)
+		if buf.Len()%2 != 0 {
+			buf.WriteByte(0)
+		}
+
+		type entry struct {
+			tag, typ    uint16
+			count, data uint32
+		}
+		photometric := uint32(1) // black is zero
+		bits := uint32(pg.bits)
+		if pg.samples > 1 {
+			photometric = 2 // RGB
+			bits = uint32(bitsOffset)
+		}
+		entries := []entry{
+			{tiffNewSubfileType, tiffLong, 1, 2}, // one page of many
+			{tiffImageWidth, tiffLong, 1, uint32(width)},
+			{tiffImageLength, tiffLong, 1, uint32(height)},
+			{tiffBitsPerSample, tiffShort, uint32(pg.samples), bits},
+			{tiffCompression, tiffShort, 1, 1},
+			{tiffPhotometric, tiffShort, 1, photometric},
+			{tiffStripOffsets, tiffLong, 1, uint32(dataOffset)},
+			{tiffSamplesPerPixel, tiffShort, 1, uint32(pg.samples)},
+			{tiffRowsPerStrip, tiffLong, 1, uint32(height)},
+			{tiffStripByteCounts, tiffLong, 1, uint32(len(pg.data))},
+			{tiffPlanarConfig, tiffShort, 1, 1},
+			{tiffPageName, tiffASCII, uint32(len(pg.name) + 1), uint32(nameOffset)},
+		}
+		if pg.samples == 4 {
+			entries = append(entries, entry{tiffExtraSamples, tiffShort, 1, 2}) // unassociated alpha
+		}
+
+		le.PutUint32(buf.Bytes()[next:], uint32(buf.Len()))
+		binary.Write(&buf, le, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(&buf, le, e.tag)
+			binary.Write(&buf, le, e.typ)
+			binary.Write(&buf, le, e.count)
+			// short values sit in the low bytes of the value field
+			binary.Write(&buf, le, e.data)
+		}
+		next = buf.Len()
+		binary.Write(&buf, le, uint32(0))
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,67 @@
+package mandel
+
+import "image"
+
+// width and height of the tiles used by InteriorTiles
+const interiorTileSize = 16
+
+// generateTiles renders the image in square tiles, starting with the
+// pixels around the edge of each tile. The set is full: it has no holes,
+// and neither does the set of points that survive any fixed number of
+// iterations. So when every sample around the edge of a tile stays inside,
+// so does everything the edge encloses, and the rest of the tile can be
+// filled in without iterating. The one risk is an escaping filament thin
+// enough to slip between the edge samples.
+func (p *Parameters) generateTiles() *image.NRGBA {
+	const size = interiorTileSize
+	canvas := p.newCanvas()
+	tilesX := (p.SizeX + size - 1) / size
+	tilesY := (p.SizeY + size - 1) / size
+
+	p.forRows(tilesY, func(ty int) {
+		band := image.Rect(0, ty*size, p.SizeX, (ty+1)*size).Intersect(canvas.Rect)
+		tile := func(tx int) image.Rectangle {
+			return image.Rect(tx*size, band.Min.Y, (tx+1)*size, band.Max.Y).Intersect(band)
+		}
+		var pixels []image.Point
+		inside := make([]bool, tilesX)
+		for tx := range inside {
+			r := tile(tx)
+			pixels = pixels[:0]
+			// in order around the edge, so neighbors share a batch
+			for col := r.Min.X; col < r.Max.X; col++ {
+				pixels = append(pixels, image.Point{col, r.Min.Y})
+			}
+			for row := r.Min.Y + 1; row < r.Max.Y; row++ {
+				pixels = append(pixels, image.Point{r.Max.X - 1, row})
+			}
+			for col := r.Max.X - 2; col >= r.Min.X && r.Dy() > 1; col-- {
+				pixels = append(pixels, image.Point{col, r.Max.Y - 1})
+			}
+			for row := r.Max.Y - 2; row > r.Min.Y && r.Dx() > 1; row-- {
+				pixels = append(pixels, image.Point{r.Min.X, row})
+			}
+			inside[tx] = p.calcPixels(canvas, pixels)
+		}
+
+		// the rest of the tiles that are not filled go a row at a time,
+		// all of the row's pixels in one batch
+		for row := band.Min.Y + 1; row < band.Max.Y-1; row++ {
+			pixels = pixels[:0]
+			for tx := range inside {
+				r := tile(tx)
+				for col := r.Min.X + 1; col < r.Max.X-1; col++ {
+					if !inside[tx] {
+						pixels = append(pixels, image.Point{col, row})
+						continue
+					}
+					// every edge pixel has the interior color
+					canvas.SetNRGBA(col, row, canvas.NRGBAAt(r.Min.X, r.Min.Y))
+					p.report.tiledPixels(1)
+				}
+			}
+			p.calcPixels(canvas, pixels)
+		}
+	})
+	return canvas
+}
@@ -0,0 +1,76 @@
+package mandel
+
+import (
+	"image"
+	"testing"
+)
+
+// interiorViews are views with large areas inside the set, each with
+// boundary running through some of the tiles.
+var interiorViews = []struct {
+	name      string
+	x, y, mag float64
+}{
+	{"whole set", -0.75, 0, 0.4},
+	{"cusp", 0.25, 0, 10},
+	{"period-2 bulb", -1.25, 0.2, 8},
+	{"minibrot", -1.7548776, 0, 2000},
+	{"seahorse valley", -0.75, 0.1, 20},
+}
+
+// TestInteriorTiles checks that InteriorTiles never fills a tile that has
+// boundary in it: every view comes out pixel for pixel as it does without
+// tiles.
+func TestInteriorTiles(t *testing.T) {
+	for _, view := range interiorViews {
+		render := func(tiles bool) (*image.NRGBA, int64) {
+			var tiled int64
+			p := Parameters{CenterX: view.x, CenterY: view.y, Magnification: view.mag, MaxIterations: 2000, SizeX: 193, SizeY: 129, AntiAlias: 2, Palette: DefaultPalette(), InteriorTiles: tiles}
+			p.Report = func(r *RenderReport) { tiled = r.TiledPixels }
+			if err := p.Init(); err != nil {
+				t.Fatal(err)
+			}
+			img, err := p.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return img, tiled
+		}
+		want, _ := render(false)
+		got, tiled := render(true)
+		if tiled == 0 {
+			t.Errorf("%s: no tiles were filled", view.name)
+		}
+		for y := 0; y < want.Rect.Dy(); y++ {
+			for x := 0; x < want.Rect.Dx(); x++ {
+				if g, w := got.NRGBAAt(x, y), want.NRGBAAt(x, y); g != w {
+					t.Errorf("%s: pixel (%d, %d) is %v with tiles, %v without", view.name, x, y, g, w)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkInteriorTiles renders the interior views with and without
+// InteriorTiles.
+func BenchmarkInteriorTiles(b *testing.B) {
+	for _, view := range interiorViews {
+		for _, tiles := range []bool{false, true} {
+			p := Parameters{CenterX: view.x, CenterY: view.y, Magnification: view.mag, MaxIterations: 5000, SizeX: 320, SizeY: 240, AntiAlias: 1, Palette: DefaultPalette(), InteriorTiles: tiles}
+			if err := p.Init(); err != nil {
+				b.Fatal(err)
+			}
+			name := view.name + "/pixels"
+			if tiles {
+				name = view.name + "/tiles"
+			}
+			b.Run(name, func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					if _, err := p.Generate(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
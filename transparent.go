@@ -0,0 +1,27 @@
+package mandel
+
+import "fmt"
+
+// initTransparent checks the Transparent setting.
+func (p *Parameters) initTransparent() error {
+	switch p.Transparent {
+	case "", "exterior":
+	case "interior":
+		if p.interiorColoring() {
+			return fmt.Errorf("a transparent interior cannot also be colored by %s interior coloring", p.InteriorColoring)
+		}
+	default:
+		return fmt.Errorf("unknown transparent region %q", p.Transparent)
+	}
+	return nil
+}
+
+// clearSample reports whether Transparent leaves a sample inside the set,
+// or one outside it, fully transparent. The alpha of the palette and
+// InsideColor carries through to the image either way.
+func (p *Parameters) clearSample(inside bool) bool {
+	if inside {
+		return p.Transparent == "interior"
+	}
+	return p.Transparent == "exterior"
+}
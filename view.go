@@ -0,0 +1,177 @@
+package mandel
+
+import (
+	"fmt"
+	"math"
+)
+
+// initView combines Transform and Rotation into the matrix that
+// planeOffset applies to offsets from the center of the image.
+func (p *Parameters) initView() error {
+	m := [4]float64{1, 0, 0, 1}
+	switch len(p.Transform) {
+	case 0:
+	case 4:
+		copy(m[:], p.Transform)
+	default:
+		return fmt.Errorf("view transform must have 4 entries, not %d", len(p.Transform))
+	}
+	if math.IsNaN(p.Rotation) || math.IsInf(p.Rotation, 0) {
+		return fmt.Errorf("rotation must be a finite number of degrees")
+	}
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return fmt.Errorf("view transform must be finite and invertible")
+	}
+
+	// turning the image counterclockwise turns the plane under it the
+	// other way
+	if p.Rotation != 0 {
+		sin, cos := math.Sincos(-p.Rotation * math.Pi / 180)
+		m = [4]float64{
+			cos*m[0] - sin*m[2], cos*m[1] - sin*m[3],
+			sin*m[0] + cos*m[2], sin*m[1] + cos*m[3],
+		}
+	}
+	p.view = m
+	p.viewSet = len(p.Transform) > 0 || p.Rotation != 0
+	return nil
+}
+
+// viewOffset maps an offset from the center of the unturned view onto the
+// plane.
+func (p *Parameters) viewOffset(dx, dy float64) (float64, float64) {
+	if !p.viewSet {
+		return dx, dy
+	}
+	m := p.view
+	return float64(m[0]*dx) + float64(m[1]*dy), float64(m[2]*dx) + float64(m[3]*dy)
+}
+
+// unviewOffset is the inverse of viewOffset.
+func (p *Parameters) unviewOffset(dx, dy float64) (float64, float64) {
+	if !p.viewSet {
+		return dx, dy
+	}
+	m := p.view
+	det := m[0]*m[3] - m[1]*m[2]
+	return (m[3]*dx - m[1]*dy) / det, (m[0]*dy - m[2]*dx) / det
+}
+
+// pixelSize is the distance on the plane from one pixel to the next,
+// taking the geometric mean of the stretch of a view transform.
+func (p *Parameters) pixelSize() float64 {
+	minsize := p.SizeX
+	if p.SizeY < p.SizeX {
+		minsize = p.SizeY
+	}
+	size := 1 / (math.Abs(p.Magnification) * float64(minsize-1))
+	if p.viewSet {
+		m := p.view
+		size *= math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+	}
+	return size
+}
+
+// PixelToComplex returns the point on the complex plane at (fx, fy) in
+// image coordinates, where pixel (col, row) covers [col, col+1) ×
+// [row, row+1), so its center is at (col+0.5, row+0.5). It follows the
+// view exactly as rendering does, with bounds, exponential maps, sample
+// offsets, Rotation, and Transform.
+func (p *Parameters) PixelToComplex(fx, fy float64) (x, y float64, err error) {
+	if err := p.checkInit("PixelToComplex"); err != nil {
+		return 0, 0, err
+	}
+	x, y = p.pixelToComplex(fx, fy)
+	return x, y, nil
+}
+
+// pixelToComplex is PixelToComplex without the check.
+func (p *Parameters) pixelToComplex(fx, fy float64) (x, y float64) {
+	col, row := math.Floor(fx), math.Floor(fy)
+	return p.toPlane(int(col), int(row), fx-col-0.5, row+0.5-fy)
+}
+
+// ComplexToPixel is the inverse of PixelToComplex, returning where the
+// point (x, y) on the complex plane falls in image coordinates. Points
+// outside the image map to coordinates outside [0, SizeX) × [0, SizeY).
+func (p *Parameters) ComplexToPixel(x, y float64) (fx, fy float64, err error) {
+	if err := p.checkInit("ComplexToPixel"); err != nil {
+		return 0, 0, err
+	}
+	fx, fy = p.toPixel(x, y)
+	return fx, fy, nil
+}
+
+// toPixel is ComplexToPixel without the check.
+func (p *Parameters) toPixel(x, y float64) (fx, fy float64) {
+	if p.viewSet {
+		ox, oy := p.unviewOffset(x-p.CenterX, y-p.CenterY)
+		x, y = p.CenterX+ox, p.CenterY+oy
+	}
+	if p.ExpMap {
+		dx, dy := x-p.CenterX, y-p.CenterY
+		if p.Magnification < 0 {
+			dx = -dx
+		}
+		// measure the angle from the start of the map the way it turns
+		start, span := p.expMapAngles()
+		turn := math.Mod(math.Atan2(dy, dx)-start, 2*math.Pi)
+		if span > 0 && turn < 0 {
+			turn += 2 * math.Pi
+		} else if span < 0 && turn > 0 {
+			turn -= 2 * math.Pi
+		}
+		fx = turn/span*float64(p.SizeX) - p.SampleOffsetX
+		fy = p.expMapDepth(math.Hypot(dx, dy))*float64(p.SizeY) - p.SampleOffsetY
+		return fx, fy
+	}
+	if p.boundsSet() {
+		fx = (x-p.MinX)/(p.MaxX-p.MinX)*float64(p.SizeX) - p.SampleOffsetX
+		fy = (p.MaxY-y)/(p.MaxY-p.MinY)*float64(p.SizeY) - p.SampleOffsetY
+		return fx, fy
+	}
+	minsize := p.SizeX
+	if p.SizeY < p.SizeX {
+		minsize = p.SizeY
+	}
+	scale := math.Abs(p.Magnification) * float64(minsize-1)
+	dx := x - p.CenterX
+	if p.Magnification < 0 {
+		dx = -dx
+	}
+	fx = dx*scale + float64(p.SizeX/2) + 0.5 - p.SampleOffsetX
+	fy = float64(p.SizeY/2) - (y-p.CenterY)*scale + 0.5 - p.SampleOffsetY
+	return fx, fy
+}
+
+// FitBounds sets CenterX, CenterY, and Magnification to frame the
+// rectangle from (minX, minY) to (maxX, maxY) on the complex plane in an
+// image of SizeX by SizeY pixels, as FitBounds does for new parameters,
+// and clears the bounds and precise center that would override them.
+// When the aspect ratios differ, the rectangle is centered and the image
+// shows extra space along one axis. A minX greater than maxX mirrors the
+// view, so FitBounds(p.Bounds()) frames the same view as p, unless p is
+// given by bounds that stretch its pixels.
+func (p *Parameters) FitBounds(minX, minY, maxX, maxY float64) {
+	minsize := p.SizeX
+	if p.SizeY < p.SizeX {
+		minsize = p.SizeY
+	}
+	// toPlane spans minsize-1 pixels per 1/Magnification units
+	width, height := maxX-minX, math.Abs(maxY-minY)
+	scale := math.Min(float64(p.SizeX)/math.Abs(width), float64(p.SizeY)/height)
+
+	// the center point is at the middle of pixel (SizeX/2, SizeY/2), which
+	// is half a pixel off the middle of the image along even dimensions
+	offX := float64(p.SizeX)/2 - 0.5 - float64(p.SizeX/2)
+	offY := float64(p.SizeY)/2 - 0.5 - float64(p.SizeY/2)
+	p.Magnification = scale / float64(minsize-1)
+	if width < 0 {
+		p.Magnification, offX = -p.Magnification, -offX
+	}
+	p.CenterX = (minX+maxX)/2 - offX/scale
+	p.CenterY = (minY+maxY)/2 + offY/scale
+	p.MinX, p.MinY, p.MaxX, p.MaxY = 0, 0, 0, 0
+	p.PreciseX, p.PreciseY = "", ""
+}